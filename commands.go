@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/graph"
+	"github.com/takahashinaoki/obsidiantui/internal/export"
+	"github.com/takahashinaoki/obsidiantui/internal/publish"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// openIndexedVault opens the vault for a non-interactive command and waits for its index
+func openIndexedVault(args []string) (*vault.Vault, error) {
+	if err := config.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	vaultPath, err := resolveVaultPath(args)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vault.NewVault(vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault at %s: %w", vaultPath, err)
+	}
+	v.WaitIndex()
+
+	return v, nil
+}
+
+func newGraphCmd() *cobra.Command {
+	var (
+		format string
+		output string
+		opts   graph.ExportOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph [vault-path]",
+		Short: "Export the vault's link graph",
+		Long:  `Export notes, links, tags and frontmatter attributes as Graphviz DOT, GraphML or JSON.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := graph.Format(format)
+			valid := false
+			for _, known := range graph.Formats {
+				valid = valid || f == known
+			}
+			if !valid {
+				return fmt.Errorf("unknown format %q (want dot, graphml or json)", format)
+			}
+
+			v, err := openIndexedVault(args)
+			if err != nil {
+				return err
+			}
+
+			if opts.Focus != "" {
+				focus := v.FindFile(opts.Focus)
+				if focus == "" {
+					focus = v.FindFile(opts.Focus + ".md")
+				}
+				if focus == "" {
+					return fmt.Errorf("note not found: %s", opts.Focus)
+				}
+				opts.Focus = focus
+			}
+
+			g := graph.Collect(v, opts)
+
+			if output == "" || output == "-" {
+				return g.Write(os.Stdout, f)
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			if err := g.Write(file, f); err != nil {
+				file.Close()
+				return err
+			}
+			return file.Close()
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "dot", "output format: dot, graphml or json")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file (default stdout)")
+	cmd.Flags().StringVar(&opts.Focus, "focus", "", "only export the neighbourhood of this note")
+	cmd.Flags().IntVar(&opts.Depth, "depth", 1, "link hops around --focus")
+	cmd.Flags().StringVar(&opts.Tag, "tag", "", "only export notes with this tag")
+
+	return cmd
+}
+
+func newExportCmd() *cobra.Command {
+	var (
+		sel     export.Selection
+		format  string
+		output  string
+		theme   string
+		cssFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [vault-path]",
+		Short: "Export notes to HTML, print-ready HTML or a flattened Markdown bundle",
+		Long: `Export the notes selected by --note, --folder and --tag.
+Wiki links between exported notes become relative links, embeds are inlined
+and math is rendered. Links to notes outside the selection become plain text.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := export.Options{Format: export.Format(format), OutDir: output, Theme: theme}
+			valid := false
+			for _, known := range export.Formats {
+				valid = valid || opts.Format == known
+			}
+			if !valid {
+				return fmt.Errorf("unknown format %q (want html, pdf or markdown)", format)
+			}
+
+			if cssFile != "" {
+				css, err := os.ReadFile(cssFile)
+				if err != nil {
+					return err
+				}
+				opts.CSS = string(css)
+			}
+
+			v, err := openIndexedVault(args)
+			if err != nil {
+				return err
+			}
+
+			if sel.Note != "" {
+				note := v.FindFile(sel.Note)
+				if note == "" {
+					note = v.FindFile(sel.Note + ".md")
+				}
+				if note == "" {
+					return fmt.Errorf("note not found: %s", sel.Note)
+				}
+				sel.Note = note
+			}
+
+			written, err := export.Export(v, export.SelectNotes(v, sel), opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Exported %d file(s) to %s\n", len(written), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sel.Note, "note", "", "export a single note")
+	cmd.Flags().StringVar(&sel.Folder, "folder", "", "export every note in this folder")
+	cmd.Flags().StringVar(&sel.Tag, "tag", "", "export notes with this tag")
+	cmd.Flags().StringVarP(&format, "format", "f", "html", "output format: html, pdf or markdown")
+	cmd.Flags().StringVarP(&output, "output", "o", "export", "output directory")
+	cmd.Flags().StringVar(&theme, "theme", "light", "HTML theme: light or dark")
+	cmd.Flags().StringVar(&cssFile, "css", "", "custom stylesheet for HTML output")
+
+	return cmd
+}
+
+func newPublishCmd() *cobra.Command {
+	var opts publish.Options
+
+	cmd := &cobra.Command{
+		Use:   "publish [vault-path]",
+		Short: "Generate a static website from part of the vault",
+		Long: `Publish notes selected by --folder, --tag or "publish: true" frontmatter as a
+static website with folder index pages, backlinks, tag pages, a search index
+and a graph page. Notes with "publish: false" are never published, and links
+to unpublished notes become plain text.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := openIndexedVault(args)
+			if err != nil {
+				return err
+			}
+
+			result, err := publish.Build(v, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Published %d note(s) as %d page(s) to %s\n", result.Notes, result.Pages, opts.OutDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Folders, "folder", nil, "publish notes in this folder (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.Tags, "tag", nil, "publish notes with this tag (repeatable)")
+	cmd.Flags().StringVarP(&opts.OutDir, "output", "o", "site", "output directory")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "site title (default vault name)")
+	cmd.Flags().StringVar(&opts.Theme, "theme", "light", "theme: light or dark")
+
+	return cmd
+}
@@ -8,14 +8,62 @@ import (
 )
 
 type Config struct {
-	VaultPath    string `mapstructure:"vault_path"`
-	LastOpenFile string `mapstructure:"last_open_file"`
-	Theme        string `mapstructure:"theme"`
-	EditorMode   string `mapstructure:"editor_mode"`
+	VaultPath    string        `mapstructure:"vault_path"`
+	Vaults       []VaultConfig `mapstructure:"vaults"`
+	LastOpenFile string        `mapstructure:"last_open_file"`
+	Theme        string        `mapstructure:"theme"`
+	EditorMode   string        `mapstructure:"editor_mode"`
+
+	GitAutoCommitInterval int  `mapstructure:"git_auto_commit_interval"` // minutes, 0 disables
+	GitCommitOnSave       bool `mapstructure:"git_commit_on_save"`
+	GitAutoPush           bool `mapstructure:"git_auto_push"`
+
+	HistoryMaxVersions   int `mapstructure:"history_max_versions"`   // per note, 0 for no limit
+	HistoryRetentionDays int `mapstructure:"history_retention_days"` // 0 keeps versions forever
+	HistoryInterval      int `mapstructure:"history_interval"`       // minutes between snapshots of unsaved edits, 0 disables
+
+	AutosaveIdle          int  `mapstructure:"autosave_idle"` // seconds without input before saving, 0 disables
+	AutosaveOnFocusChange bool `mapstructure:"autosave_on_focus_change"`
+	AutosaveOnFileSwitch  bool `mapstructure:"autosave_on_file_switch"`
+	SwapFiles             bool `mapstructure:"swap_files"`
+
+	FileSort           string   `mapstructure:"file_sort"` // name, modified, created, size or links
+	FileSortDescending bool     `mapstructure:"file_sort_descending"`
+	FileColumns        []string `mapstructure:"file_columns"` // modified, words, links, tasks or notes
+	FolderNotes        bool     `mapstructure:"folder_notes"` // Folder/Folder.md stands for Folder
+
+	OutlineSidebar bool `mapstructure:"outline_sidebar"` // outline shown beside the editor
+
+	RestoreSession bool `mapstructure:"restore_session"`
+	VaultPicker    bool `mapstructure:"vault_picker"` // ask which vault to open at startup
+
+	Keymap KeymapConfig `mapstructure:"keymap"`
+}
+
+// KeymapConfig rebinds keys. Each map goes from an action name to the key
+// sequences that run it, e.g. quick_switch: ["ctrl+p", "<leader> f"].
+type KeymapConfig struct {
+	Leader   string              `mapstructure:"leader"`
+	Global   map[string][]string `mapstructure:"global"`
+	Editor   map[string][]string `mapstructure:"editor"` // normal mode
+	Tree     map[string][]string `mapstructure:"tree"`   // file tree
+	Preview  map[string][]string `mapstructure:"preview"`
+	Commands map[string][]string `mapstructure:"commands"` // by palette command ID
+}
+
+// VaultConfig is a known vault. Settings overrides the global settings while
+// the vault is open, using the same keys, e.g. git_auto_push: true.
+type VaultConfig struct {
+	Name     string                 `mapstructure:"name"`
+	Path     string                 `mapstructure:"path"`
+	Settings map[string]interface{} `mapstructure:"settings"`
 }
 
 var AppConfig *Config
 
+// activeVault is the vault whose overrides are applied to AppConfig
+var activeVault string
+
 func Init() error {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -35,6 +83,24 @@ func Init() error {
 	viper.SetDefault("last_open_file", "")
 	viper.SetDefault("theme", "default")
 	viper.SetDefault("editor_mode", "normal")
+	viper.SetDefault("git_auto_commit_interval", 0)
+	viper.SetDefault("git_commit_on_save", false)
+	viper.SetDefault("git_auto_push", false)
+	viper.SetDefault("history_max_versions", 50)
+	viper.SetDefault("history_retention_days", 30)
+	viper.SetDefault("history_interval", 5)
+	viper.SetDefault("autosave_idle", 0)
+	viper.SetDefault("autosave_on_focus_change", false)
+	viper.SetDefault("autosave_on_file_switch", false)
+	viper.SetDefault("swap_files", true)
+	viper.SetDefault("file_sort", "name")
+	viper.SetDefault("file_sort_descending", false)
+	viper.SetDefault("file_columns", []string{})
+	viper.SetDefault("folder_notes", true)
+	viper.SetDefault("outline_sidebar", false)
+	viper.SetDefault("restore_session", true)
+	viper.SetDefault("vault_picker", true)
+	viper.SetDefault("keymap.leader", "space")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -54,13 +120,123 @@ func Init() error {
 }
 
 func Save() error {
-	viper.Set("vault_path", AppConfig.VaultPath)
-	viper.Set("last_open_file", AppConfig.LastOpenFile)
-	viper.Set("theme", AppConfig.Theme)
-	viper.Set("editor_mode", AppConfig.EditorMode)
+	store()
 	return viper.WriteConfig()
 }
 
+// store copies AppConfig into viper. Settings overridden by the current vault
+// are written back to its overrides rather than the global settings.
+func store() {
+	vc := currentVault()
+	set := func(key string, value interface{}) {
+		if vc != nil {
+			if _, ok := vc.Settings[key]; ok {
+				vc.Settings[key] = value
+				return
+			}
+		}
+		viper.Set(key, value)
+	}
+
+	viper.Set("vault_path", AppConfig.VaultPath)
+	set("last_open_file", AppConfig.LastOpenFile)
+	set("theme", AppConfig.Theme)
+	set("editor_mode", AppConfig.EditorMode)
+	set("git_auto_commit_interval", AppConfig.GitAutoCommitInterval)
+	set("git_commit_on_save", AppConfig.GitCommitOnSave)
+	set("git_auto_push", AppConfig.GitAutoPush)
+	set("history_max_versions", AppConfig.HistoryMaxVersions)
+	set("history_retention_days", AppConfig.HistoryRetentionDays)
+	set("history_interval", AppConfig.HistoryInterval)
+	set("autosave_idle", AppConfig.AutosaveIdle)
+	set("autosave_on_focus_change", AppConfig.AutosaveOnFocusChange)
+	set("autosave_on_file_switch", AppConfig.AutosaveOnFileSwitch)
+	set("swap_files", AppConfig.SwapFiles)
+	set("file_sort", AppConfig.FileSort)
+	set("file_sort_descending", AppConfig.FileSortDescending)
+	set("file_columns", AppConfig.FileColumns)
+	set("folder_notes", AppConfig.FolderNotes)
+	set("outline_sidebar", AppConfig.OutlineSidebar)
+	set("restore_session", AppConfig.RestoreSession)
+	viper.Set("vault_picker", AppConfig.VaultPicker)
+	storeVaults()
+}
+
+func storeVaults() {
+	vaults := make([]map[string]interface{}, len(AppConfig.Vaults))
+	for i, vc := range AppConfig.Vaults {
+		vaults[i] = map[string]interface{}{"name": vc.Name, "path": vc.Path}
+		if len(vc.Settings) > 0 {
+			vaults[i]["settings"] = vc.Settings
+		}
+	}
+	viper.Set("vaults", vaults)
+}
+
+// currentVault returns the entry whose overrides are applied, or nil
+func currentVault() *VaultConfig {
+	return FindVault(activeVault)
+}
+
+// FindVault looks up a known vault by name or path
+func FindVault(nameOrPath string) *VaultConfig {
+	if nameOrPath == "" {
+		return nil
+	}
+	for i, vc := range AppConfig.Vaults {
+		if vc.Name == nameOrPath {
+			return &AppConfig.Vaults[i]
+		}
+	}
+	abs, err := filepath.Abs(nameOrPath)
+	if err != nil {
+		return nil
+	}
+	for i, vc := range AppConfig.Vaults {
+		if p, err := filepath.Abs(vc.Path); err == nil && p == abs {
+			return &AppConfig.Vaults[i]
+		}
+	}
+	return nil
+}
+
+// UseVault makes path the current vault, adding it to the known vaults, and
+// applies its settings overrides on top of the global settings
+func UseVault(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	// Write back the outgoing vault's settings before they are replaced
+	store()
+
+	vc := FindVault(abs)
+	if vc == nil {
+		AppConfig.Vaults = append(AppConfig.Vaults, VaultConfig{Name: filepath.Base(abs), Path: abs})
+		vc = &AppConfig.Vaults[len(AppConfig.Vaults)-1]
+	}
+	AppConfig.VaultPath = abs
+	viper.Set("vault_path", abs)
+	storeVaults()
+
+	merged := viper.New()
+	if err := merged.MergeConfigMap(viper.AllSettings()); err != nil {
+		return err
+	}
+	if err := merged.MergeConfigMap(vc.Settings); err != nil {
+		return err
+	}
+
+	cfg := &Config{}
+	if err := merged.Unmarshal(cfg); err != nil {
+		return err
+	}
+	AppConfig = cfg
+	activeVault = abs
+	return nil
+}
+
 func SetVaultPath(path string) {
 	AppConfig.VaultPath = path
 }
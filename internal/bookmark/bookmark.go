@@ -0,0 +1,430 @@
+// Package bookmark reads and writes the vault's bookmarks in Obsidian's
+// format, .obsidian/bookmarks.json, so both apps share the same list.
+package bookmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Item types used by Obsidian
+const (
+	TypeFile   = "file"
+	TypeFolder = "folder"
+	TypeSearch = "search"
+	TypeGroup  = "group"
+	TypeURL    = "url"
+)
+
+// Item is a bookmark or a group of them. Fields this package does not know
+// about, e.g. a graph bookmark's options, are kept as they were, and fields
+// are written in the order they were read, as Obsidian writes them.
+type Item struct {
+	Type    string  `json:"type"`
+	Ctime   int64   `json:"ctime"`             // creation time in Unix milliseconds
+	Path    string  `json:"path,omitempty"`    // vault-relative, slash separated
+	Subpath string  `json:"subpath,omitempty"` // "#Heading" or "#^block" within a file
+	Query   string  `json:"query,omitempty"`   // for saved searches
+	URL     string  `json:"url,omitempty"`
+	Title   string  `json:"title,omitempty"` // display name; required for groups
+	Items   []*Item `json:"items,omitempty"` // children of a group
+
+	order []string // field names as read
+	extra map[string]json.RawMessage
+}
+
+// item has Item's fields without its JSON methods
+type item Item
+
+var knownFields = []string{"type", "ctime", "path", "subpath", "query", "url", "title", "items"}
+
+func (it *Item) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*item)(it)); err != nil {
+		return err
+	}
+	order, fields, err := readObject(data)
+	if err != nil {
+		return err
+	}
+	for _, f := range knownFields {
+		delete(fields, f)
+	}
+	it.order, it.extra = order, fields
+	return nil
+}
+
+func (it Item) MarshalJSON() ([]byte, error) {
+	data, err := marshal(item(it))
+	if err != nil {
+		return nil, err
+	}
+	_, fields, err := readObject(data)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range it.extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	// Obsidian expects every group to have an items list, even an empty one
+	if it.Type == TypeGroup {
+		if _, ok := fields["items"]; !ok {
+			fields["items"] = json.RawMessage("[]")
+		}
+	}
+	return writeObject(append(slices.Clone(it.order), knownFields...), fields)
+}
+
+// readObject decodes a JSON object into its fields and their order
+func readObject(data []byte) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if t != json.Delim('{') {
+		return nil, nil, errors.New("bookmark: expected a JSON object")
+	}
+
+	var order []string
+	fields := make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := t.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := fields[key]; !ok {
+			order = append(order, key)
+		}
+		fields[key] = v
+	}
+	return order, fields, nil
+}
+
+// writeObject encodes fields as a JSON object, in order first and the rest
+// sorted by name
+func writeObject(order []string, fields map[string]json.RawMessage) ([]byte, error) {
+	keys := make([]string, 0, len(fields))
+	written := make(map[string]bool, len(fields))
+	for _, k := range order {
+		if _, ok := fields[k]; ok && !written[k] {
+			keys = append(keys, k)
+			written[k] = true
+		}
+	}
+	var rest []string
+	for k := range fields {
+		if !written[k] {
+			rest = append(rest, k)
+		}
+	}
+	slices.Sort(rest)
+	keys = append(keys, rest...)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(fields[k])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// marshal is json.Marshal without escaping <, > and &, which Obsidian
+// writes as they are
+func marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// NewNote bookmarks a note, or a heading or block in it when subpath is set.
+// relPath is a vault-relative path as used by the vault package.
+func NewNote(relPath, subpath string) *Item {
+	return &Item{Type: TypeFile, Ctime: now(), Path: filepath.ToSlash(relPath), Subpath: subpath}
+}
+
+// NewFolder bookmarks a folder
+func NewFolder(relPath string) *Item {
+	return &Item{Type: TypeFolder, Ctime: now(), Path: filepath.ToSlash(relPath)}
+}
+
+// NewSearch bookmarks a search query
+func NewSearch(query string) *Item {
+	return &Item{Type: TypeSearch, Ctime: now(), Query: query}
+}
+
+// NewGroup creates an empty group
+func NewGroup(title string) *Item {
+	return &Item{Type: TypeGroup, Ctime: now(), Title: title, Items: []*Item{}}
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// RelPath is the item's path in the vault package's form
+func (it *Item) RelPath() string {
+	return filepath.FromSlash(it.Path)
+}
+
+// Label is the name shown for the item: its title, or one derived from what
+// it points at
+func (it *Item) Label() string {
+	if it.Title != "" {
+		return it.Title
+	}
+	switch it.Type {
+	case TypeFile:
+		name := strings.TrimSuffix(path.Base(it.Path), ".md")
+		if it.Subpath != "" {
+			return name + " › " + strings.TrimPrefix(strings.TrimPrefix(it.Subpath, "#"), "^")
+		}
+		return name
+	case TypeFolder:
+		return path.Base(it.Path)
+	case TypeSearch:
+		return it.Query
+	case TypeURL:
+		return it.URL
+	case TypeGroup:
+		return "Untitled group"
+	}
+	return it.Type
+}
+
+// Store is the bookmarks file of a vault
+type Store struct {
+	path  string
+	Items []*Item
+
+	order []string // field names as read
+	extra map[string]json.RawMessage
+}
+
+// File is where Obsidian keeps the bookmarks of the vault at vaultPath
+func File(vaultPath string) string {
+	return filepath.Join(vaultPath, ".obsidian", "bookmarks.json")
+}
+
+// Load reads a bookmarks file. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if s.order, s.extra, err = readObject(data); err != nil {
+		return nil, err
+	}
+	if raw, ok := s.extra["items"]; ok {
+		if err := json.Unmarshal(raw, &s.Items); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file, indented the way Obsidian does
+func (s *Store) Save() error {
+	fields := maps.Clone(s.extra)
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+	items := s.Items
+	if items == nil {
+		items = []*Item{}
+	}
+	raw, err := marshal(items)
+	if err != nil {
+		return err
+	}
+	fields["items"] = raw
+
+	data, err := writeObject(s.order, fields)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return vault.AtomicWriteFile(s.path, out.Bytes(), 0644)
+}
+
+// Add appends an item at the top level
+func (s *Store) Add(it *Item) {
+	s.Items = append(s.Items, it)
+}
+
+// Walk calls fn for every item depth first, groups before their children.
+// depth is 0 for top-level items.
+func (s *Store) Walk(fn func(it *Item, depth int)) {
+	var walk func(items []*Item, depth int)
+	walk = func(items []*Item, depth int) {
+		for _, it := range items {
+			fn(it, depth)
+			if it.Type == TypeGroup {
+				walk(it.Items, depth+1)
+			}
+		}
+	}
+	walk(s.Items, 0)
+}
+
+// All returns every bookmark that is not a group, depth first
+func (s *Store) All() []*Item {
+	var all []*Item
+	s.Walk(func(it *Item, _ int) {
+		if it.Type != TypeGroup {
+			all = append(all, it)
+		}
+	})
+	return all
+}
+
+// Find returns the first bookmark of the given type whose path (or query,
+// for searches) and subpath match, or nil
+func (s *Store) Find(typ, target, subpath string) *Item {
+	var found *Item
+	s.Walk(func(it *Item, _ int) {
+		if found != nil || it.Type != typ || it.Subpath != subpath {
+			return
+		}
+		switch typ {
+		case TypeSearch:
+			if it.Query == target {
+				found = it
+			}
+		default:
+			if it.Path == filepath.ToSlash(target) {
+				found = it
+			}
+		}
+	})
+	return found
+}
+
+// locate finds the list holding it, its index there, and the group owning
+// the list (nil at the top level)
+func (s *Store) locate(it *Item) (list *[]*Item, index int, parent *Item) {
+	var search func(items *[]*Item, owner *Item) bool
+	search = func(items *[]*Item, owner *Item) bool {
+		for i, x := range *items {
+			if x == it {
+				list, index, parent = items, i, owner
+				return true
+			}
+			if x.Type == TypeGroup && search(&x.Items, x) {
+				return true
+			}
+		}
+		return false
+	}
+	if !search(&s.Items, nil) {
+		return nil, -1, nil
+	}
+	return list, index, parent
+}
+
+// Remove deletes an item, with its children if it is a group
+func (s *Store) Remove(it *Item) bool {
+	list, i, _ := s.locate(it)
+	if list == nil {
+		return false
+	}
+	*list = append((*list)[:i], (*list)[i+1:]...)
+	return true
+}
+
+// Move shifts an item up (delta < 0) or down among its siblings
+func (s *Store) Move(it *Item, delta int) bool {
+	list, i, _ := s.locate(it)
+	if list == nil {
+		return false
+	}
+	j := i + delta
+	if j < 0 || j >= len(*list) {
+		return false
+	}
+	(*list)[i], (*list)[j] = (*list)[j], (*list)[i]
+	return true
+}
+
+// Indent moves an item into the group just above it
+func (s *Store) Indent(it *Item) bool {
+	list, i, _ := s.locate(it)
+	if list == nil || i == 0 || (*list)[i-1].Type != TypeGroup {
+		return false
+	}
+	group := (*list)[i-1]
+	*list = append((*list)[:i], (*list)[i+1:]...)
+	group.Items = append(group.Items, it)
+	return true
+}
+
+// Outdent moves an item out of its group, to just after the group
+func (s *Store) Outdent(it *Item) bool {
+	list, i, parent := s.locate(it)
+	if list == nil || parent == nil {
+		return false
+	}
+	*list = append((*list)[:i], (*list)[i+1:]...)
+	outer, j, _ := s.locate(parent)
+	*outer = append((*outer)[:j+1], append([]*Item{it}, (*outer)[j+1:]...)...)
+	return true
+}
+
+// Rename updates bookmarks after a note or folder moves from oldPath to
+// newPath, including bookmarks of anything inside a moved folder. It returns
+// how many bookmarks changed.
+func (s *Store) Rename(oldPath, newPath string) int {
+	oldPath, newPath = filepath.ToSlash(oldPath), filepath.ToSlash(newPath)
+	changed := 0
+	s.Walk(func(it *Item, _ int) {
+		if it.Type != TypeFile && it.Type != TypeFolder {
+			return
+		}
+		if it.Path == oldPath {
+			it.Path = newPath
+			changed++
+		} else if rest, ok := strings.CutPrefix(it.Path, oldPath+"/"); ok {
+			it.Path = newPath + "/" + rest
+			changed++
+		}
+	})
+	return changed
+}
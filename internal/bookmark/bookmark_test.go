@@ -0,0 +1,199 @@
+package bookmark
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// load reads testdata/bookmarks.json from a copy the test may overwrite
+func load(t *testing.T) (*Store, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "bookmarks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := File(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, data
+}
+
+func labels(items []*Item) []string {
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Label())
+	}
+	return got
+}
+
+func TestRoundTrip(t *testing.T) {
+	s, want := load(t)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("saved file differs from the one read:\n%s", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s, _ := load(t)
+
+	var got []string
+	s.Walk(func(it *Item, depth int) {
+		got = append(got, strings.Repeat("  ", depth)+it.Label())
+	})
+	want := []string{"Plan", "Plan › Goals", "Work", "  Archive", "  Local graph", "  Empty", `tag:#todo path:"Notes"`, "Obsidian"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk() = %q, want %q", got, want)
+	}
+	if n := len(s.All()); n != 6 {
+		t.Errorf("All() has %d bookmarks, want 6", n)
+	}
+
+	if it := s.Find(TypeFile, "Notes/Plan.md", "#Goals & <risks>"); it == nil || it.Title != "Plan › Goals" {
+		t.Errorf("Find(heading) = %+v", it)
+	}
+	if it := s.Find(TypeFolder, filepath.FromSlash("Notes/Archive"), ""); it == nil || it.Ctime != 1700000000003 {
+		t.Errorf("Find(folder) = %+v", it)
+	}
+	if it := s.Find(TypeSearch, `tag:#todo path:"Notes"`, ""); it == nil {
+		t.Error("Find(search) found nothing")
+	}
+	if it := s.Find(TypeFile, "Notes/Other.md", ""); it != nil {
+		t.Errorf("Find(missing) = %+v", it)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(File(t.TempDir()))
+	if err != nil || len(s.Items) != 0 {
+		t.Fatalf("Load() of a missing file = %v, %v", s, err)
+	}
+
+	s.Add(NewGroup("New"))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"items": []`)) {
+		t.Errorf("empty group saved without items:\n%s", data)
+	}
+}
+
+func TestRename(t *testing.T) {
+	s, _ := load(t)
+
+	// Only whole path segments match: Notes/Plan.md is not inside Note
+	if n := s.Rename("Note", "Other"); n != 0 {
+		t.Errorf("Rename(Note) changed %d bookmarks, want 0", n)
+	}
+	if n := s.Rename("Notes", "Docs"); n != 3 {
+		t.Errorf("Rename(Notes) changed %d bookmarks, want 3", n)
+	}
+	for _, it := range s.All() {
+		if strings.HasPrefix(it.Path, "Notes") {
+			t.Errorf("%s still has the old path %s", it.Label(), it.Path)
+		}
+	}
+	if s.Find(TypeFolder, "Docs/Archive", "") == nil {
+		t.Error("folder inside the renamed folder was not moved")
+	}
+	if n := s.Rename("Docs/Plan.md", "Docs/Plans.md"); n != 2 {
+		t.Errorf("Rename(note) changed %d bookmarks, want both of the note", n)
+	}
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	s, _ := load(t)
+	first, work := s.Items[0], s.Items[2]
+
+	if s.Move(first, -1) {
+		t.Error("Move() moved the first item up")
+	}
+	if !s.Move(first, 1) || s.Items[1] != first {
+		t.Errorf("Move(down) = %q", labels(s.Items))
+	}
+	if s.Move(work.Items[2], 1) {
+		t.Error("Move() moved the last item of a group down")
+	}
+
+	if !s.Remove(work) {
+		t.Fatal("Remove() did not find the group")
+	}
+	if got := len(s.All()); got != 4 {
+		t.Errorf("after removing a group %d bookmarks are left, want 4", got)
+	}
+	if s.Remove(work) {
+		t.Error("Remove() removed an item twice")
+	}
+}
+
+func TestIndentOutdent(t *testing.T) {
+	s, _ := load(t)
+	plan, work := s.Items[0], s.Items[2]
+	search := s.Items[3]
+	archive, empty := work.Items[0], work.Items[2]
+
+	// Nothing above the first item, and a group only takes what follows it
+	if s.Indent(plan) {
+		t.Error("Indent() moved the first item")
+	}
+	if s.Indent(s.Items[1]) {
+		t.Error("Indent() moved an item under a bookmark that is not a group")
+	}
+	// The first item of a group has nothing above it in the group
+	if s.Indent(archive) {
+		t.Error("Indent() moved the first item of a group")
+	}
+	if s.Outdent(plan) {
+		t.Error("Outdent() moved a top-level item")
+	}
+
+	if !s.Indent(search) {
+		t.Fatal("Indent() did not move the item under the group above it")
+	}
+	if got, want := labels(work.Items), []string{"Archive", "Local graph", "Empty", search.Label()}; !slices.Equal(got, want) {
+		t.Errorf("group after Indent() = %q, want %q", got, want)
+	}
+
+	// Into the empty group just above, then out of both groups again
+	if !s.Indent(search) || !slices.Equal(empty.Items, []*Item{search}) {
+		t.Fatalf("nested Indent() = %q", labels(empty.Items))
+	}
+	if !s.Outdent(search) {
+		t.Fatal("Outdent() from a nested group failed")
+	}
+	if got, want := labels(work.Items), []string{"Archive", "Local graph", "Empty", search.Label()}; !slices.Equal(got, want) {
+		t.Errorf("group after Outdent() = %q, want %q", got, want)
+	}
+	// The last item of a group goes to just after the group
+	if !s.Outdent(search) {
+		t.Fatal("Outdent() from the end of a group failed")
+	}
+	if got, want := labels(s.Items), []string{"Plan", "Plan › Goals", "Work", search.Label(), "Obsidian"}; !slices.Equal(got, want) {
+		t.Errorf("top level after Outdent() = %q, want %q", got, want)
+	}
+	if len(empty.Items) != 0 {
+		t.Errorf("empty group keeps %q", labels(empty.Items))
+	}
+}
@@ -1,11 +1,13 @@
 package backlinks
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/takahashinaoki/obsidiantui/internal/vault"
 )
 
@@ -22,6 +24,7 @@ var (
 				Background(lipgloss.Color("62")).
 				Foreground(lipgloss.Color("230"))
 	backlinksLinkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	backlinksBlockStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
 	backlinksNormalStyle   = lipgloss.NewStyle()
 	backlinksMoreStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	backlinksNoLinksStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
@@ -33,6 +36,7 @@ var (
 
 type Model struct {
 	backlinks []string
+	rows      []row
 	cursor    int
 	vault     *vault.Vault
 	filePath  string
@@ -55,8 +59,18 @@ var DefaultKeyMap = KeyMap{
 	Cancel: key.NewBinding(key.WithKeys("esc", "ctrl+b"), key.WithHelp("esc", "close")),
 }
 
+// row is a linking note, or one of its links to a block of the note
+type row struct {
+	path  string
+	line  int // the block link's line, or -1 for the note
+	label string
+}
+
+// FileSelectedMsg is sent when a row is picked. Line is the block link's
+// line, or -1 to open the note where it was left.
 type FileSelectedMsg struct {
 	Path string
+	Line int
 }
 
 type BacklinksClosedMsg struct{}
@@ -92,17 +106,17 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 
 		case key.Matches(msg, DefaultKeyMap.Down):
-			if m.cursor < len(m.backlinks)-1 {
+			if m.cursor < len(m.rows)-1 {
 				m.cursor++
 			}
 			return m, nil
 
 		case key.Matches(msg, DefaultKeyMap.Enter):
-			if len(m.backlinks) > 0 && m.cursor < len(m.backlinks) {
-				selected := m.backlinks[m.cursor]
+			if len(m.rows) > 0 && m.cursor < len(m.rows) {
+				selected := m.rows[m.cursor]
 				m.active = false
 				return m, func() tea.Msg {
-					return FileSelectedMsg{Path: selected}
+					return FileSelectedMsg{Path: selected.path, Line: selected.line}
 				}
 			}
 			return m, nil
@@ -111,12 +125,12 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.MouseMsg:
 		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
 			clickedIndex := msg.Y - 3
-			if clickedIndex >= 0 && clickedIndex < len(m.backlinks) {
+			if clickedIndex >= 0 && clickedIndex < len(m.rows) {
 				m.cursor = clickedIndex
-				selected := m.backlinks[m.cursor]
+				selected := m.rows[m.cursor]
 				m.active = false
 				return m, func() tea.Msg {
-					return FileSelectedMsg{Path: selected}
+					return FileSelectedMsg{Path: selected.path, Line: selected.line}
 				}
 			}
 		}
@@ -135,31 +149,36 @@ func (m Model) View() string {
 	b.WriteString(backlinksTitleStyle.Render("Backlinks") + "\n")
 	b.WriteString(backlinksSubtitleStyle.Render("Files linking to: "+m.filePath) + "\n\n")
 
-	if len(m.backlinks) > 0 {
+	if len(m.rows) > 0 {
 		maxItems := m.height - 6
-		if maxItems > len(m.backlinks) {
-			maxItems = len(m.backlinks)
+		if maxItems > len(m.rows) {
+			maxItems = len(m.rows)
 		}
 
 		for i := 0; i < maxItems; i++ {
-			link := m.backlinks[i]
+			r := m.rows[i]
 			var style lipgloss.Style
 
-			if i == m.cursor {
+			switch {
+			case i == m.cursor:
 				style = backlinksSelectedStyle
-			} else {
+			case r.line >= 0:
+				style = backlinksBlockStyle
+			default:
 				style = backlinksLinkStyle
 			}
 
-			displayLink := link
-			if len(displayLink) > m.width-6 {
+			displayLink := r.label
+			if r.line >= 0 {
+				displayLink = ansi.Truncate(displayLink, m.width-6, "…")
+			} else if len(displayLink) > m.width-6 {
 				displayLink = "..." + displayLink[len(displayLink)-(m.width-9):]
 			}
 
 			b.WriteString(style.Width(m.width - 4).Render("  " + displayLink) + "\n")
 		}
 
-		if len(m.backlinks) > maxItems {
+		if len(m.rows) > maxItems {
 			b.WriteString(backlinksMoreStyle.Render("  ... and more"))
 		}
 	} else {
@@ -178,9 +197,38 @@ func (m *Model) Show(filePath string) {
 	m.active = true
 	m.filePath = filePath
 	m.backlinks = m.vault.GetBacklinks(filePath)
+	m.rows = m.buildRows()
 	m.cursor = 0
 }
 
+// buildRows lists each linking note followed by its links to blocks of the
+// note, with the line each block starts at
+func (m Model) buildRows() []row {
+	refs := make(map[string][]vault.BlockRef)
+	for _, ref := range m.vault.BlockRefs(m.filePath) {
+		refs[ref.Source] = append(refs[ref.Source], ref)
+	}
+
+	var rows []row
+	seen := make(map[string]bool)
+	for _, path := range m.backlinks {
+		// The index has a note once for each of its links here
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		rows = append(rows, row{path: path, line: -1, label: path})
+		for _, ref := range refs[path] {
+			label := fmt.Sprintf("  ↳ ^%s → line %d: %s", ref.ID, ref.Block+1, ref.Text)
+			if ref.Block < 0 {
+				label = fmt.Sprintf("  ↳ ^%s → no such block", ref.ID)
+			}
+			rows = append(rows, row{path: path, line: ref.Line, label: label})
+		}
+	}
+	return rows
+}
+
 func (m *Model) Hide() {
 	m.active = false
 }
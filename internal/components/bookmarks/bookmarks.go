@@ -0,0 +1,342 @@
+package bookmarks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/bookmark"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("136")).Padding(0, 1)
+	inputStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("136")).Padding(0, 1)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("136")).Foreground(lipgloss.Color("230"))
+	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	groupStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	detailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	missingStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("136")).Padding(1)
+)
+
+var typeIcons = map[string]string{
+	bookmark.TypeFile:   "•",
+	bookmark.TypeFolder: "▪",
+	bookmark.TypeSearch: "?",
+	bookmark.TypeURL:    "↗",
+}
+
+// OpenBookmarkMsg is sent when a bookmark is picked
+type OpenBookmarkMsg struct {
+	Item *bookmark.Item
+}
+
+// BookmarksChangedMsg is sent after the pane edits the store, which the
+// caller should save
+type BookmarksChangedMsg struct{}
+
+// BookmarksClosedMsg is sent when the pane is closed
+type BookmarksClosedMsg struct{}
+
+type editMode int
+
+const (
+	editNone editMode = iota
+	editNewGroup
+	editTitle
+)
+
+type row struct {
+	item  *bookmark.Item
+	depth int
+}
+
+// Model lists the bookmarks of a store as a tree of groups, and edits it
+type Model struct {
+	store     *bookmark.Store
+	exists    func(*bookmark.Item) bool
+	rows      []row
+	collapsed map[*bookmark.Item]bool
+	cursor    int
+	textinput textinput.Model
+	editing   editMode
+	width     int
+	height    int
+	active    bool
+}
+
+func New() Model {
+	ti := textinput.New()
+	ti.CharLimit = 256
+	ti.Width = 40
+
+	return Model{textinput: ti, collapsed: make(map[*bookmark.Item]bool)}
+}
+
+// Show opens the pane over store. exists reports whether a bookmark's
+// target is still there; missing ones are marked.
+func (m *Model) Show(store *bookmark.Store, exists func(*bookmark.Item) bool) {
+	m.active = true
+	m.store = store
+	m.exists = exists
+	m.editing = editNone
+	m.collapsed = make(map[*bookmark.Item]bool)
+	m.rebuild()
+	m.cursor = 0
+}
+
+func (m *Model) Hide() {
+	m.active = false
+	m.editing = editNone
+	m.textinput.Blur()
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+// rebuild flattens the visible part of the tree into rows
+func (m *Model) rebuild() {
+	m.rows = m.rows[:0]
+	var walk func(items []*bookmark.Item, depth int)
+	walk = func(items []*bookmark.Item, depth int) {
+		for _, it := range items {
+			m.rows = append(m.rows, row{item: it, depth: depth})
+			if it.Type == bookmark.TypeGroup && !m.collapsed[it] {
+				walk(it.Items, depth+1)
+			}
+		}
+	}
+	walk(m.store.Items, 0)
+	m.cursor = max(0, min(m.cursor, len(m.rows)-1))
+}
+
+func (m Model) selected() *bookmark.Item {
+	if m.cursor < len(m.rows) {
+		return m.rows[m.cursor].item
+	}
+	return nil
+}
+
+// follow puts the cursor back on it after the tree changed
+func (m *Model) follow(it *bookmark.Item) {
+	m.rebuild()
+	for i, r := range m.rows {
+		if r.item == it {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func changed() tea.Msg {
+	return BookmarksChangedMsg{}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	if m.editing != editNone {
+		return m.updateEditing(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	it := m.selected()
+
+	switch keyMsg.String() {
+	case "esc", "q", "ctrl+c":
+		m.Hide()
+		return m, func() tea.Msg { return BookmarksClosedMsg{} }
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+
+	case "enter", "l":
+		if it == nil {
+			return m, nil
+		}
+		if it.Type == bookmark.TypeGroup {
+			m.collapsed[it] = !m.collapsed[it]
+			m.rebuild()
+			return m, nil
+		}
+		m.Hide()
+		return m, func() tea.Msg { return OpenBookmarkMsg{Item: it} }
+
+	case "tab", "h":
+		if it != nil && it.Type == bookmark.TypeGroup {
+			m.collapsed[it] = !m.collapsed[it]
+			m.rebuild()
+		}
+
+	case "d", "delete":
+		if it != nil && m.store.Remove(it) {
+			m.rebuild()
+			return m, changed
+		}
+
+	case "K", "J":
+		delta := 1
+		if keyMsg.String() == "K" {
+			delta = -1
+		}
+		if it != nil && m.store.Move(it, delta) {
+			m.follow(it)
+			return m, changed
+		}
+
+	case ">":
+		if it != nil && m.store.Indent(it) {
+			m.follow(it)
+			return m, changed
+		}
+
+	case "<":
+		if it != nil && m.store.Outdent(it) {
+			m.follow(it)
+			return m, changed
+		}
+
+	case "n":
+		m.editing = editNewGroup
+		m.textinput.Placeholder = "Group name"
+		m.textinput.SetValue("")
+		return m, m.textinput.Focus()
+
+	case "r":
+		if it != nil {
+			m.editing = editTitle
+			m.textinput.Placeholder = it.Label()
+			m.textinput.SetValue(it.Title)
+			m.textinput.CursorEnd()
+			return m, m.textinput.Focus()
+		}
+	}
+
+	return m, nil
+}
+
+// updateEditing handles input while naming a group or retitling a bookmark
+func (m Model) updateEditing(msg tea.Msg) (Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.editing = editNone
+			m.textinput.Blur()
+			return m, nil
+
+		case "enter":
+			title := strings.TrimSpace(m.textinput.Value())
+			mode := m.editing
+			m.editing = editNone
+			m.textinput.Blur()
+
+			switch mode {
+			case editNewGroup:
+				if title == "" {
+					return m, nil
+				}
+				group := bookmark.NewGroup(title)
+				m.store.Add(group)
+				m.follow(group)
+			case editTitle:
+				it := m.selected()
+				if it == nil || (title == "" && it.Type == bookmark.TypeGroup) {
+					return m, nil
+				}
+				it.Title = title
+			}
+			return m, changed
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Bookmarks") + "\n\n")
+
+	maxVisible := max(5, m.height-10)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.rows))
+
+	for i := start; i < end; i++ {
+		r := m.rows[i]
+		it := r.item
+
+		indent := strings.Repeat("  ", r.depth)
+		icon := typeIcons[it.Type]
+		style := normalStyle
+		if it.Type == bookmark.TypeGroup {
+			icon = "▾"
+			if m.collapsed[it] {
+				icon = "▸"
+			}
+			style = groupStyle
+		} else if icon == "" {
+			icon = "·"
+		}
+		if i == m.cursor {
+			style = selectedStyle
+		}
+
+		line := indent + style.Render(icon+" "+it.Label())
+		switch {
+		case it.Type == bookmark.TypeGroup:
+			line += detailStyle.Render(fmt.Sprintf(" (%d)", len(it.Items)))
+		case it.Type == bookmark.TypeSearch:
+			line += detailStyle.Render(" search")
+		case it.Path != "" && m.exists != nil && !m.exists(it):
+			line += missingStyle.Render(" (missing)")
+		case it.Path != "" && it.Title != "":
+			line += detailStyle.Render(" " + it.Path)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(m.rows) == 0 {
+		b.WriteString(hintStyle.Render("  No bookmarks yet. Use M-B to bookmark the current note.") + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.editing != editNone {
+		b.WriteString(inputStyle.Render(m.textinput.View()) + "\n")
+		b.WriteString(hintStyle.Render("Enter: confirm  Esc: cancel"))
+	} else {
+		b.WriteString(hintStyle.Render("Enter: open  d: delete  r: rename  n: new group  J/K: move  >/<: into/out of group"))
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.textinput.Width = max(10, width-10)
+}
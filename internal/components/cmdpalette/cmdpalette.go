@@ -38,6 +38,8 @@ type PaletteClosedMsg struct{}
 type Model struct {
 	textinput textinput.Model
 	commands  []Command
+	extra     []Command
+	keyLabels map[string]string // command ID -> key, overriding Command.Key
 	filtered  []Command
 	cursor    int
 	width     int
@@ -59,21 +61,106 @@ func New() Model {
 
 func defaultCommands() []Command {
 	return []Command{
+		{ID: "quick-switch", Name: "Quick Switcher", Description: "Fuzzy find a note by name, alias or heading, or create one", Key: "C-p"},
 		{ID: "search", Name: "Search Files", Description: "Search for files in vault", Key: "/"},
 		{ID: "graph", Name: "Graph View", Description: "View note connections", Key: "C-g"},
+		{ID: "graph-analytics", Name: "Graph Analytics", Description: "Hubs, PageRank, clusters and shortest paths"},
 		{ID: "tags", Name: "Tags", Description: "Browse all tags", Key: "C-t"},
 		{ID: "outline", Name: "Outline", Description: "View document outline", Key: "C-l"},
+		{ID: "outline-sidebar", Name: "Toggle Outline Sidebar", Description: "Show the outline beside the editor, following the cursor", Key: "M-t"},
 		{ID: "backlinks", Name: "Backlinks", Description: "Show files linking to current", Key: "C-b"},
 		{ID: "forwardlinks", Name: "Forward Links", Description: "Show files linked from current", Key: "M-f"},
 		{ID: "daily", Name: "Daily Note", Description: "Open today's daily note", Key: "M-d"},
+		{ID: "go-back", Name: "Go Back", Description: "Return to the previous location", Key: "C-o"},
+		{ID: "go-forward", Name: "Go Forward", Description: "Undo a Go Back", Key: "M-i"},
+		{ID: "jump-list", Name: "Jump List", Description: "Pick any location from the navigation history", Key: "M-j"},
+		{ID: "recent-files", Name: "Recent Files", Description: "Switch to a recently opened note", Key: "M-r"},
+		{ID: "bookmarks", Name: "Bookmarks", Description: "Browse and organise bookmarked notes, headings, folders and searches", Key: "M-b"},
+		{ID: "bookmark-toggle", Name: "Bookmark Current Note", Description: "Add or remove a bookmark for the note, or the tree selection", Key: "M-B"},
+		{ID: "bookmark-heading", Name: "Bookmark Heading", Description: "Bookmark the heading above the cursor"},
+		{ID: "copy-block-link", Name: "Copy Block Link", Description: "Give the block at the cursor a ^id and copy a link to it"},
+		{ID: "insert-table", Name: "Insert Table", Description: "Insert an empty table at the cursor"},
+		{ID: "format-table", Name: "Format Table", Description: "Line up the columns of the table at the cursor"},
+		{ID: "bookmark-folder", Name: "Bookmark Current Folder", Description: "Bookmark the folder of the current note"},
 		{ID: "save", Name: "Save File", Description: "Save current file", Key: "C-s"},
 		{ID: "refresh", Name: "Refresh Vault", Description: "Rescan vault files", Key: "C-r"},
-		{ID: "newfile", Name: "New File", Description: "Create a new file", Key: "C-n"},
+		{ID: "newfile", Name: "New File", Description: "Create a note next to the current one", Key: "C-n"},
+		{ID: "newfolder", Name: "New Folder", Description: "Create a folder next to the current note"},
+		{ID: "rename-file", Name: "Rename File", Description: "Rename the current note in the file tree"},
+		{ID: "reveal-file", Name: "Reveal Active File", Description: "Show the current note in the file tree", Key: "M-l"},
+		{ID: "tree-filter", Name: "Filter File Tree", Description: "Show only files and folders whose name matches"},
+		{ID: "tree-sort", Name: "Sort File Tree", Description: "Order notes by name, modified or created time, size or links"},
+		{ID: "tree-collapse-all", Name: "Collapse All Folders", Description: "Close every folder in the file tree"},
+		{ID: "tree-expand-all", Name: "Expand All Folders", Description: "Open every folder in the file tree"},
+		{ID: "tree-columns", Name: "File Tree Columns", Description: "Show modified date, word count, backlinks, tasks or folder note counts"},
+		{ID: "folder-index", Name: "Folder Index", Description: "List the notes in the current folder with their titles, tags and descriptions"},
+		{ID: "tree-peek", Name: "Toggle Note Peek", Description: "Show the start of the note under the tree cursor beside it"},
+		{ID: "delete-file", Name: "Delete File", Description: "Move the current note, or the tree selection, to .trash", Key: "Del"},
+		{ID: "vault-switch", Name: "Switch Vault", Description: "Open another vault", Key: "M-v"},
+		{ID: "keymap-problems", Name: "Keymap Problems", Description: "List bad or conflicting bindings in the keymap config"},
 		{ID: "help", Name: "Toggle Help", Description: "Show/hide keybindings help", Key: "?"},
 		{ID: "edit", Name: "Edit Mode", Description: "Switch to edit view", Key: "M-e"},
 		{ID: "preview", Name: "Preview Mode", Description: "Switch to preview view", Key: "M-p"},
 		{ID: "split", Name: "Split Mode", Description: "Switch to split view", Key: "M-s"},
 		{ID: "toggle", Name: "Cycle View", Description: "Cycle through view modes", Key: "C-e"},
+		{ID: "export-html", Name: "Export Note to HTML", Description: "Write the current note as a standalone HTML page"},
+		{ID: "export-pdf", Name: "Export Note for PDF", Description: "Write print-ready HTML for the current note"},
+		{ID: "export-markdown", Name: "Export Note to Markdown", Description: "Write the current note with embeds inlined"},
+		{ID: "export-folder-html", Name: "Export Folder to HTML", Description: "Export every note in the current note's folder"},
+		{ID: "publish", Name: "Publish Site", Description: "Build a static site from notes with publish: true"},
+		{ID: "export-graph-dot", Name: "Export Graph (DOT)", Description: "Write the link graph as Graphviz DOT"},
+		{ID: "export-graph-graphml", Name: "Export Graph (GraphML)", Description: "Write the link graph as GraphML"},
+		{ID: "export-graph-json", Name: "Export Graph (JSON)", Description: "Write the link graph as JSON adjacency"},
+		{ID: "history", Name: "File History", Description: "Browse, diff and restore saved versions of the current note", Key: "M-h"},
+		{ID: "tab-next", Name: "Next Tab", Description: "Show the next open note", Key: "M-."},
+		{ID: "tab-prev", Name: "Previous Tab", Description: "Show the previous open note", Key: "M-,"},
+		{ID: "tab-close", Name: "Close Tab", Description: "Close the current note's tab", Key: "M-w"},
+		{ID: "tab-move-left", Name: "Move Tab Left", Description: "Reorder the current tab", Key: "M-<"},
+		{ID: "tab-move-right", Name: "Move Tab Right", Description: "Reorder the current tab", Key: "M->"},
+		{ID: "split-vertical", Name: "Split Right", Description: "Open a window side by side", Key: "M-\\"},
+		{ID: "split-horizontal", Name: "Split Down", Description: "Open a window below", Key: "M--"},
+		{ID: "window-next", Name: "Next Window", Description: "Focus the next split window", Key: "M-o"},
+		{ID: "window-close", Name: "Close Window", Description: "Close the focused split window", Key: "M-x"},
+		{ID: "git-status", Name: "Git: Refresh Status", Description: "Update change markers in the file tree"},
+		{ID: "git-diff", Name: "Git: Diff Current Note", Description: "Show changes to the current note since the last commit"},
+		{ID: "git-commit", Name: "Git: Commit All", Description: "Stage every change and commit with an auto-generated message"},
+		{ID: "git-pull", Name: "Git: Pull", Description: "Pull changes from the remote"},
+		{ID: "git-push", Name: "Git: Push", Description: "Push commits to the remote"},
+		{ID: "git-auto-commit", Name: "Git: Toggle Auto-Commit", Description: "Commit all changes on an interval"},
+		{ID: "git-init", Name: "Git: Init Repository", Description: "Create a git repository in the vault"},
+	}
+}
+
+// Lookup finds a built-in command by ID
+func Lookup(id string) (Command, bool) {
+	for _, cmd := range defaultCommands() {
+		if cmd.ID == id {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// SetExtraCommands lists commands after the built-in ones, replacing any
+// set before. They are for things the user defines, like bookmarks.
+func (m *Model) SetExtraCommands(extra []Command) {
+	m.extra = extra
+	m.rebuild()
+}
+
+// SetKeyLabels shows the given keys for commands, for when the user has
+// rebound them. An empty label hides the default one.
+func (m *Model) SetKeyLabels(labels map[string]string) {
+	m.keyLabels = labels
+	m.rebuild()
+}
+
+func (m *Model) rebuild() {
+	m.commands = append(defaultCommands(), m.extra...)
+	for i, cmd := range m.commands {
+		if label, ok := m.keyLabels[cmd.ID]; ok {
+			m.commands[i].Key = label
+		}
 	}
 }
 
@@ -213,7 +300,10 @@ func (m Model) View() string {
 			name = name[:maxNameLen-3] + "..."
 		}
 
-		key := keyStyle.Render("[" + cmd.Key + "]")
+		key := ""
+		if cmd.Key != "" {
+			key = keyStyle.Render("[" + cmd.Key + "]")
+		}
 		desc := descStyle.Render(cmd.Description)
 
 		var style lipgloss.Style
@@ -0,0 +1,182 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("28")).Padding(0, 1)
+	addedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	removedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hunkStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	metaStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("245"))
+	contextStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	emptyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("28")).Padding(1)
+)
+
+// DiffClosedMsg is sent when the diff view is closed
+type DiffClosedMsg struct{}
+
+// Model shows a unified diff in a scrollable overlay
+type Model struct {
+	title  string
+	lines  []string
+	offset int
+	width  int
+	height int
+	active bool
+}
+
+func New() Model {
+	return Model{}
+}
+
+// Show opens the overlay with the given unified diff
+func (m *Model) Show(title, diff string) {
+	m.active = true
+	m.title = title
+	m.offset = 0
+	m.lines = nil
+	if strings.TrimSpace(diff) != "" {
+		m.lines = strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	}
+}
+
+func (m *Model) Hide() {
+	m.active = false
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func (m Model) visibleLines() int {
+	n := m.height - 8
+	if n < 5 {
+		n = 5
+	}
+	return n
+}
+
+func (m *Model) scroll(delta int) {
+	m.offset += delta
+	if maxOffset := len(m.lines) - m.visibleLines(); m.offset > maxOffset {
+		m.offset = maxOffset
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.Hide()
+			return m, func() tea.Msg { return DiffClosedMsg{} }
+		case "up", "k":
+			m.scroll(-1)
+		case "down", "j":
+			m.scroll(1)
+		case "pgup", "ctrl+u":
+			m.scroll(-m.visibleLines() / 2)
+		case "pgdown", "ctrl+d":
+			m.scroll(m.visibleLines() / 2)
+		case "g":
+			m.offset = 0
+		case "G":
+			m.scroll(len(m.lines))
+		case "n":
+			m.jumpHunk(1)
+		case "N":
+			m.jumpHunk(-1)
+		}
+
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scroll(-3)
+		case tea.MouseButtonWheelDown:
+			m.scroll(3)
+		}
+	}
+
+	return m, nil
+}
+
+// jumpHunk scrolls to the next or previous @@ hunk header
+func (m *Model) jumpHunk(direction int) {
+	for i := m.offset + direction; i >= 0 && i < len(m.lines); i += direction {
+		if strings.HasPrefix(m.lines[i], "@@") {
+			m.offset = i
+			m.scroll(0)
+			return
+		}
+	}
+}
+
+// StyleLine colours one line of a unified diff
+func StyleLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+		strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "):
+		return metaStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return hunkStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return addedStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return removedStyle.Render(line)
+	}
+	return contextStyle.Render(line)
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title) + "\n")
+	b.WriteString(emptyStyle.Render("j/k: scroll | n/N: next/prev hunk | Esc: close") + "\n\n")
+
+	if len(m.lines) == 0 {
+		b.WriteString(emptyStyle.Render("No changes"))
+		return containerStyle.Width(m.width).Render(b.String())
+	}
+
+	end := m.offset + m.visibleLines()
+	if end > len(m.lines) {
+		end = len(m.lines)
+	}
+
+	maxLen := m.width - 6
+	for _, line := range m.lines[m.offset:end] {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if maxLen > 3 && len([]rune(line)) > maxLen {
+			line = string([]rune(line)[:maxLen-3]) + "..."
+		}
+		b.WriteString(StyleLine(line) + "\n")
+	}
+
+	if len(m.lines) > m.visibleLines() {
+		b.WriteString(emptyStyle.Render(fmt.Sprintf("[%d-%d/%d]", m.offset+1, end, len(m.lines))))
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
@@ -0,0 +1,186 @@
+package filetree
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Column is a right-aligned detail shown after names
+type Column int
+
+const (
+	ColumnModified Column = iota
+	ColumnWords
+	ColumnLinks // notes linking to the note
+	ColumnTasks
+	ColumnNotes // notes in a folder, including subfolders
+)
+
+var columnNames = []string{"modified", "words", "links", "tasks", "notes"}
+
+// columnWidths are the cells' widths, so columns line up between rows
+var columnWidths = []int{6, 5, 4, 5, 4}
+
+var (
+	columnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("242"))
+	tasksDoneStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// minNameWidth is the room kept for names; narrower rows drop columns
+const minNameWidth = 8
+
+func (c Column) String() string {
+	if int(c) < len(columnNames) {
+		return columnNames[c]
+	}
+	return ""
+}
+
+// ParseColumn reads a column as written in the config, e.g. "words"
+func ParseColumn(name string) (Column, bool) {
+	for i, n := range columnNames {
+		if strings.EqualFold(name, n) {
+			return Column(i), true
+		}
+	}
+	return 0, false
+}
+
+// SetColumns sets the columns shown, in order
+func (m *Model) SetColumns(columns []Column) {
+	m.columns = columns
+	m.statNodes(m.Root)
+}
+
+// Columns returns the columns shown
+func (m Model) Columns() []Column {
+	return m.columns
+}
+
+// ToggleColumn shows or hides a column, keeping them in the usual order
+func (m *Model) ToggleColumn(c Column) {
+	shown := make(map[Column]bool)
+	for _, col := range m.columns {
+		shown[col] = true
+	}
+	shown[c] = !shown[c]
+
+	m.columns = nil
+	for i := range columnNames {
+		if shown[Column(i)] {
+			m.columns = append(m.columns, Column(i))
+		}
+	}
+	m.statNodes(m.Root)
+}
+
+// fittingColumns returns the columns that leave room for a name in width.
+// The leftmost go first, so the ones kept line up with other rows.
+func (m Model) fittingColumns(width int) []Column {
+	columns := m.columns
+	for len(columns) > 0 && width-columnsWidth(columns) < minNameWidth {
+		columns = columns[1:]
+	}
+	return columns
+}
+
+// columnsWidth is the room columns take, including the space before each
+func columnsWidth(columns []Column) int {
+	w := 0
+	for _, c := range columns {
+		w += columnWidths[c] + 1
+	}
+	return w
+}
+
+// renderColumns draws a node's cells. The index is read each time, so counts
+// fill in once it is built and follow edits.
+func (m Model) renderColumns(node *Node, columns []Column) string {
+	var b strings.Builder
+	for _, c := range columns {
+		cell, style := m.cell(node, c)
+		b.WriteByte(' ')
+		b.WriteString(strings.Repeat(" ", max(0, columnWidths[c]-lipgloss.Width(cell))))
+		b.WriteString(style.Render(cell))
+	}
+	return b.String()
+}
+
+func (m Model) cell(node *Node, c Column) (string, lipgloss.Style) {
+	if node.IsDir {
+		if c == ColumnNotes {
+			return fmt.Sprint(node.Notes), columnStyle
+		}
+		return "", columnStyle
+	}
+
+	stats := m.vault.Stats(node.Path)
+	switch c {
+	case ColumnModified:
+		modTime := node.ModTime
+		if stats.ModTime.After(modTime) {
+			modTime = stats.ModTime
+		}
+		return shortTime(modTime, time.Now()), columnStyle
+	case ColumnWords:
+		return shortCount(stats.Words), columnStyle
+	case ColumnLinks:
+		if stats.Backlinks == 0 {
+			return "", columnStyle
+		}
+		return fmt.Sprintf("←%d", stats.Backlinks), columnStyle
+	case ColumnTasks:
+		if stats.Tasks == 0 {
+			return "", columnStyle
+		}
+		cell := fmt.Sprintf("%d/%d", stats.TasksDone, stats.Tasks)
+		if stats.TasksDone == stats.Tasks {
+			return cell, tasksDoneStyle
+		}
+		return cell, columnStyle
+	}
+	return "", columnStyle
+}
+
+// shortTime fits a time in six columns: the time today, the date this year,
+// and the year before that
+func shortTime(t, now time.Time) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case t.Year() == now.Year() && t.YearDay() == now.YearDay():
+		return t.Format("15:04")
+	case t.Year() == now.Year():
+		return t.Format("Jan 02")
+	}
+	return t.Format("2006")
+}
+
+// shortCount fits a word count in five columns, e.g. 950, 9.5k or 120k
+func shortCount(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 9950:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprintf("%dk", (n+500)/1000)
+}
+
+// countNotes sets the note counts of node and the folders below it
+func countNotes(node *Node) int {
+	if !node.IsDir {
+		return 1
+	}
+	node.Notes = 0
+	if node.FolderNote != "" {
+		node.Notes++
+	}
+	for _, child := range node.Children {
+		node.Notes += countNotes(child)
+	}
+	return node.Notes
+}
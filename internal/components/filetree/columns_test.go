@@ -0,0 +1,42 @@
+package filetree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestModifiedUnderNameSort(t *testing.T) {
+	v := vaulttest.New(t, map[string]string{"a.md": "never opened"})
+	modified := time.Date(2001, 2, 3, 4, 5, 6, 0, time.Local)
+	if err := os.Chtimes(filepath.Join(v.Path, "a.md"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(v)
+	node := m.Root.Children[0]
+	if cell, _ := m.cell(node, ColumnModified); cell != "" {
+		t.Fatalf("cell() = %q before the column is shown, want the note left unstat'd", cell)
+	}
+	m.SetColumns([]Column{ColumnModified})
+	if cell, _ := m.cell(node, ColumnModified); cell != "2001" {
+		t.Errorf("modified cell = %q under the name sort, want %q", cell, "2001")
+	}
+
+	m = New(v)
+	m.TogglePeek()
+	if got := m.Root.Children[0].ModTime; !got.Equal(modified) {
+		t.Errorf("ModTime = %v with the peek shown, want %v", got, modified)
+	}
+
+	// Columns read from the config before the tree is rebuilt are honoured too
+	m = New(v)
+	m.SetColumns([]Column{ColumnWords, ColumnModified})
+	m.Refresh()
+	if cell, _ := m.cell(m.Root.Children[0], ColumnModified); cell != "2001" {
+		t.Errorf("modified cell = %q after a refresh, want %q", cell, "2001")
+	}
+}
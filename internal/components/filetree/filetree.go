@@ -4,9 +4,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
 	"github.com/takahashinaoki/obsidiantui/internal/vault"
 )
 
@@ -15,6 +18,13 @@ var (
 	selectedUnfocusedStyle = lipgloss.NewStyle().Background(lipgloss.Color("240")).Foreground(lipgloss.Color("255"))
 	dirStyle               = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
 	defaultStyle           = lipgloss.NewStyle()
+	gitModifiedStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	gitAddedStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	gitDeletedStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	gitFolderStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	markedColor            = lipgloss.Color("214")
+	filterLabelStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	filterStyle            = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 	indentStrings          = []string{"", "  ", "    ", "      ", "        ", "          ", "            ", "              ", "                ", "                  "}
 )
 
@@ -26,6 +36,15 @@ type Node struct {
 	Children []*Node
 	Parent   *Node
 	Depth    int
+
+	// For sorting notes, see sort.go
+	ModTime time.Time
+	Created time.Time
+	Size    int64
+	Links   int
+
+	Notes      int    // notes below a folder, see columns.go
+	FolderNote string // note standing for a folder, hidden from its children
 }
 
 type Model struct {
@@ -37,6 +56,22 @@ type Model struct {
 	height       int
 	focused      bool
 	selectedPath string
+	gitStatus    map[string]string // path -> one-letter git status
+	gitDirs      map[string]bool   // folders containing changed files
+	marked       map[string]bool   // paths selected for an operation
+	clip         []string          // cut or copied paths, see ops.go
+	clipCut      bool
+	input        textinput.Model
+	editing      editMode
+	sortBy       SortBy
+	sortDesc     bool
+	filter       string // shows only matching names and their folders
+	columns      []Column
+	peeking      bool // shows a peek at the note under the cursor, see peek.go
+	peek         peekNote
+	folderNotes  bool // folders open their folder notes, see folders.go
+	keySeq       keymap.Resolver
+	statted      bool // notes' times and sizes are read, see sort.go
 }
 
 type FileSelectedMsg struct {
@@ -45,8 +80,11 @@ type FileSelectedMsg struct {
 
 func New(v *vault.Vault) Model {
 	m := Model{
-		vault:   v,
-		focused: true,
+		vault:       v,
+		focused:     true,
+		marked:      make(map[string]bool),
+		input:       newInput(),
+		folderNotes: true,
 	}
 	m.buildTree()
 	return m
@@ -108,25 +146,25 @@ func (m *Model) buildTree() {
 		}
 	}
 
-	sortNodes(m.Root)
-	m.flattenTree()
-}
-
-func sortNodes(node *Node) {
-	sort.Slice(node.Children, func(i, j int) bool {
-		if node.Children[i].IsDir != node.Children[j].IsDir {
-			return node.Children[i].IsDir
-		}
-		return strings.ToLower(node.Children[i].Name) < strings.ToLower(node.Children[j].Name)
-	})
-
-	for _, child := range node.Children {
-		sortNodes(child)
+	if m.folderNotes {
+		hideFolderNotes(m.Root)
 	}
+	countNotes(m.Root)
+	m.statted = false
+	m.statNodes(m.Root)
+	m.sortNodes(m.Root)
+	m.flattenTree()
 }
 
 func (m *Model) flattenTree() {
 	m.FlatNodes = m.FlatNodes[:0]
+	if m.filter != "" {
+		m.FlatNodes = append(m.FlatNodes, m.flattenFiltered(m.Root)...)
+		if len(m.FlatNodes) == 0 {
+			m.FlatNodes = append(m.FlatNodes, m.Root)
+		}
+		return
+	}
 	m.flattenNode(m.Root)
 }
 
@@ -141,17 +179,45 @@ func (m *Model) flattenNode(node *Node) {
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return m.waitIndex()
 }
 
+// Update handles msg, then reads the note under the cursor for the peek if
+// the cursor moved
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.syncPeek()
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	if !m.focused {
 		return m, nil
 	}
 
+	switch m.editing {
+	case editNone:
+	case editFilter:
+		return m.updateFilter(msg)
+	default:
+		return m.updateEditing(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		_, action, pending := m.keySeq.Feed(msg.String(), keys)
+		if pending {
+			return m, nil
+		}
+		if action != "" {
+			return m, m.runAction(action)
+		}
+		if m, cmd, ok := m.updateOps(msg); ok {
+			return m, cmd
+		}
 		switch msg.String() {
+		case "esc":
+			m.ClearFilter()
 		case "up", "k":
 			if m.Cursor > 0 {
 				m.Cursor--
@@ -163,14 +229,11 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		case "enter":
 			if m.Cursor < len(m.FlatNodes) {
 				node := m.FlatNodes[m.Cursor]
-				if node.IsDir {
+				if node.IsDir && node.FolderNote == "" {
 					node.Expanded = !node.Expanded
 					m.flattenTree()
 				} else {
-					m.selectedPath = node.Path
-					return m, func() tea.Msg {
-						return FileSelectedMsg{Path: node.Path}
-					}
+					return m, m.open(node)
 				}
 			}
 		case "tab", "l", "h":
@@ -207,32 +270,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		case tea.MouseButtonLeft:
 			if msg.Action == tea.MouseActionPress {
-				// Calculate scroll offset same as View()
-				start := 0
-				if m.height > 0 && len(m.FlatNodes) > m.height {
-					if m.Cursor >= m.height/2 {
-						start = m.Cursor - m.height/2
-					}
-					if start+m.height > len(m.FlatNodes) {
-						start = len(m.FlatNodes) - m.height
-						if start < 0 {
-							start = 0
-						}
-					}
-				}
-
-				clickedIndex := msg.Y + start
+				start, _ := m.visibleRange()
+				clickedIndex := msg.Y - m.headerHeight() + start
 				if clickedIndex >= 0 && clickedIndex < len(m.FlatNodes) {
 					m.Cursor = clickedIndex
 					node := m.FlatNodes[m.Cursor]
-					if node.IsDir {
+					if node.IsDir && node.FolderNote == "" {
 						node.Expanded = !node.Expanded
 						m.flattenTree()
 					} else {
-						m.selectedPath = node.Path
-						return m, func() tea.Msg {
-							return FileSelectedMsg{Path: node.Path}
-						}
+						return m, m.open(node)
 					}
 				}
 			}
@@ -242,49 +289,122 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// runAction performs an action of the file tree's keymap
+func (m *Model) runAction(action string) tea.Cmd {
+	switch action {
+	case "filter":
+		return m.StartFilter()
+	case "sort_next", "sort_reverse":
+		sorted := m.cycleSort(action == "sort_reverse")
+		return func() tea.Msg { return sorted }
+	case "peek":
+		m.TogglePeek()
+	case "collapse_all":
+		m.CollapseAll()
+	case "expand_all":
+		m.ExpandAll()
+	case "open":
+		if node := m.CursorNode(); node != nil {
+			if node.IsDir && node.FolderNote == "" {
+				path := node.Path
+				return func() tea.Msg { return FolderIndexMsg{Dir: path} }
+			}
+			return m.open(node)
+		}
+	}
+	return nil
+}
+
+// headerHeight is the number of lines above the nodes, for the filter
+func (m Model) headerHeight() int {
+	if m.filter != "" || m.editing == editFilter {
+		return 1
+	}
+	return 0
+}
+
+// visibleRange returns the nodes that fit, keeping the cursor centred
+func (m Model) visibleRange() (start, end int) {
+	height := m.height - m.headerHeight()
+	// A new note or folder is named on an extra row below the cursor
+	if m.editing == editNewNote || m.editing == editNewFolder {
+		height--
+	}
+	height = max(1, height)
+
+	end = len(m.FlatNodes)
+	if m.height > 0 && end > height {
+		if m.Cursor >= height/2 {
+			start = m.Cursor - height/2
+		}
+		if start+height < end {
+			end = start + height
+		} else {
+			start = max(0, end-height)
+		}
+	}
+	return start, end
+}
+
 func (m Model) View() string {
 	var b strings.Builder
 	b.Grow(m.width * m.height)
 
-	start := 0
-	end := len(m.FlatNodes)
-
-	if m.height > 0 && end > m.height {
-		if m.Cursor >= m.height/2 {
-			start = m.Cursor - m.height/2
-		}
-		if start+m.height < end {
-			end = start + m.height
+	if m.headerHeight() > 0 {
+		b.WriteString(filterLabelStyle.Render("Filter: "))
+		if m.editing == editFilter {
+			b.WriteString(m.input.View())
 		} else {
-			start = end - m.height
-			if start < 0 {
-				start = 0
-			}
+			b.WriteString(filterStyle.Render(m.filter))
 		}
+		b.WriteByte('\n')
 	}
 
+	start, end := m.visibleRange()
 	for i := start; i < end && i < len(m.FlatNodes); i++ {
 		node := m.FlatNodes[i]
-		m.renderNode(&b, node, i == m.Cursor)
+		m.renderNode(&b, node, i)
 		b.WriteByte('\n')
+		if (m.editing == editNewNote || m.editing == editNewFolder) && i == m.Cursor {
+			m.renderInput(&b)
+			b.WriteByte('\n')
+		}
 	}
 
 	return b.String()
 }
 
-func (m Model) renderNode(b *strings.Builder, node *Node, selected bool) {
-	// Cached indent
-	if node.Depth < len(indentStrings) {
-		b.WriteString(indentStrings[node.Depth])
+func writeIndent(b *strings.Builder, depth int) {
+	if depth < len(indentStrings) {
+		b.WriteString(indentStrings[depth])
 	} else {
-		for i := 0; i < node.Depth; i++ {
+		for i := 0; i < depth; i++ {
 			b.WriteString("  ")
 		}
 	}
+}
+
+// renderInput draws the row where a new note or folder is named
+func (m Model) renderInput(b *strings.Builder) {
+	_, depth := m.targetDir()
+	writeIndent(b, depth)
+	if m.editing == editNewFolder {
+		b.WriteString("▶ ")
+	} else {
+		b.WriteString("  ")
+	}
+	b.WriteString(m.input.View())
+}
+
+func (m Model) renderNode(b *strings.Builder, node *Node, i int) {
+	selected := i == m.Cursor
+
+	// Cached indent
+	writeIndent(b, node.Depth)
 
 	// Icon
 	if node.IsDir {
-		if node.Expanded {
+		if m.showsChildren(node, i) {
 			b.WriteString("▼ ")
 		} else {
 			b.WriteString("▶ ")
@@ -293,9 +413,20 @@ func (m Model) renderNode(b *strings.Builder, node *Node, selected bool) {
 		b.WriteString("  ")
 	}
 
+	if selected && m.editing == editRename {
+		b.WriteString(m.input.View())
+		return
+	}
+
 	// Name (with truncation if needed)
 	name := node.Name
+	marker := m.gitMarker(node)
 	maxLen := m.width - node.Depth*2 - 4
+	if marker != "" {
+		maxLen -= 2
+	}
+	columns := m.fittingColumns(maxLen)
+	maxLen -= columnsWidth(columns)
 	if maxLen > 0 && len(name) > maxLen {
 		name = name[:maxLen-3] + "..."
 	}
@@ -313,8 +444,61 @@ func (m Model) renderNode(b *strings.Builder, node *Node, selected bool) {
 	} else {
 		style = defaultStyle
 	}
+	if node.FolderNote != "" {
+		style = style.Underline(true)
+	}
+	if m.marked[node.Path] {
+		style = style.Foreground(markedColor).Bold(true)
+	}
+	if m.clipCut && m.inClip(node.Path) {
+		style = style.Faint(true)
+	}
 
 	b.WriteString(style.Render(name))
+
+	used := node.Depth*2 + 2 + lipgloss.Width(name)
+	if marker != "" {
+		b.WriteByte(' ')
+		b.WriteString(marker)
+		used += 2
+	}
+
+	if len(columns) > 0 {
+		b.WriteString(strings.Repeat(" ", max(0, m.width-used-columnsWidth(columns))))
+		b.WriteString(m.renderColumns(node, columns))
+	}
+}
+
+// gitMarker returns the coloured git status letter shown after a node's name
+func (m Model) gitMarker(node *Node) string {
+	if node.IsDir {
+		if m.gitDirs[node.Path] {
+			return gitFolderStyle.Render("•")
+		}
+		return ""
+	}
+
+	status := m.gitStatus[node.Path]
+	switch status {
+	case "":
+		return ""
+	case "A", "?":
+		return gitAddedStyle.Render(status)
+	case "D", "U":
+		return gitDeletedStyle.Render(status)
+	}
+	return gitModifiedStyle.Render(status)
+}
+
+// SetGitStatus sets the per-file git status markers, keyed by vault-relative path
+func (m *Model) SetGitStatus(status map[string]string) {
+	m.gitStatus = status
+	m.gitDirs = make(map[string]bool)
+	for path := range status {
+		for dir := filepath.Dir(path); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			m.gitDirs[dir] = true
+		}
+	}
 }
 
 func (m *Model) SetSize(width, height int) {
@@ -323,7 +507,12 @@ func (m *Model) SetSize(width, height int) {
 }
 
 func (m *Model) SetFocused(focused bool) {
+	if focused && !m.focused {
+		// The note may have been edited while another pane had focus
+		m.peek = peekNote{}
+	}
 	m.focused = focused
+	m.syncPeek()
 }
 
 func (m Model) Focused() bool {
@@ -334,7 +523,93 @@ func (m Model) SelectedPath() string {
 	return m.selectedPath
 }
 
+// CursorNode returns the node under the cursor, or nil
+func (m Model) CursorNode() *Node {
+	if m.Cursor >= 0 && m.Cursor < len(m.FlatNodes) {
+		return m.FlatNodes[m.Cursor]
+	}
+	return nil
+}
+
+// Reveal expands the folders leading to path and puts the cursor on it
+func (m *Model) Reveal(path string) bool {
+	var find func(node *Node) *Node
+	find = func(node *Node) *Node {
+		for _, child := range node.Children {
+			if child.Path == path || child.FolderNote == path {
+				return child
+			}
+			if child.IsDir && strings.HasPrefix(path, child.Path+string(filepath.Separator)) {
+				return find(child)
+			}
+		}
+		return nil
+	}
+	target := find(m.Root)
+	if target == nil {
+		return false
+	}
+	for p := target.Parent; p != nil; p = p.Parent {
+		p.Expanded = true
+	}
+	m.flattenTree()
+	if m.filter != "" && !m.shown(target) {
+		m.filter = ""
+		m.flattenTree()
+	}
+	m.moveCursorTo(target)
+	return true
+}
+
 func (m *Model) Refresh() {
+	expanded := m.ExpandedPaths()
 	m.vault.Scan()
 	m.buildTree()
+	m.SetExpanded(expanded)
+	m.peek = peekNote{}
+	m.syncPeek()
+
+	for path := range m.marked {
+		if !m.vault.HasFile(path) {
+			delete(m.marked, path)
+		}
+	}
+}
+
+// ExpandedPaths returns the folders that are currently expanded
+func (m Model) ExpandedPaths() []string {
+	var paths []string
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		for _, child := range node.Children {
+			if child.IsDir && child.Expanded {
+				paths = append(paths, child.Path)
+				walk(child)
+			}
+		}
+	}
+	walk(m.Root)
+	return paths
+}
+
+// SetExpanded expands the given folders, ignoring ones that no longer exist
+func (m *Model) SetExpanded(paths []string) {
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		for _, child := range node.Children {
+			if child.IsDir {
+				child.Expanded = want[child.Path]
+				walk(child)
+			}
+		}
+	}
+	walk(m.Root)
+	m.flattenTree()
+	if m.Cursor >= len(m.FlatNodes) {
+		m.Cursor = len(m.FlatNodes) - 1
+	}
 }
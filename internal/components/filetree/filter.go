@@ -0,0 +1,142 @@
+package filetree
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StartFilter opens the filter input at the top of the tree. The tree is
+// filtered as the query is typed.
+func (m *Model) StartFilter() tea.Cmd {
+	m.editing = editFilter
+	m.input.Placeholder = "Filter by name"
+	m.input.SetValue(m.filter)
+	m.input.CursorEnd()
+	m.input.Width = max(10, m.width-10)
+	return m.input.Focus()
+}
+
+// Filter returns the current filter, "" when the whole tree is shown
+func (m Model) Filter() string {
+	return m.filter
+}
+
+// SetFilter shows only nodes whose name contains query, case-insensitively,
+// along with the folders leading to them
+func (m *Model) SetFilter(query string) {
+	m.filter = strings.TrimSpace(query)
+	m.flattenTree()
+
+	// Start on the first match rather than a folder above it
+	m.Cursor = 0
+	if m.filter == "" {
+		return
+	}
+	for i, node := range m.FlatNodes {
+		if i > 0 && m.matches(node) {
+			m.Cursor = i
+			return
+		}
+	}
+}
+
+// ClearFilter shows the whole tree again, keeping the cursor where it is
+func (m *Model) ClearFilter() {
+	if m.filter == "" {
+		return
+	}
+	cur := m.CursorNode()
+	m.filter = ""
+	m.flattenTree()
+	m.moveCursorTo(cur)
+}
+
+// shown reports whether node is in the flattened tree
+func (m Model) shown(node *Node) bool {
+	for _, n := range m.FlatNodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) matches(node *Node) bool {
+	return strings.Contains(strings.ToLower(node.Name), strings.ToLower(m.filter))
+}
+
+// flattenFiltered lists node if it or anything below it matches, with the
+// matches below it. Folders are opened regardless of their expanded state.
+func (m *Model) flattenFiltered(node *Node) []*Node {
+	var below []*Node
+	for _, child := range node.Children {
+		below = append(below, m.flattenFiltered(child)...)
+	}
+	if len(below) == 0 && !m.matches(node) {
+		return nil
+	}
+	return append([]*Node{node}, below...)
+}
+
+// showsChildren reports whether the rows after a folder are its children,
+// for drawing its arrow
+func (m Model) showsChildren(node *Node, i int) bool {
+	if m.filter == "" {
+		return node.Expanded
+	}
+	return i+1 < len(m.FlatNodes) && m.FlatNodes[i+1].Parent == node
+}
+
+// updateFilter handles keys while the filter is typed. Enter keeps the
+// filter and returns to the tree; Esc clears it.
+func (m Model) updateFilter(msg tea.Msg) (Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.stopEditing()
+			m.SetFilter("")
+			return m, nil
+		case "enter", "down", "up":
+			m.stopEditing()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	prev := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != prev {
+		m.SetFilter(m.input.Value())
+	}
+	return m, cmd
+}
+
+// CollapseAll closes every folder, leaving the cursor on the top-level one
+// it was in
+func (m *Model) CollapseAll() {
+	cur := m.CursorNode()
+	for cur != nil && cur.Parent != nil && cur.Parent != m.Root {
+		cur = cur.Parent
+	}
+	m.setAllExpanded(m.Root, false)
+	m.flattenTree()
+	m.moveCursorTo(cur)
+}
+
+// ExpandAll opens every folder
+func (m *Model) ExpandAll() {
+	cur := m.CursorNode()
+	m.setAllExpanded(m.Root, true)
+	m.flattenTree()
+	m.moveCursorTo(cur)
+}
+
+func (m *Model) setAllExpanded(node *Node, expanded bool) {
+	for _, child := range node.Children {
+		if child.IsDir {
+			child.Expanded = expanded
+			m.setAllExpanded(child, expanded)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package filetree
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// FolderIndexMsg asks for the index of a folder without a folder note
+type FolderIndexMsg struct {
+	Dir string
+}
+
+// SetFolderNotes turns folder notes on or off. When on, a folder's folder
+// note is hidden from its children and opened by selecting the folder.
+func (m *Model) SetFolderNotes(on bool) {
+	if m.folderNotes == on {
+		return
+	}
+	m.folderNotes = on
+	expanded := m.ExpandedPaths()
+	m.buildTree()
+	m.SetExpanded(expanded)
+}
+
+// hideFolderNotes moves folder notes out of the children of their folders
+func hideFolderNotes(node *Node) {
+	for i := 0; i < len(node.Children); i++ {
+		child := node.Children[i]
+		if child.IsDir {
+			hideFolderNotes(child)
+			continue
+		}
+		if node.Parent != nil && child.Path == vault.FolderNotePath(node.Path) {
+			node.FolderNote = child.Path
+			node.Children = append(node.Children[:i], node.Children[i+1:]...)
+			i--
+		}
+	}
+}
+
+// open opens a note, or the folder note of a folder and shows what is in it
+func (m *Model) open(node *Node) tea.Cmd {
+	path := node.Path
+	if node.IsDir {
+		if !node.Expanded {
+			node.Expanded = true
+			m.flattenTree()
+		}
+		path = node.FolderNote
+	}
+	m.selectedPath = path
+	return func() tea.Msg { return FileSelectedMsg{Path: path} }
+}
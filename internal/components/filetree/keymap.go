@@ -0,0 +1,37 @@
+package filetree
+
+import "github.com/takahashinaoki/obsidiantui/internal/keymap"
+
+// keys are the bindings shared by every file tree
+var keys = DefaultKeymap()
+
+// DefaultKeymap returns the file tree bindings before any configuration
+func DefaultKeymap() *keymap.Map {
+	km := keymap.NewMap("tree")
+	bind := func(action, help string, seqs ...string) {
+		parsed := make([]keymap.Sequence, len(seqs))
+		for i, s := range seqs {
+			parsed[i] = keymap.MustParse(s)
+		}
+		km.Bind(action, help, parsed...)
+	}
+
+	bind("filter", "filter", "f")
+	bind("sort_next", "next sort", "s")
+	bind("sort_reverse", "previous sort", "S")
+	bind("peek", "peek", "i")
+	bind("collapse_all", "collapse all", "W")
+	bind("expand_all", "expand all", "E")
+	bind("open", "open note or folder index", "o")
+	return km
+}
+
+// SetKeymap installs the bindings used by every file tree
+func SetKeymap(km *keymap.Map) {
+	keys = km
+}
+
+// Keymap returns the bindings in use
+func Keymap() *keymap.Map {
+	return keys
+}
@@ -0,0 +1,311 @@
+package filetree
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The tree only asks for file operations; the caller performs them, since
+// open notes, bookmarks and history have to follow, and then calls Refresh.
+
+// CreateMsg asks for a note or folder to be created
+type CreateMsg struct {
+	Path  string
+	IsDir bool
+}
+
+// RenameMsg asks for a note or folder to be renamed
+type RenameMsg struct {
+	From, To string
+}
+
+// PasteMsg asks for cut or copied paths to be moved or copied into Dir
+type PasteMsg struct {
+	Paths []string
+	Dir   string
+	Move  bool
+}
+
+// DuplicateMsg asks for copies of paths next to the originals
+type DuplicateMsg struct {
+	Paths []string
+}
+
+// DeleteMsg asks for paths to be deleted, which the caller should confirm
+type DeleteMsg struct {
+	Paths []string
+}
+
+// ClipboardMsg is sent after paths are cut or copied
+type ClipboardMsg struct {
+	Paths []string
+	Cut   bool
+}
+
+type editMode int
+
+const (
+	editNone editMode = iota
+	editRename
+	editNewNote
+	editNewFolder
+	editFilter
+)
+
+func newInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.CharLimit = 255
+	return ti
+}
+
+// Editing reports whether a name is being typed, so keys should go to the
+// tree rather than global bindings
+func (m Model) Editing() bool {
+	return m.editing != editNone
+}
+
+// Selection returns the marked paths, or else the one under the cursor. The
+// vault folder itself is never included.
+func (m Model) Selection() []string {
+	if len(m.marked) > 0 {
+		paths := make([]string, 0, len(m.marked))
+		for p := range m.marked {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		return outermost(paths)
+	}
+	if node := m.CursorNode(); node != nil && node.Path != "" {
+		return []string{node.Path}
+	}
+	return nil
+}
+
+// outermost drops paths inside other paths of the sorted list, which go
+// along with their folder anyway
+func outermost(paths []string) []string {
+	var kept []string
+	for _, p := range paths {
+		if n := len(kept); n > 0 && strings.HasPrefix(p, kept[n-1]+string(filepath.Separator)) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// ClearSelection unmarks everything
+func (m *Model) ClearSelection() {
+	m.marked = make(map[string]bool)
+}
+
+// targetDir is the folder new and pasted items go in: the folder under the
+// cursor, or the folder of the note under it
+func (m Model) targetDir() (dir string, depth int) {
+	node := m.CursorNode()
+	switch {
+	case node == nil:
+		return "", 1
+	case node.IsDir:
+		return node.Path, node.Depth + 1
+	case node.Parent != nil:
+		return node.Parent.Path, node.Depth
+	}
+	return "", 1
+}
+
+// StartCreate opens an input below the cursor for the name of a new note,
+// or folder with isDir. Names may contain "/" to create subfolders.
+func (m *Model) StartCreate(isDir bool) tea.Cmd {
+	if node := m.CursorNode(); node != nil && node.IsDir && !node.Expanded {
+		node.Expanded = true
+		m.flattenTree()
+	}
+	m.editing = editNewNote
+	m.input.Placeholder = "New note"
+	if isDir {
+		m.editing = editNewFolder
+		m.input.Placeholder = "New folder"
+	}
+	m.input.SetValue("")
+	m.input.Width = max(10, m.width-m.editDepth()*2-4)
+	return m.input.Focus()
+}
+
+// StartRename opens an input over the name of the node under the cursor
+func (m *Model) StartRename() tea.Cmd {
+	node := m.CursorNode()
+	if node == nil || node.Path == "" {
+		return nil
+	}
+	name := node.Name
+	if !node.IsDir {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	m.editing = editRename
+	m.input.Placeholder = name
+	m.input.SetValue(name)
+	m.input.CursorEnd()
+	m.input.Width = max(10, m.width-node.Depth*2-4)
+	return m.input.Focus()
+}
+
+func (m Model) editDepth() int {
+	if m.editing == editRename {
+		if node := m.CursorNode(); node != nil {
+			return node.Depth
+		}
+	}
+	_, depth := m.targetDir()
+	return depth
+}
+
+func (m *Model) stopEditing() {
+	m.editing = editNone
+	m.input.Blur()
+}
+
+// notePath adds the note extension to a typed name that lacks it
+func notePath(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".md") {
+		return name
+	}
+	return name + ".md"
+}
+
+// updateEditing handles keys while a name is typed
+func (m Model) updateEditing(msg tea.Msg) (Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.stopEditing()
+			return m, nil
+
+		case "enter":
+			name := filepath.FromSlash(strings.TrimSpace(m.input.Value()))
+			mode := m.editing
+			m.stopEditing()
+			if name == "" {
+				return m, nil
+			}
+
+			switch mode {
+			case editRename:
+				node := m.CursorNode()
+				if node == nil || node.Path == "" {
+					return m, nil
+				}
+				if !node.IsDir {
+					name = notePath(name)
+				}
+				to := filepath.Join(filepath.Dir(node.Path), name)
+				if to == node.Path {
+					return m, nil
+				}
+				return m, func() tea.Msg { return RenameMsg{From: node.Path, To: to} }
+
+			case editNewNote, editNewFolder:
+				dir, _ := m.targetDir()
+				isDir := mode == editNewFolder || strings.HasSuffix(name, string(filepath.Separator))
+				if !isDir {
+					name = notePath(name)
+				}
+				path := filepath.Join(dir, name)
+				return m, func() tea.Msg { return CreateMsg{Path: path, IsDir: isDir} }
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateOps handles the file operation keys, reporting false for others
+func (m Model) updateOps(keyMsg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	switch keyMsg.String() {
+	case "v":
+		if node := m.CursorNode(); node != nil && node.Path != "" {
+			if m.marked[node.Path] {
+				delete(m.marked, node.Path)
+			} else {
+				m.marked[node.Path] = true
+			}
+			if m.Cursor < len(m.FlatNodes)-1 {
+				m.Cursor++
+			}
+		}
+
+	case "esc":
+		// Also clears the filter, so it isn't reported as handled
+		m.ClearSelection()
+		m.clip, m.clipCut = nil, false
+		return m, nil, false
+
+	case "a":
+		return m, m.StartCreate(false), true
+
+	case "A":
+		return m, m.StartCreate(true), true
+
+	case "r":
+		return m, m.StartRename(), true
+
+	case "x", "c":
+		paths := m.Selection()
+		if len(paths) == 0 {
+			return m, nil, true
+		}
+		m.clip, m.clipCut = paths, keyMsg.String() == "x"
+		m.ClearSelection()
+		msg := ClipboardMsg{Paths: paths, Cut: m.clipCut}
+		return m, func() tea.Msg { return msg }, true
+
+	case "p":
+		if len(m.clip) == 0 {
+			return m, nil, true
+		}
+		dir, _ := m.targetDir()
+		msg := PasteMsg{Paths: m.clip, Dir: dir, Move: m.clipCut}
+		if m.clipCut {
+			// Cut paths are gone once moved; copied ones can be pasted again
+			m.clip, m.clipCut = nil, false
+		}
+		return m, func() tea.Msg { return msg }, true
+
+	case "C":
+		paths := m.Selection()
+		if len(paths) == 0 {
+			return m, nil, true
+		}
+		m.ClearSelection()
+		return m, func() tea.Msg { return DuplicateMsg{Paths: paths} }, true
+
+	case "d":
+		paths := m.Selection()
+		if len(paths) == 0 {
+			return m, nil, true
+		}
+		return m, func() tea.Msg { return DeleteMsg{Paths: paths} }, true
+
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// inClip reports whether path was cut or copied
+func (m Model) inClip(path string) bool {
+	for _, p := range m.clip {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
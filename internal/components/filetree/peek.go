@@ -0,0 +1,165 @@
+package filetree
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+var (
+	peekStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1)
+	peekTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	peekKeyStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+)
+
+// peekLines is how many lines of a note the peek shows
+const peekLines = 10
+
+// peekNote is the note in the peek, read when the cursor moves rather than
+// each time the peek is drawn
+type peekNote struct {
+	path string
+	fm   map[string]string
+	body string
+	err  error
+}
+
+// TogglePeek shows or hides the peek at the note under the cursor
+func (m *Model) TogglePeek() {
+	m.peeking = !m.peeking
+	m.peek = peekNote{}
+	m.statNodes(m.Root)
+	m.syncPeek()
+}
+
+// peekPath is the note the peek shows for node, "" for a plain folder
+func peekPath(node *Node) string {
+	if node.IsDir {
+		return node.FolderNote
+	}
+	return node.Path
+}
+
+// syncPeek reads the note under the cursor if the peek shows another one
+func (m *Model) syncPeek() {
+	node := m.CursorNode()
+	if !m.peeking || node == nil {
+		return
+	}
+	path := peekPath(node)
+	if path == "" || path == m.peek.path {
+		return
+	}
+	content, err := m.vault.ReadFile(path)
+	fm, body := parser.ExtractFrontmatter(content)
+	m.peek = peekNote{path: path, fm: fm, body: body, err: err}
+}
+
+// Peeking reports whether the peek is shown
+func (m Model) Peeking() bool {
+	return m.peeking
+}
+
+// CursorRow returns the line of the tree's view the cursor is on
+func (m Model) CursorRow() int {
+	start, _ := m.visibleRange()
+	return m.headerHeight() + m.Cursor - start
+}
+
+// PeekView draws the frontmatter and first lines of the note under the
+// cursor, or what a folder holds, in a box at most width wide
+func (m Model) PeekView(width int) string {
+	node := m.CursorNode()
+	if node == nil || width < 20 {
+		return ""
+	}
+	inner := width - peekStyle.GetHorizontalFrameSize()
+
+	var lines []string
+	if node.IsDir && node.FolderNote == "" {
+		lines = append(lines, peekTitleStyle.Render(ansi.Truncate(node.Name, inner, "…")))
+		folders := 0
+		for _, child := range node.Children {
+			if child.IsDir {
+				folders++
+			}
+		}
+		lines = append(lines, columnStyle.Render(fmt.Sprintf("%d notes, %d folders", node.Notes, folders)))
+		return peekStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+	}
+
+	// A folder with a folder note shows the note
+	path := peekPath(node)
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	lines = append(lines, peekTitleStyle.Render(ansi.Truncate(title, inner, "…")))
+	lines = append(lines, columnStyle.Render(ansi.Truncate(m.peekStats(path, node.ModTime), inner, "…")))
+
+	if m.peek.path != path {
+		return peekStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+	}
+	if m.peek.err != nil {
+		lines = append(lines, "", m.peek.err.Error())
+		return peekStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+	}
+
+	fm, body := m.peek.fm, m.peek.body
+	if len(fm) > 0 {
+		lines = append(lines, "")
+		keys := make([]string, 0, len(fm))
+		for k := range fm {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			line := peekKeyStyle.Render(k+":") + " " + fm[k]
+			lines = append(lines, ansi.Truncate(line, inner, "…"))
+		}
+	}
+
+	var text []string
+	for _, line := range strings.Split(body, "\n") {
+		if len(text) == peekLines {
+			break
+		}
+		if strings.TrimSpace(line) == "" && (len(text) == 0 || text[len(text)-1] == "") {
+			continue
+		}
+		text = append(text, ansi.Truncate(strings.TrimRight(line, " \t"), inner, "…"))
+	}
+	for len(text) > 0 && text[len(text)-1] == "" {
+		text = text[:len(text)-1]
+	}
+	if len(text) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, text...)
+	}
+
+	return peekStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+}
+
+// peekStats summarises a note's details from the index
+func (m Model) peekStats(path string, modTime time.Time) string {
+	stats := m.vault.Stats(path)
+	if stats.ModTime.After(modTime) {
+		modTime = stats.ModTime
+	}
+
+	parts := []string{fmt.Sprintf("%d words", stats.Words)}
+	if stats.Tasks > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d tasks", stats.TasksDone, stats.Tasks))
+	}
+	parts = append(parts, fmt.Sprintf("%d backlinks", stats.Backlinks))
+	if !modTime.IsZero() {
+		parts = append(parts, modTime.Format("2006-01-02 15:04"))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,34 @@
+package filetree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestPeekReadsOnCursorMove(t *testing.T) {
+	v := vaulttest.New(t, map[string]string{"a.md": "first note", "b.md": "second note"})
+	m := New(v)
+	m.Cursor = 1 // a.md, below the vault's root
+	m.TogglePeek()
+	if view := m.PeekView(40); !strings.Contains(view, "first note") {
+		t.Fatalf("PeekView() = %q, want a.md's content", view)
+	}
+
+	// Drawing again uses what was read, not the file
+	if err := os.WriteFile(filepath.Join(v.Path, "a.md"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if view := m.PeekView(40); !strings.Contains(view, "first note") {
+		t.Errorf("PeekView() read the file again: %q", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if view := m.PeekView(40); !strings.Contains(view, "second note") {
+		t.Errorf("PeekView() after moving down = %q, want b.md's content", view)
+	}
+}
@@ -0,0 +1,203 @@
+package filetree
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// SortBy is what notes are ordered by. Folders always come first, by name.
+type SortBy int
+
+const (
+	SortName SortBy = iota
+	SortModified
+	SortCreated
+	SortSize
+	SortLinks // notes linking to the note
+)
+
+var sortNames = []string{"name", "modified", "created", "size", "links"}
+
+func (s SortBy) String() string {
+	if int(s) < len(sortNames) {
+		return sortNames[s]
+	}
+	return "name"
+}
+
+// ParseSortBy reads a sort mode as written in the config, e.g. "modified"
+func ParseSortBy(name string) (SortBy, bool) {
+	for i, n := range sortNames {
+		if strings.EqualFold(name, n) {
+			return SortBy(i), true
+		}
+	}
+	return SortName, false
+}
+
+// needsStat reports whether the mode orders notes by what os.Lstat returns
+func (s SortBy) needsStat() bool {
+	return s == SortModified || s == SortCreated || s == SortSize
+}
+
+// IndexedMsg is sent once the vault's index is built and link counts are
+// known
+type IndexedMsg struct{}
+
+// waitIndex waits in the background for the vault's index
+func (m Model) waitIndex() tea.Cmd {
+	v := m.vault
+	return func() tea.Msg {
+		v.WaitIndex()
+		return IndexedMsg{}
+	}
+}
+
+// Indexed re-sorts a tree ordered by links now the counts are known
+func (m *Model) Indexed() {
+	if m.sortBy == SortLinks {
+		m.resort()
+	}
+}
+
+// SortChangedMsg is sent when the sort mode is changed from the tree
+type SortChangedMsg struct {
+	By         SortBy
+	Descending bool
+}
+
+// SetSort orders the tree by the given mode
+func (m *Model) SetSort(by SortBy, descending bool) {
+	m.sortBy, m.sortDesc = by, descending
+	m.resort()
+}
+
+// Sort returns the current sort mode
+func (m Model) Sort() (SortBy, bool) {
+	return m.sortBy, m.sortDesc
+}
+
+// resort reorders the tree keeping the cursor on the same node
+func (m *Model) resort() {
+	cur := m.CursorNode()
+	m.statNodes(m.Root)
+	m.sortNodes(m.Root)
+	m.flattenTree()
+	m.moveCursorTo(cur)
+}
+
+// moveCursorTo puts the cursor on node if it is shown
+func (m *Model) moveCursorTo(node *Node) {
+	defer m.syncPeek()
+	for i, n := range m.FlatNodes {
+		if n == node {
+			m.Cursor = i
+			return
+		}
+	}
+	m.Cursor = max(0, min(m.Cursor, len(m.FlatNodes)-1))
+}
+
+// needsStat reports whether the tree orders or shows notes by their times
+// and sizes: when sorting by them, with the modified column, or in the peek
+func (m Model) needsStat() bool {
+	return m.sortBy.needsStat() || m.peeking || slices.Contains(m.columns, ColumnModified)
+}
+
+// statNodes fills in the times and sizes of the notes below node, once per
+// tree and only when the tree needs them
+func (m *Model) statNodes(node *Node) {
+	if !m.needsStat() || m.statted {
+		return
+	}
+	m.statted = true
+	m.walkStat(node)
+}
+
+func (m *Model) walkStat(node *Node) {
+	for _, child := range node.Children {
+		if child.IsDir {
+			m.walkStat(child)
+			continue
+		}
+		path := filepath.Join(m.vault.Path, child.Path)
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		child.ModTime = info.ModTime()
+		child.Size = info.Size()
+		child.Created = vault.CreatedTime(path, info)
+	}
+}
+
+func (m *Model) sortNodes(node *Node) {
+	// Link counts change as notes are edited, so they are read at sort time
+	if m.sortBy == SortLinks {
+		for _, child := range node.Children {
+			if !child.IsDir {
+				child.Links = len(m.vault.GetBacklinks(child.Path))
+			}
+		}
+	}
+
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return m.less(node.Children[i], node.Children[j])
+	})
+
+	for _, child := range node.Children {
+		if child.IsDir {
+			m.sortNodes(child)
+		}
+	}
+}
+
+func compareNames(a, b *Node) int {
+	return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+}
+
+func (m Model) less(a, b *Node) bool {
+	if a.IsDir != b.IsDir {
+		return a.IsDir
+	}
+
+	var c int
+	switch {
+	case a.IsDir && m.sortBy != SortName:
+		return compareNames(a, b) < 0
+	case m.sortBy == SortModified:
+		c = a.ModTime.Compare(b.ModTime)
+	case m.sortBy == SortCreated:
+		c = a.Created.Compare(b.Created)
+	case m.sortBy == SortSize:
+		c = cmp.Compare(a.Size, b.Size)
+	case m.sortBy == SortLinks:
+		c = cmp.Compare(a.Links, b.Links)
+	default:
+		c = compareNames(a, b)
+	}
+	if m.sortDesc {
+		c = -c
+	}
+	if c == 0 {
+		return compareNames(a, b) < 0
+	}
+	return c < 0
+}
+
+// cycleSort moves to the next sort mode, or flips the direction with reverse
+func (m *Model) cycleSort(reverse bool) SortChangedMsg {
+	if reverse {
+		m.SetSort(m.sortBy, !m.sortDesc)
+	} else {
+		m.SetSort((m.sortBy+1)%SortBy(len(sortNames)), m.sortDesc)
+	}
+	return SortChangedMsg{By: m.sortBy, Descending: m.sortDesc}
+}
@@ -0,0 +1,43 @@
+package filetree
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestSort(t *testing.T) {
+	v := vaulttest.New(t, map[string]string{
+		"a.md": "no links",
+		"b.md": "[[c]]",
+		"c.md": "[[b]] and [[a]]",
+		"d.md": "[[c]]",
+	})
+	m := New(v)
+
+	names := func() (got []string) {
+		for _, n := range m.Root.Children {
+			got = append(got, n.Name)
+		}
+		return got
+	}
+
+	if n := m.Root.Children[0]; !n.ModTime.IsZero() {
+		t.Error("notes were stat'd for sorting by name")
+	}
+	m.SetSort(SortModified, false)
+	if n := m.Root.Children[0]; n.ModTime.IsZero() {
+		t.Error("notes were not stat'd for sorting by modification time")
+	}
+
+	// Counts read before the index is built are fixed up once it is
+	m.SetSort(SortLinks, true)
+	if _, ok := m.Init()().(IndexedMsg); !ok {
+		t.Fatal("Init() does not wait for the index")
+	}
+	m.Indexed()
+	if got, want := names(), []string{"c.md", "a.md", "b.md", "d.md"}; !slices.Equal(got, want) {
+		t.Errorf("sorted by links = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package folderindex
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Padding(0, 1)
+	subtitleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Italic(true)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	folderStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	noteStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	tagStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	detailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("57")).Padding(1)
+)
+
+// FileSelectedMsg is sent when a note, or a subfolder's folder note, is picked
+type FileSelectedMsg struct {
+	Path string
+}
+
+// CreateFolderNoteMsg asks for the folder note of Dir to be created
+type CreateFolderNoteMsg struct {
+	Dir string
+}
+
+// FolderIndexClosedMsg is sent when the index is closed without a choice
+type FolderIndexClosedMsg struct{}
+
+// Model lists the notes and subfolders of a folder that has no folder note,
+// with the titles, tags and descriptions of the notes
+type Model struct {
+	vault   *vault.Vault
+	dir     string
+	entries []vault.FolderEntry
+	cursor  int
+	width   int
+	height  int
+	active  bool
+}
+
+func New(v *vault.Vault) Model {
+	return Model{vault: v}
+}
+
+// Show opens the index of dir, "" for the vault folder
+func (m *Model) Show(dir string) {
+	m.active = true
+	m.dir = dir
+	m.entries = m.vault.FolderIndex(dir)
+	m.cursor = 0
+}
+
+func (m *Model) Hide() {
+	m.active = false
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "esc", "q":
+		m.Hide()
+		return m, func() tea.Msg { return FolderIndexClosedMsg{} }
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.entries)-1 {
+			m.cursor++
+		}
+
+	case "backspace", "h":
+		if m.dir != "" {
+			parent := filepath.Dir(m.dir)
+			if parent == "." {
+				parent = ""
+			}
+			return m.open(parent)
+		}
+
+	case "enter", "l":
+		if m.cursor >= len(m.entries) {
+			return m, nil
+		}
+		entry := m.entries[m.cursor]
+		if entry.IsDir {
+			return m.open(entry.Path)
+		}
+		m.Hide()
+		return m, func() tea.Msg { return FileSelectedMsg{Path: entry.Path} }
+
+	case "n":
+		if m.dir == "" {
+			return m, nil
+		}
+		if m.vault.FolderNote(m.dir) != "" {
+			return m.open(m.dir)
+		}
+		dir := m.dir
+		m.Hide()
+		return m, func() tea.Msg { return CreateFolderNoteMsg{Dir: dir} }
+	}
+	return m, nil
+}
+
+// open moves to another folder, opening its folder note instead if it has one
+func (m Model) open(dir string) (Model, tea.Cmd) {
+	if note := m.vault.FolderNote(dir); note != "" {
+		m.Hide()
+		return m, func() tea.Msg { return FileSelectedMsg{Path: note} }
+	}
+	m.Show(dir)
+	return m, nil
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	subtitle := filepath.Base(m.vault.Path)
+	switch {
+	case m.dir != "" && m.vault.FolderNote(m.dir) == "":
+		subtitle = m.dir + " has no folder note (n: create it, h: parent folder)"
+	case m.dir != "":
+		subtitle = m.dir + " (n: open its folder note, h: parent folder)"
+	}
+	b.WriteString(titleStyle.Render("Folder Index") + "\n")
+	b.WriteString(subtitleStyle.Render(ansi.Truncate(subtitle, m.width-4, "…")) + "\n\n")
+
+	if len(m.entries) == 0 {
+		b.WriteString(detailStyle.Render("  Nothing in this folder"))
+		return containerStyle.Width(m.width).Render(b.String())
+	}
+
+	// Notes with a description take two lines, so count on that
+	inner := m.width - 4
+	maxVisible := max(3, (m.height-6)/2)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.entries))
+
+	for i := start; i < end; i++ {
+		b.WriteString(m.renderEntry(m.entries[i], i == m.cursor, inner))
+		b.WriteByte('\n')
+	}
+	if end < len(m.entries) {
+		b.WriteString(detailStyle.Render(fmt.Sprintf("  ... and %d more", len(m.entries)-end)))
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m Model) renderEntry(e vault.FolderEntry, selected bool, width int) string {
+	prefix := "  "
+	if selected {
+		prefix = "▶ "
+	}
+
+	if e.IsDir {
+		line := prefix + "▸ " + e.Title
+		style := folderStyle
+		if selected {
+			style = selectedStyle
+		}
+		count := fmt.Sprintf(" %d notes", e.Notes)
+		if e.Notes == 1 {
+			count = " 1 note"
+		}
+		return style.Render(ansi.Truncate(line, width, "…")) + detailStyle.Render(count)
+	}
+
+	style := noteStyle
+	if selected {
+		style = selectedStyle
+	}
+	line := style.Render(ansi.Truncate(prefix+e.Title, width, "…"))
+	if len(e.Tags) > 0 {
+		tags := "#" + strings.Join(e.Tags, " #")
+		if room := width - ansi.StringWidth(prefix+e.Title) - 1; room > 3 {
+			line += " " + tagStyle.Render(ansi.Truncate(tags, room, "…"))
+		}
+	}
+	if e.Description != "" {
+		line += "\n" + detailStyle.Render(ansi.Truncate("    "+e.Description, width, "…"))
+	}
+	return line
+}
@@ -0,0 +1,352 @@
+package graph
+
+import (
+	"math"
+	"sort"
+)
+
+// Analysis holds link graph metrics computed over the vault's notes
+type Analysis struct {
+	IDs         []string
+	InDegree    map[string]int
+	OutDegree   map[string]int
+	PageRank    map[string]float64
+	Communities [][]string // clusters of densely linked notes, largest first
+	Components  [][]string // strongly connected components with more than one note
+	Bridges     []Edge     // links whose removal disconnects part of the graph
+
+	out        map[string][]string
+	undirected map[string][]string
+}
+
+// Analyze computes degree, PageRank, cluster, component and bridge metrics
+func Analyze(ids []string, edges []Edge) *Analysis {
+	a := &Analysis{
+		IDs:        append([]string(nil), ids...),
+		InDegree:   make(map[string]int),
+		OutDegree:  make(map[string]int),
+		out:        make(map[string][]string),
+		undirected: make(map[string][]string),
+	}
+	sort.Strings(a.IDs)
+
+	known := make(map[string]bool)
+	for _, id := range a.IDs {
+		known[id] = true
+	}
+
+	seen := make(map[Edge]bool)
+	seenUndirected := make(map[Edge]bool)
+	for _, e := range edges {
+		if seen[e] || !known[e.Source] || !known[e.Target] || e.Source == e.Target {
+			continue
+		}
+		seen[e] = true
+		a.out[e.Source] = append(a.out[e.Source], e.Target)
+		a.OutDegree[e.Source]++
+		a.InDegree[e.Target]++
+
+		u := undirectedKey(e)
+		if !seenUndirected[u] {
+			seenUndirected[u] = true
+			a.undirected[e.Source] = append(a.undirected[e.Source], e.Target)
+			a.undirected[e.Target] = append(a.undirected[e.Target], e.Source)
+		}
+	}
+	for _, id := range a.IDs {
+		sort.Strings(a.out[id])
+		sort.Strings(a.undirected[id])
+	}
+
+	a.computePageRank()
+	a.computeCommunities()
+	a.computeComponents()
+	a.computeBridges()
+
+	return a
+}
+
+func undirectedKey(e Edge) Edge {
+	if e.Source > e.Target {
+		return Edge{Source: e.Target, Target: e.Source}
+	}
+	return e
+}
+
+// ByDegree returns note IDs ordered by total link count, most connected first
+func (a *Analysis) ByDegree() []string {
+	ids := append([]string(nil), a.IDs...)
+	sort.SliceStable(ids, func(i, j int) bool {
+		di := a.InDegree[ids[i]] + a.OutDegree[ids[i]]
+		dj := a.InDegree[ids[j]] + a.OutDegree[ids[j]]
+		if di != dj {
+			return di > dj
+		}
+		return a.InDegree[ids[i]] > a.InDegree[ids[j]]
+	})
+	return ids
+}
+
+// ByPageRank returns note IDs ordered by PageRank score, highest first
+func (a *Analysis) ByPageRank() []string {
+	ids := append([]string(nil), a.IDs...)
+	sort.SliceStable(ids, func(i, j int) bool {
+		return a.PageRank[ids[i]] > a.PageRank[ids[j]]
+	})
+	return ids
+}
+
+func (a *Analysis) computePageRank() {
+	const (
+		damping    = 0.85
+		iterations = 50
+		tolerance  = 1e-9
+	)
+
+	n := float64(len(a.IDs))
+	a.PageRank = make(map[string]float64)
+	if n == 0 {
+		return
+	}
+	for _, id := range a.IDs {
+		a.PageRank[id] = 1 / n
+	}
+
+	for it := 0; it < iterations; it++ {
+		// Rank held by notes without outgoing links is spread over every note
+		dangling := 0.0
+		for _, id := range a.IDs {
+			if len(a.out[id]) == 0 {
+				dangling += a.PageRank[id]
+			}
+		}
+
+		next := make(map[string]float64, len(a.IDs))
+		base := (1-damping)/n + damping*dangling/n
+		for _, id := range a.IDs {
+			next[id] = base
+		}
+		for _, id := range a.IDs {
+			targets := a.out[id]
+			if len(targets) == 0 {
+				continue
+			}
+			share := damping * a.PageRank[id] / float64(len(targets))
+			for _, t := range targets {
+				next[t] += share
+			}
+		}
+
+		delta := 0.0
+		for _, id := range a.IDs {
+			delta += math.Abs(next[id] - a.PageRank[id])
+		}
+		a.PageRank = next
+		if delta < tolerance {
+			break
+		}
+	}
+}
+
+// computeCommunities clusters notes with label propagation over the undirected graph
+func (a *Analysis) computeCommunities() {
+	label := make(map[string]string)
+	for _, id := range a.IDs {
+		label[id] = id
+	}
+
+	for it := 0; it < 20; it++ {
+		changed := false
+		for _, id := range a.IDs {
+			neighbours := a.undirected[id]
+			if len(neighbours) == 0 {
+				continue
+			}
+
+			counts := make(map[string]int)
+			for _, n := range neighbours {
+				counts[label[n]]++
+			}
+
+			best := label[id]
+			bestCount := counts[best]
+			for l, c := range counts {
+				if c > bestCount || (c == bestCount && l < best) {
+					best, bestCount = l, c
+				}
+			}
+			if best != label[id] {
+				label[id] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, id := range a.IDs {
+		if len(a.undirected[id]) == 0 {
+			continue
+		}
+		groups[label[id]] = append(groups[label[id]], id)
+	}
+
+	a.Communities = nil
+	for _, members := range groups {
+		if len(members) > 1 {
+			a.Communities = append(a.Communities, members)
+		}
+	}
+	sortGroups(a.Communities)
+}
+
+// computeComponents finds strongly connected components with Tarjan's algorithm
+func (a *Analysis) computeComponents() {
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+
+	a.Components = nil
+
+	var strongConnect func(v string)
+	strongConnect = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range a.out[v] {
+			if _, visited := indices[w]; !visited {
+				strongConnect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], indices[w])
+			}
+		}
+
+		if lowlink[v] == indices[v] {
+			var component []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			if len(component) > 1 {
+				sort.Strings(component)
+				a.Components = append(a.Components, component)
+			}
+		}
+	}
+
+	for _, id := range a.IDs {
+		if _, visited := indices[id]; !visited {
+			strongConnect(id)
+		}
+	}
+	sortGroups(a.Components)
+}
+
+// computeBridges finds links whose removal splits the undirected graph
+func (a *Analysis) computeBridges() {
+	timer := 0
+	disc := make(map[string]int)
+	low := make(map[string]int)
+
+	a.Bridges = nil
+
+	var visit func(v, parent string)
+	visit = func(v, parent string) {
+		timer++
+		disc[v] = timer
+		low[v] = timer
+
+		skippedParent := false
+		for _, w := range a.undirected[v] {
+			if w == parent && !skippedParent {
+				skippedParent = true
+				continue
+			}
+			if _, visited := disc[w]; visited {
+				low[v] = min(low[v], disc[w])
+				continue
+			}
+			visit(w, v)
+			low[v] = min(low[v], low[w])
+			if low[w] > disc[v] {
+				a.Bridges = append(a.Bridges, undirectedKey(Edge{Source: v, Target: w}))
+			}
+		}
+	}
+
+	for _, id := range a.IDs {
+		if _, visited := disc[id]; !visited {
+			visit(id, "")
+		}
+	}
+
+	sort.Slice(a.Bridges, func(i, j int) bool {
+		if a.Bridges[i].Source != a.Bridges[j].Source {
+			return a.Bridges[i].Source < a.Bridges[j].Source
+		}
+		return a.Bridges[i].Target < a.Bridges[j].Target
+	})
+}
+
+// ShortestPath returns the shortest chain of notes from one note to another.
+// Links are followed in their direction first; if no such path exists the
+// search ignores link direction and directed reports false.
+func (a *Analysis) ShortestPath(from, to string) (path []string, directed bool) {
+	if path := bfs(a.out, from, to); path != nil {
+		return path, true
+	}
+	return bfs(a.undirected, from, to), false
+}
+
+func bfs(adj map[string][]string, from, to string) []string {
+	if from == "" || to == "" {
+		return nil
+	}
+	if from == to {
+		return []string{from}
+	}
+
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range adj[v] {
+			if _, seen := prev[w]; seen {
+				continue
+			}
+			prev[w] = v
+			if w == to {
+				var path []string
+				for at := to; at != ""; at = prev[at] {
+					path = append([]string{at}, path...)
+				}
+				return path
+			}
+			queue = append(queue, w)
+		}
+	}
+	return nil
+}
+
+func sortGroups(groups [][]string) {
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0] < groups[j][0]
+	})
+}
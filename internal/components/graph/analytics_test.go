@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+// edges builds edges from "a>b" pairs
+func edges(pairs ...string) []Edge {
+	var es []Edge
+	for _, p := range pairs {
+		es = append(es, Edge{Source: p[:1], Target: p[2:]})
+	}
+	return es
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []string
+		edges       []Edge
+		components  [][]string
+		communities [][]string
+		bridges     []Edge
+	}{
+		{
+			name:        "cycle",
+			ids:         []string{"a", "b", "c"},
+			edges:       edges("a>b", "b>c", "c>a"),
+			components:  [][]string{{"a", "b", "c"}},
+			communities: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:        "disconnected parts",
+			ids:         []string{"a", "b", "c", "d", "e"},
+			edges:       edges("a>b", "c>d", "d>c"),
+			components:  [][]string{{"c", "d"}},
+			communities: [][]string{{"a", "b"}, {"c", "d"}},
+			bridges:     edges("a>b", "c>d"),
+		},
+		{
+			name:       "bridge between cycles",
+			ids:        []string{"a", "b", "c", "d", "e", "f"},
+			edges:      edges("a>b", "b>c", "c>a", "c>d", "d>e", "e>f", "f>d"),
+			components: [][]string{{"a", "b", "c"}, {"d", "e", "f"}},
+			// Label propagation spreads b's label over the bridge
+			communities: [][]string{{"a", "b", "c", "d", "e", "f"}},
+			bridges:     edges("c>d"),
+		},
+		{
+			name:        "self links, duplicates and unknown notes are ignored",
+			ids:         []string{"a", "b"},
+			edges:       edges("a>a", "a>b", "a>b", "a>z"),
+			communities: [][]string{{"a", "b"}},
+			bridges:     edges("a>b"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Analyze(tt.ids, tt.edges)
+			if !slices.EqualFunc(a.Components, tt.components, slices.Equal) {
+				t.Errorf("Components = %v, want %v", a.Components, tt.components)
+			}
+			if !slices.EqualFunc(a.Communities, tt.communities, slices.Equal) {
+				t.Errorf("Communities = %v, want %v", a.Communities, tt.communities)
+			}
+			if !slices.Equal(a.Bridges, tt.bridges) {
+				t.Errorf("Bridges = %v, want %v", a.Bridges, tt.bridges)
+			}
+
+			sum := 0.0
+			for _, id := range tt.ids {
+				sum += a.PageRank[id]
+			}
+			if math.Abs(sum-1) > 1e-6 {
+				t.Errorf("PageRank sums to %v, want 1", sum)
+			}
+		})
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-6 }
+
+	// Every note of a cycle ranks the same
+	a := Analyze([]string{"a", "b", "c"}, edges("a>b", "b>c", "c>a"))
+	for _, id := range a.IDs {
+		if !near(a.PageRank[id], 1.0/3) {
+			t.Errorf("cycle: PageRank[%s] = %v, want 1/3", id, a.PageRank[id])
+		}
+	}
+
+	// A note linked from both others outranks them; the dangling hub's rank
+	// is spread evenly, so the two leaves rank the same
+	a = Analyze([]string{"a", "b", "hub"}, []Edge{{"a", "hub"}, {"b", "hub"}})
+	if !near(a.PageRank["a"], a.PageRank["b"]) {
+		t.Errorf("leaves rank %v and %v, want the same", a.PageRank["a"], a.PageRank["b"])
+	}
+	if a.PageRank["hub"] <= a.PageRank["a"] {
+		t.Errorf("hub ranks %v, below a leaf's %v", a.PageRank["hub"], a.PageRank["a"])
+	}
+	if got := a.ByPageRank()[0]; got != "hub" {
+		t.Errorf("ByPageRank()[0] = %q, want hub", got)
+	}
+	if got := a.ByDegree()[0]; got != "hub" {
+		t.Errorf("ByDegree()[0] = %q, want hub", got)
+	}
+
+	// Without links every note ranks 1/n
+	a = Analyze([]string{"a", "b", "c", "d"}, nil)
+	for _, id := range a.IDs {
+		if !near(a.PageRank[id], 0.25) {
+			t.Errorf("no links: PageRank[%s] = %v, want 1/4", id, a.PageRank[id])
+		}
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	a := Analyze([]string{"a", "b", "c", "d", "e", "x"}, edges("a>b", "b>c", "c>d", "a>e", "e>d", "x>c"))
+
+	tests := []struct {
+		from, to string
+		path     []string
+		directed bool
+	}{
+		{"a", "d", []string{"a", "e", "d"}, true},
+		{"a", "c", []string{"a", "b", "c"}, true},
+		{"a", "a", []string{"a"}, true},
+		{"d", "a", []string{"d", "e", "a"}, false},
+		{"a", "x", []string{"a", "b", "c", "x"}, false},
+		{"b", "e", []string{"b", "a", "e"}, false},
+		{"a", "missing", nil, false},
+		{"", "a", nil, false},
+	}
+	for _, tt := range tests {
+		path, directed := a.ShortestPath(tt.from, tt.to)
+		if !slices.Equal(path, tt.path) || directed != tt.directed {
+			t.Errorf("ShortestPath(%q, %q) = %v, %v, want %v, %v", tt.from, tt.to, path, directed, tt.path, tt.directed)
+		}
+	}
+
+	// Notes in another part of the graph can't be reached either way
+	a = Analyze([]string{"a", "b", "c"}, edges("a>b"))
+	if path, directed := a.ShortestPath("a", "c"); path != nil || directed {
+		t.Errorf("ShortestPath to an unlinked note = %v, %v, want nil, false", path, directed)
+	}
+}
+
+func TestAnalyzeSubpathLinks(t *testing.T) {
+	// a, b and c link in a cycle only through headings and blocks
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "# Intro\n[[b#Intro]]\n",
+		"b.md": "# Intro\n[[c#^blk]]\n",
+		"c.md": "[[a#Intro|back]] ^blk\n",
+		"d.md": "[[a#Intro]]\n",
+	})
+	m := New(v)
+	m.BuildGraph()
+	a := m.analysis
+
+	want := [][]string{{"a.md", "b.md", "c.md"}}
+	if !slices.EqualFunc(a.Components, want, slices.Equal) {
+		t.Errorf("Components = %v, want %v", a.Components, want)
+	}
+	if want := []Edge{{"a.md", "d.md"}}; !slices.Equal(a.Bridges, want) {
+		t.Errorf("Bridges = %v, want %v", a.Bridges, want)
+	}
+	if got := a.ByPageRank()[0]; got != "a.md" {
+		t.Errorf("ByPageRank()[0] = %q, want a.md", got)
+	}
+	path, directed := a.ShortestPath("d.md", "c.md")
+	if want := []string{"d.md", "a.md", "b.md", "c.md"}; !slices.Equal(path, want) || !directed {
+		t.Errorf("ShortestPath(d, c) = %v, %v, want %v, true", path, directed, want)
+	}
+}
@@ -0,0 +1,220 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	sectionActiveStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("93")).Padding(0, 1)
+	sectionInactiveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Padding(0, 1)
+	groupHeaderStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+)
+
+// AnalyticsSection selects which metric the analytics view lists
+type AnalyticsSection int
+
+const (
+	SectionHubs AnalyticsSection = iota
+	SectionPageRank
+	SectionClusters
+	SectionComponents
+	SectionBridges
+	SectionPath
+	sectionCount
+)
+
+var sectionNames = []string{"Hubs", "PageRank", "Clusters", "Components", "Bridges", "Path"}
+
+// analyticsRow is one line of the analytics view; rows without an ID are headers
+type analyticsRow struct {
+	ID   string
+	Text string
+}
+
+// updateAnalytics handles keys specific to the analytics view
+func (m *Model) updateAnalytics(msg tea.KeyMsg) (bool, tea.Cmd) {
+	rows := m.analyticsRows()
+
+	switch msg.String() {
+	case "left", "h":
+		m.anSection = (m.anSection + sectionCount - 1) % sectionCount
+		m.anCursor = 0
+	case "right", "l":
+		m.anSection = (m.anSection + 1) % sectionCount
+		m.anCursor = 0
+	case "up", "k":
+		if m.anCursor > 0 {
+			m.anCursor--
+		}
+	case "down", "j":
+		if m.anCursor < len(rows)-1 {
+			m.anCursor++
+		}
+	case "g":
+		m.anCursor = 0
+	case "G":
+		m.anCursor = max(len(rows)-1, 0)
+	case "a":
+		if m.anCursor < len(rows) && rows[m.anCursor].ID != "" {
+			m.pathFrom = rows[m.anCursor].ID
+		}
+	case "b":
+		if m.anCursor < len(rows) && rows[m.anCursor].ID != "" {
+			m.pathTo = rows[m.anCursor].ID
+			m.anSection = SectionPath
+			m.anCursor = 0
+		}
+	case "enter":
+		if m.anCursor < len(rows) && rows[m.anCursor].ID != "" {
+			path := rows[m.anCursor].ID
+			m.Hide()
+			return true, func() tea.Msg { return FileSelectedMsg{Path: path} }
+		}
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (m Model) nodeName(id string) string {
+	if node := m.nodeMap[id]; node != nil {
+		return node.Name
+	}
+	return id
+}
+
+func (m Model) analyticsRows() []analyticsRow {
+	a := m.analysis
+	if a == nil {
+		return nil
+	}
+
+	var rows []analyticsRow
+	switch m.anSection {
+	case SectionHubs:
+		for _, id := range a.ByDegree() {
+			rows = append(rows, analyticsRow{ID: id, Text: fmt.Sprintf("%-4d %s  (in %d, out %d)",
+				a.InDegree[id]+a.OutDegree[id], m.nodeName(id), a.InDegree[id], a.OutDegree[id])})
+		}
+
+	case SectionPageRank:
+		for _, id := range a.ByPageRank() {
+			rows = append(rows, analyticsRow{ID: id, Text: fmt.Sprintf("%.4f %s", a.PageRank[id], m.nodeName(id))})
+		}
+
+	case SectionClusters:
+		for i, group := range a.Communities {
+			rows = append(rows, analyticsRow{Text: fmt.Sprintf("Cluster %d (%d notes)", i+1, len(group))})
+			for _, id := range group {
+				rows = append(rows, analyticsRow{ID: id, Text: "  " + m.nodeName(id)})
+			}
+		}
+
+	case SectionComponents:
+		for i, group := range a.Components {
+			rows = append(rows, analyticsRow{Text: fmt.Sprintf("Component %d (%d notes)", i+1, len(group))})
+			for _, id := range group {
+				rows = append(rows, analyticsRow{ID: id, Text: "  " + m.nodeName(id)})
+			}
+		}
+
+	case SectionBridges:
+		for _, e := range a.Bridges {
+			rows = append(rows, analyticsRow{ID: e.Source, Text: m.nodeName(e.Source) + " ⇄ " + m.nodeName(e.Target)})
+		}
+
+	case SectionPath:
+		from, to := "(press a)", "(press b)"
+		if m.pathFrom != "" {
+			from = m.nodeName(m.pathFrom)
+		}
+		if m.pathTo != "" {
+			to = m.nodeName(m.pathTo)
+		}
+		rows = append(rows, analyticsRow{Text: "From: " + from + "  To: " + to})
+
+		if m.pathFrom != "" && m.pathTo != "" {
+			path, directed := a.ShortestPath(m.pathFrom, m.pathTo)
+			switch {
+			case path == nil:
+				rows = append(rows, analyticsRow{Text: "No path between these notes"})
+			case !directed:
+				rows = append(rows, analyticsRow{Text: fmt.Sprintf("%d hops (ignoring link direction)", len(path)-1)})
+			default:
+				rows = append(rows, analyticsRow{Text: fmt.Sprintf("%d hops", len(path)-1)})
+			}
+			for i, id := range path {
+				prefix := "  → "
+				if i == 0 {
+					prefix = "  ◉ "
+				}
+				rows = append(rows, analyticsRow{ID: id, Text: prefix + m.nodeName(id)})
+			}
+		}
+	}
+
+	return rows
+}
+
+func (m Model) renderAnalyticsView() string {
+	var b strings.Builder
+
+	var tabs []string
+	for i, name := range sectionNames {
+		if AnalyticsSection(i) == m.anSection {
+			tabs = append(tabs, sectionActiveStyle.Render(name))
+		} else {
+			tabs = append(tabs, sectionInactiveStyle.Render(name))
+		}
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + "\n")
+	b.WriteString(statsStyle.Render("h/l: section | a/b: path from/to | Enter: open") + "\n\n")
+
+	rows := m.analyticsRows()
+	if len(rows) == 0 {
+		return b.String() + nodeOrphanStyle.Render("Nothing to show")
+	}
+
+	maxVisible := m.height - 12
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+
+	start := 0
+	if m.anCursor >= maxVisible {
+		start = m.anCursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(rows))
+
+	for i := start; i < end; i++ {
+		row := rows[i]
+
+		text := row.Text
+		maxLen := m.width - 10
+		if maxLen > 3 {
+			text = ansi.Truncate(text, maxLen, "...")
+		}
+
+		switch {
+		case i == m.anCursor:
+			b.WriteString(nodeFocusedStyle.Render("▶ " + text))
+		case row.ID == "":
+			b.WriteString(groupHeaderStyle.Render("  " + text))
+		default:
+			b.WriteString(nodeNormalStyle.Render("  " + text))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(rows) > maxVisible {
+		b.WriteString(statsStyle.Render(fmt.Sprintf("\n[%d/%d]", m.anCursor+1, len(rows))))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,342 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Format identifies a graph export format
+type Format string
+
+const (
+	FormatDOT     Format = "dot"
+	FormatGraphML Format = "graphml"
+	FormatJSON    Format = "json"
+)
+
+// Formats lists the supported export formats
+var Formats = []Format{FormatDOT, FormatGraphML, FormatJSON}
+
+// Extension returns the file extension used for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ExportOptions restricts which part of the vault graph is exported
+type ExportOptions struct {
+	Focus string // only export the neighbourhood of this note
+	Depth int    // number of link hops around Focus (defaults to 1)
+	Tag   string // only export notes carrying this tag or one of its subtags
+}
+
+// ExportNode is a note with the metadata written to export files
+type ExportNode struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Tags       []string          `json:"tags,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Links      []string          `json:"links"`
+	Backlinks  []string          `json:"backlinks"`
+}
+
+// ExportGraph is a snapshot of the vault's link graph ready to be written out
+type ExportGraph struct {
+	Nodes []*ExportNode
+	Edges []Edge
+}
+
+// Collect builds an export graph for the vault. The vault index must be built.
+func Collect(v *vault.Vault, opts ExportOptions) *ExportGraph {
+	notes := v.Notes()
+	nodes := make(map[string]*ExportNode)
+	for _, file := range notes {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".md") {
+			continue
+		}
+
+		relPath := file.RelativePath
+		node := &ExportNode{
+			ID:   relPath,
+			Name: strings.TrimSuffix(file.Name, ".md"),
+			Tags: file.Tags,
+		}
+		if content, err := v.ReadFile(relPath); err == nil {
+			if fm, _ := parser.ExtractFrontmatter(content); len(fm) > 0 {
+				node.Attributes = fm
+			}
+		}
+		nodes[relPath] = node
+	}
+
+	// Deduplicate edges; a note may link to the same target several times
+	seen := make(map[Edge]bool)
+	var edges []Edge
+	for _, e := range buildEdges(v, notes) {
+		if seen[e] || nodes[e.Source] == nil || nodes[e.Target] == nil {
+			continue
+		}
+		seen[e] = true
+		edges = append(edges, e)
+	}
+
+	keep := make(map[string]bool)
+	for id, node := range nodes {
+		if opts.Tag == "" || hasTag(node.Tags, opts.Tag) {
+			keep[id] = true
+		}
+	}
+
+	if opts.Focus != "" {
+		keep = neighbourhood(opts.Focus, opts.Depth, edges, keep)
+	}
+
+	g := &ExportGraph{}
+	for _, e := range edges {
+		if keep[e.Source] && keep[e.Target] {
+			g.Edges = append(g.Edges, e)
+			nodes[e.Source].Links = append(nodes[e.Source].Links, e.Target)
+			nodes[e.Target].Backlinks = append(nodes[e.Target].Backlinks, e.Source)
+		}
+	}
+	for id := range keep {
+		node := nodes[id]
+		if node.Links == nil {
+			node.Links = []string{}
+		}
+		if node.Backlinks == nil {
+			node.Backlinks = []string{}
+		}
+		sort.Strings(node.Links)
+		sort.Strings(node.Backlinks)
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+
+	return g
+}
+
+func hasTag(tags []string, tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	for _, t := range tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// neighbourhood returns the allowed notes within depth hops of focus,
+// following links in both directions
+func neighbourhood(focus string, depth int, edges []Edge, allowed map[string]bool) map[string]bool {
+	if depth <= 0 {
+		depth = 1
+	}
+
+	adjacent := make(map[string][]string)
+	for _, e := range edges {
+		adjacent[e.Source] = append(adjacent[e.Source], e.Target)
+		adjacent[e.Target] = append(adjacent[e.Target], e.Source)
+	}
+
+	result := make(map[string]bool)
+	if !allowed[focus] {
+		return result
+	}
+	result[focus] = true
+
+	frontier := []string{focus}
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, n := range adjacent[id] {
+				if allowed[n] && !result[n] {
+					result[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return result
+}
+
+// Write encodes the graph in the given format
+func (g *ExportGraph) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatGraphML:
+		return g.WriteGraphML(w)
+	case FormatJSON:
+		return g.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown graph format: %s", format)
+}
+
+// WriteDOT writes the graph as a Graphviz digraph
+func (g *ExportGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph vault {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, n := range g.Nodes {
+		b.WriteString("  " + dotQuote(n.ID) + " [label=" + dotQuote(n.Name))
+		if len(n.Tags) > 0 {
+			b.WriteString(", tags=" + dotQuote(strings.Join(n.Tags, ",")))
+		}
+		for _, k := range sortedKeys(n.Attributes) {
+			b.WriteString(", " + dotQuote(k) + "=" + dotQuote(n.Attributes[k]))
+		}
+		b.WriteString("];\n")
+	}
+
+	for _, e := range g.Edges {
+		b.WriteString("  " + dotQuote(e.Source) + " -> " + dotQuote(e.Target) + ";\n")
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML; frontmatter keys become node attributes
+func (g *ExportGraph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "tags", For: "node", AttrName: "tags", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "vault", EdgeDefault: "directed"},
+	}
+
+	attrKeys := make(map[string]string)
+	for _, n := range g.Nodes {
+		for k := range n.Attributes {
+			attrKeys[k] = ""
+		}
+	}
+	for i, k := range sortedKeys(attrKeys) {
+		attrKeys[k] = fmt.Sprintf("fm%d", i)
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attrKeys[k], For: "node", AttrName: k, AttrType: "string"})
+	}
+
+	for _, n := range g.Nodes {
+		node := graphMLNode{ID: n.ID}
+		node.Data = append(node.Data, graphMLData{Key: "name", Value: n.Name})
+		if len(n.Tags) > 0 {
+			node.Data = append(node.Data, graphMLData{Key: "tags", Value: strings.Join(n.Tags, ",")})
+		}
+		for _, k := range sortedKeys(n.Attributes) {
+			node.Data = append(node.Data, graphMLData{Key: attrKeys[k], Value: n.Attributes[k]})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.Source, Target: e.Target})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJSON writes the graph as JSON; every node carries its adjacency
+// (outgoing links and backlinks) alongside the flat edge list
+func (g *ExportGraph) WriteJSON(w io.Writer) error {
+	doc := struct {
+		Nodes []*ExportNode `json:"nodes"`
+		Edges []Edge        `json:"edges"`
+	}{Nodes: g.Nodes, Edges: g.Edges}
+	if doc.Nodes == nil {
+		doc.Nodes = []*ExportNode{}
+	}
+	if doc.Edges == nil {
+		doc.Edges = []Edge{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ExportFileName returns the default file name for an export of the vault graph
+func ExportFileName(v *vault.Vault, format Format) string {
+	return filepath.Base(v.Path) + "-graph" + format.Extension()
+}
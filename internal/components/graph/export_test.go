@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// exportVault links a -> b, c; b -> c; d -> a and leaves e unlinked
+func exportVault(t *testing.T) *vault.Vault {
+	return vaulttest.Indexed(t, map[string]string{
+		"a.md":     "---\ntype: hub\n---\n#project [[b]], [[b]] again and [[sub/c|C]]\n",
+		"b.md":     "#project/sub [[c]] and [[missing]]\n",
+		"sub/c.md": "no links\n",
+		"d.md":     "#other [[a]]\n",
+		"e.md":     "---\nnote: a \"b\" & <c>\n---\nisolated\n",
+	})
+}
+
+func ids(g *ExportGraph) []string {
+	var got []string
+	for _, n := range g.Nodes {
+		got = append(got, n.ID)
+	}
+	return got
+}
+
+func TestCollect(t *testing.T) {
+	v := exportVault(t)
+
+	tests := []struct {
+		name  string
+		opts  ExportOptions
+		nodes []string
+		edges []Edge
+	}{
+		{
+			name:  "whole vault",
+			nodes: []string{"a.md", "b.md", "d.md", "e.md", "sub/c.md"},
+			edges: []Edge{{"a.md", "b.md"}, {"a.md", "sub/c.md"}, {"b.md", "sub/c.md"}, {"d.md", "a.md"}},
+		},
+		{
+			name:  "tag and subtags",
+			opts:  ExportOptions{Tag: "#project"},
+			nodes: []string{"a.md", "b.md"},
+			edges: []Edge{{"a.md", "b.md"}},
+		},
+		{
+			name:  "neighbourhood",
+			opts:  ExportOptions{Focus: "sub/c.md"},
+			nodes: []string{"a.md", "b.md", "sub/c.md"},
+			edges: []Edge{{"a.md", "b.md"}, {"a.md", "sub/c.md"}, {"b.md", "sub/c.md"}},
+		},
+		{
+			name:  "neighbourhood within a tag",
+			opts:  ExportOptions{Focus: "d.md", Depth: 2, Tag: "other"},
+			nodes: []string{"d.md"},
+		},
+		{
+			name:  "unlinked focus",
+			opts:  ExportOptions{Focus: "e.md", Depth: 3},
+			nodes: []string{"e.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Collect(v, tt.opts)
+			if got := ids(g); !slices.Equal(got, tt.nodes) {
+				t.Errorf("nodes = %v, want %v", got, tt.nodes)
+			}
+			if !slices.Equal(g.Edges, tt.edges) {
+				t.Errorf("edges = %v, want %v", g.Edges, tt.edges)
+			}
+		})
+	}
+}
+
+func TestCollectSubpathLinks(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "[[b#Intro]], [[c#^blk]], [[d]] and [[#Own heading]]\n\n# Own heading\n",
+		"b.md": "# Intro\n",
+		"c.md": "text ^blk\n",
+		"d.md": "",
+	})
+
+	g := Collect(v, ExportOptions{})
+	want := []Edge{{"a.md", "b.md"}, {"a.md", "c.md"}, {"a.md", "d.md"}}
+	if !slices.Equal(g.Edges, want) {
+		t.Errorf("edges = %v, want %v", g.Edges, want)
+	}
+	// The links agree with the vault's backlinks
+	for _, n := range g.Nodes {
+		if got := v.GetBacklinks(n.ID); !slices.Equal(n.Backlinks, got) {
+			t.Errorf("%s backlinks = %v, vault has %v", n.ID, n.Backlinks, got)
+		}
+	}
+}
+
+func TestNeighbourhood(t *testing.T) {
+	edges := []Edge{{"a", "b"}, {"b", "c"}, {"c", "d"}, {"x", "y"}}
+	all := map[string]bool{"a": true, "b": true, "c": true, "d": true, "x": true, "y": true}
+
+	tests := []struct {
+		name    string
+		focus   string
+		depth   int
+		allowed map[string]bool
+		want    []string
+	}{
+		{"default depth", "b", 0, all, []string{"a", "b", "c"}},
+		{"follows backlinks", "d", 2, all, []string{"b", "c", "d"}},
+		{"stays in its component", "x", 5, all, []string{"x", "y"}},
+		{"does not cross filtered notes", "a", 3, map[string]bool{"a": true, "c": true, "d": true}, []string{"a"}},
+		{"filtered focus", "a", 1, map[string]bool{"b": true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for id := range neighbourhood(tt.focus, tt.depth, edges, tt.allowed) {
+				got = append(got, id)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("neighbourhood(%q, %d) = %v, want %v", tt.focus, tt.depth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	g := Collect(exportVault(t), ExportOptions{})
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := g.Write(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "vault"+format.Extension())
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("%s export differs from %s:\n%s", format, golden, got)
+			}
+		})
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := (&ExportGraph{}).Write(&bytes.Buffer{}, Format("svg")); err == nil {
+		t.Error("Write accepted an unknown format")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
 	"github.com/takahashinaoki/obsidiantui/internal/vault"
 )
 
@@ -37,8 +38,8 @@ type Node struct {
 
 // Edge represents a link between two files
 type Edge struct {
-	Source string
-	Target string
+	Source string `json:"source"`
+	Target string `json:"target"`
 }
 
 // Model is the graph view model
@@ -55,6 +56,12 @@ type Model struct {
 	viewMode    ViewMode
 	offsetX     int
 	offsetY     int
+
+	analysis  *Analysis
+	anSection AnalyticsSection
+	anCursor  int
+	pathFrom  string
+	pathTo    string
 }
 
 type ViewMode int
@@ -63,6 +70,7 @@ const (
 	ViewList ViewMode = iota
 	ViewLocal
 	ViewCanvas
+	ViewAnalytics
 )
 
 type FileSelectedMsg struct {
@@ -84,14 +92,15 @@ func (m *Model) BuildGraph() {
 	m.nodeMap = make(map[string]*Node)
 	m.edges = nil
 
-	files := m.vault.Files
+	notes := m.vault.Notes()
 
 	// Create nodes for all markdown files
-	for relPath, file := range files {
-		if file.IsDir || !strings.HasSuffix(strings.ToLower(file.Name), ".md") {
+	for _, file := range notes {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".md") {
 			continue
 		}
 
+		relPath := file.RelativePath
 		node := &Node{
 			ID:        relPath,
 			Name:      strings.TrimSuffix(file.Name, ".md"),
@@ -115,25 +124,40 @@ func (m *Model) BuildGraph() {
 	})
 
 	// Build edges
-	for relPath, file := range files {
-		if file.IsDir {
-			continue
-		}
+	m.edges = buildEdges(m.vault, notes)
+
+	// Initialize positions for canvas view
+	m.initializePositions()
+
+	ids := make([]string, 0, len(m.nodes))
+	for _, node := range m.nodes {
+		ids = append(ids, node.ID)
+	}
+	m.analysis = Analyze(ids, m.edges)
+	m.anCursor = 0
+}
+
+// buildEdges resolves the wiki links of the notes, a snapshot from
+// v.Notes, into graph edges
+func buildEdges(v *vault.Vault, notes []vault.File) []Edge {
+	var edges []Edge
+	for _, file := range notes {
+		relPath := file.RelativePath
 		for _, link := range file.Links {
-			if link.IsWikiLink {
-				targetPath := m.vault.FindFile(link.Target + ".md")
+			// [[note#Heading]] and [[note#^id]] link to the note
+			note, _ := parser.SplitSubpath(link.Target)
+			if link.IsWikiLink && note != "" {
+				targetPath := v.FindFile(note + ".md")
 				if targetPath == "" {
-					targetPath = m.vault.FindFile(link.Target)
+					targetPath = v.FindFile(note)
 				}
 				if targetPath != "" && targetPath != relPath {
-					m.edges = append(m.edges, Edge{Source: relPath, Target: targetPath})
+					edges = append(edges, Edge{Source: relPath, Target: targetPath})
 				}
 			}
 		}
 	}
-
-	// Initialize positions for canvas view
-	m.initializePositions()
+	return edges
 }
 
 func (m *Model) initializePositions() {
@@ -172,6 +196,15 @@ func (m *Model) Show(focusedFile string) {
 	}
 }
 
+// ShowAnalytics opens the graph directly in the analytics view
+func (m *Model) ShowAnalytics(focusedFile string) {
+	m.Show(focusedFile)
+	m.viewMode = ViewAnalytics
+	if focusedFile != "" {
+		m.pathFrom = focusedFile
+	}
+}
+
 func (m *Model) Hide() {
 	m.active = false
 	for _, node := range m.nodes {
@@ -190,6 +223,12 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.viewMode == ViewAnalytics {
+			if handled, cmd := m.updateAnalytics(msg); handled {
+				return m, cmd
+			}
+		}
+
 		switch msg.String() {
 		case "esc", "q", "ctrl+g":
 			m.Hide()
@@ -214,7 +253,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		case "tab":
 			// Cycle view mode
-			m.viewMode = (m.viewMode + 1) % 3
+			m.viewMode = (m.viewMode + 1) % 4
 
 		case "l":
 			// Switch to local graph of current selection
@@ -223,6 +262,23 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.viewMode = ViewLocal
 			}
 
+		case "a":
+			// Mark start of a shortest path query
+			if m.cursor < len(m.nodes) {
+				m.pathFrom = m.nodes[m.cursor].ID
+			}
+
+		case "b":
+			// Mark end of a shortest path query and show the result
+			if m.cursor < len(m.nodes) {
+				m.pathTo = m.nodes[m.cursor].ID
+				if m.pathFrom != "" {
+					m.viewMode = ViewAnalytics
+					m.anSection = SectionPath
+					m.anCursor = 0
+				}
+			}
+
 		case "g":
 			// Go to top
 			m.cursor = 0
@@ -280,6 +336,8 @@ func (m Model) View() string {
 		modeStr = "Local"
 	case ViewCanvas:
 		modeStr = "Canvas"
+	case ViewAnalytics:
+		modeStr = "Analytics"
 	}
 	title := titleStyle.Render(fmt.Sprintf("Graph View [%s]", modeStr))
 	b.WriteString(title + "\n")
@@ -303,6 +361,8 @@ func (m Model) View() string {
 		b.WriteString(m.renderLocalView())
 	case ViewCanvas:
 		b.WriteString(m.renderCanvasView())
+	case ViewAnalytics:
+		b.WriteString(m.renderAnalyticsView())
 	}
 
 	return containerStyle.Width(m.width).Render(b.String())
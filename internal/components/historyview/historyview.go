@@ -0,0 +1,345 @@
+package historyview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/components/diffview"
+	"github.com/takahashinaoki/obsidiantui/internal/diff"
+	"github.com/takahashinaoki/obsidiantui/internal/history"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("130")).Padding(0, 1)
+	subtitleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Italic(true)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	dimSelected    = lipgloss.NewStyle().Background(lipgloss.Color("240")).Foreground(lipgloss.Color("255"))
+	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	statAddStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	statDelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hunkMarkStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("130")).Padding(1)
+)
+
+const (
+	contextLines    = 3
+	maxVisibleItems = 8
+)
+
+// RestoreMsg asks for the note's buffer to be replaced with restored content
+type RestoreMsg struct {
+	Path    string
+	Content string
+	Version time.Time
+	Partial bool // only some hunks were restored
+}
+
+// HistoryClosedMsg is sent when the history overlay is closed
+type HistoryClosedMsg struct{}
+
+type focusArea int
+
+const (
+	focusVersions focusArea = iota
+	focusHunks
+)
+
+// Model lists a note's stored versions and diffs them against the buffer
+type Model struct {
+	store    *history.Store
+	path     string
+	current  string
+	versions []history.Version
+	cursor   int
+
+	hunks      []diff.Hunk
+	hunkCursor int
+	selected   map[int]bool
+	focus      focusArea
+	err        string
+
+	width  int
+	height int
+	active bool
+}
+
+func New() Model {
+	return Model{}
+}
+
+// Show opens the overlay for path, comparing versions with current
+func (m *Model) Show(store *history.Store, path, current string) {
+	m.active = true
+	m.store = store
+	m.path = path
+	m.current = current
+	m.cursor = 0
+	m.focus = focusVersions
+	m.err = ""
+
+	versions, err := store.Versions(path)
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.versions = versions
+	m.loadDiff()
+}
+
+func (m *Model) Hide() {
+	m.active = false
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func (m *Model) loadDiff() {
+	m.hunks = nil
+	m.hunkCursor = 0
+	m.selected = make(map[int]bool)
+
+	if m.cursor >= len(m.versions) {
+		return
+	}
+	content, err := m.store.Load(m.versions[m.cursor])
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.hunks = diff.Compute(m.current, content, contextLines)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		m.Hide()
+		return m, func() tea.Msg { return HistoryClosedMsg{} }
+	case "tab":
+		if m.focus == focusVersions && len(m.hunks) > 0 {
+			m.focus = focusHunks
+		} else {
+			m.focus = focusVersions
+		}
+		return m, nil
+	case "r":
+		return m.restore(false)
+	}
+
+	if m.focus == focusHunks {
+		switch keyMsg.String() {
+		case "up", "k":
+			if m.hunkCursor > 0 {
+				m.hunkCursor--
+			}
+		case "down", "j":
+			if m.hunkCursor < len(m.hunks)-1 {
+				m.hunkCursor++
+			}
+		case " ", "x":
+			m.selected[m.hunkCursor] = !m.selected[m.hunkCursor]
+		case "left", "h":
+			m.focus = focusVersions
+		case "enter", "p":
+			return m.restore(true)
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+			m.loadDiff()
+		}
+	case "down", "j":
+		if m.cursor < len(m.versions)-1 {
+			m.cursor++
+			m.loadDiff()
+		}
+	case "right", "l":
+		if len(m.hunks) > 0 {
+			m.focus = focusHunks
+		}
+	case "enter":
+		return m.restore(false)
+	}
+
+	return m, nil
+}
+
+// restore emits the selected version, or only its selected hunks when
+// partial is set (the hunk under the cursor if none are marked)
+func (m Model) restore(partial bool) (Model, tea.Cmd) {
+	if m.cursor >= len(m.versions) || len(m.hunks) == 0 {
+		return m, nil
+	}
+
+	version := m.versions[m.cursor]
+	msg := RestoreMsg{Path: m.path, Version: version.Time, Partial: partial}
+
+	if partial {
+		selected := m.selected
+		if !anySelected(selected) {
+			selected = map[int]bool{m.hunkCursor: true}
+		}
+		msg.Content = diff.Apply(m.current, m.hunks, func(i int) bool { return selected[i] })
+	} else {
+		content, err := m.store.Load(version)
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		msg.Content = content
+	}
+
+	m.Hide()
+	return m, func() tea.Msg { return msg }
+}
+
+func anySelected(selected map[int]bool) bool {
+	for _, ok := range selected {
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func countChanges(hunks []diff.Hunk) (added, removed int) {
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case diff.Insert:
+				added++
+			case diff.Delete:
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("File History") + "\n")
+	b.WriteString(subtitleStyle.Render(m.path) + "\n\n")
+
+	if m.err != "" {
+		b.WriteString(statDelStyle.Render("  "+m.err) + "\n")
+	}
+
+	if len(m.versions) == 0 {
+		b.WriteString(hintStyle.Render("  No saved versions yet"))
+		return containerStyle.Width(m.width).Render(b.String())
+	}
+
+	// Versions list
+	start := 0
+	if m.cursor >= maxVisibleItems {
+		start = m.cursor - maxVisibleItems + 1
+	}
+	end := min(start+maxVisibleItems, len(m.versions))
+	for i := start; i < end; i++ {
+		line := "  " + m.versions[i].Time.Local().Format("2006-01-02 15:04:05")
+		if i == 0 {
+			line += "  (latest)"
+		}
+		switch {
+		case i == m.cursor && m.focus == focusVersions:
+			b.WriteString(selectedStyle.Width(m.width-4).Render(line) + "\n")
+		case i == m.cursor:
+			b.WriteString(dimSelected.Width(m.width-4).Render(line) + "\n")
+		default:
+			b.WriteString(normalStyle.Render(line) + "\n")
+		}
+	}
+	if len(m.versions) > maxVisibleItems {
+		b.WriteString(hintStyle.Render(fmt.Sprintf("  [%d/%d]", m.cursor+1, len(m.versions))) + "\n")
+	}
+	b.WriteString("\n")
+
+	// Diff of the selected version against the buffer
+	if len(m.hunks) == 0 {
+		b.WriteString(hintStyle.Render("  Identical to the current buffer") + "\n")
+	} else {
+		added, removed := countChanges(m.hunks)
+		b.WriteString(hintStyle.Render("Restoring this version: ") +
+			statAddStyle.Render(fmt.Sprintf("+%d", added)) + " " +
+			statDelStyle.Render(fmt.Sprintf("-%d", removed)) + "\n")
+		b.WriteString(m.renderHunks(m.height - end + start - 14))
+	}
+
+	b.WriteString("\n" + hintStyle.Render("j/k: move | Tab: versions/hunks | Space: mark hunk | p: restore hunks | r: restore version | Esc: close"))
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m Model) renderHunks(maxLines int) string {
+	if maxLines < 5 {
+		maxLines = 5
+	}
+
+	var lines []string
+	focusLine := 0
+	for i, h := range m.hunks {
+		pointer, check := " ", " "
+		if i == m.hunkCursor && m.focus == focusHunks {
+			focusLine = len(lines)
+			pointer = hunkMarkStyle.Render("▶")
+		}
+		if m.selected[i] {
+			check = hunkMarkStyle.Render("✓")
+		}
+		mark := pointer + check
+		for j, text := range strings.Split(strings.TrimRight(h.String(), "\n"), "\n") {
+			prefix := "  "
+			if j == 0 {
+				prefix = mark
+			}
+			lines = append(lines, prefix+m.truncate(text))
+		}
+	}
+
+	start := min(focusLine, max(len(lines)-maxLines, 0))
+	end := min(start+maxLines, len(lines))
+
+	var b strings.Builder
+	for _, line := range lines[start:end] {
+		b.WriteString(line + "\n")
+	}
+	if end < len(lines) {
+		b.WriteString(hintStyle.Render("  ...") + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) truncate(line string) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	maxLen := m.width - 10
+	if maxLen > 3 && len([]rune(line)) > maxLen {
+		line = string([]rune(line)[:maxLen-3]) + "..."
+	}
+	return diffview.StyleLine(line)
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
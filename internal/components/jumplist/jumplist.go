@@ -0,0 +1,185 @@
+package jumplist
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Padding(0, 1)
+	inputStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	detailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	currentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	emptyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("57")).Padding(1)
+)
+
+// Item is one location in the list
+type Item struct {
+	Label   string
+	Detail  string
+	Current bool // marked as where the user is now
+}
+
+// ItemSelectedMsg is sent when an item is picked. Index is into the items
+// passed to Show; Context is echoed back so callers can tell lists apart.
+type ItemSelectedMsg struct {
+	Index   int
+	Context string
+}
+
+// JumpListClosedMsg is sent when the list is closed without a choice
+type JumpListClosedMsg struct{}
+
+// Model is a filterable list of locations to jump to
+type Model struct {
+	textinput textinput.Model
+	title     string
+	context   string
+	items     []Item
+	filtered  []int // indices into items
+	cursor    int
+	width     int
+	height    int
+	active    bool
+}
+
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "Type to filter..."
+	ti.CharLimit = 256
+	ti.Width = 40
+
+	return Model{textinput: ti}
+}
+
+// Show opens the list with the cursor on item cursor
+func (m *Model) Show(title, context string, items []Item, cursor int) tea.Cmd {
+	m.active = true
+	m.title = title
+	m.context = context
+	m.items = items
+	m.textinput.SetValue("")
+	m.filter()
+	m.cursor = max(0, min(cursor, len(m.filtered)-1))
+	return m.textinput.Focus()
+}
+
+func (m *Model) Hide() {
+	m.active = false
+	m.textinput.Blur()
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func (m *Model) filter() {
+	query := strings.ToLower(m.textinput.Value())
+	m.filtered = nil
+	for i, item := range m.items {
+		if query == "" || strings.Contains(strings.ToLower(item.Label), query) {
+			m.filtered = append(m.filtered, i)
+		}
+	}
+	m.cursor = max(0, min(m.cursor, len(m.filtered)-1))
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.Hide()
+			return m, func() tea.Msg { return JumpListClosedMsg{} }
+
+		case "up", "ctrl+p":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+
+		case "down", "ctrl+n":
+			if m.cursor < len(m.filtered)-1 {
+				m.cursor++
+			}
+			return m, nil
+
+		case "enter":
+			if m.cursor < len(m.filtered) {
+				selected := ItemSelectedMsg{Index: m.filtered[m.cursor], Context: m.context}
+				m.Hide()
+				return m, func() tea.Msg { return selected }
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	prev := m.textinput.Value()
+	m.textinput, cmd = m.textinput.Update(msg)
+	if m.textinput.Value() != prev {
+		m.cursor = 0
+		m.filter()
+	}
+
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(m.title) + "\n")
+	b.WriteString(inputStyle.Render(m.textinput.View()) + "\n\n")
+
+	maxVisible := max(5, m.height-8)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.filtered))
+
+	for i := start; i < end; i++ {
+		item := m.items[m.filtered[i]]
+
+		label := "  " + item.Label
+		style := normalStyle
+		if i == m.cursor {
+			label = "▶ " + item.Label
+			style = selectedStyle
+		}
+		line := style.Render(label)
+		if item.Detail != "" {
+			line += " " + detailStyle.Render(item.Detail)
+		}
+		if item.Current {
+			line += currentStyle.Render(" ●")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(m.filtered) == 0 {
+		b.WriteString(emptyStyle.Render("  Nothing here"))
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.textinput.Width = max(10, width-10)
+}
@@ -0,0 +1,39 @@
+package liveeditor
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// BlockLinkMsg is sent to copy a link to the block at the cursor, after it
+// got its ^id. ID is empty when the cursor isn't on a block.
+type BlockLinkMsg struct {
+	Path string
+	ID   string
+}
+
+// AddBlockID gives the paragraph, list item or other block at the cursor a
+// ^id marker unless it has one, and returns the id
+func (m *Model) AddBlockID() (string, bool) {
+	a, ok := parser.FindBlockAnchor(m.lines, m.cursorRow)
+	if !ok {
+		return "", false
+	}
+	if a.ID != "" {
+		return a.ID, true
+	}
+
+	id := parser.NewBlockID(m.lines)
+	lines, inserted := parser.AddBlockID(m.lines, a, id)
+	m.lines = lines
+	m.linesInserted(a.Line+1, inserted)
+	m.modified = true
+	m.invalidateAllCache()
+	return id, true
+}
+
+func (m *Model) blockLink() tea.Cmd {
+	id, _ := m.AddBlockID()
+	msg := BlockLinkMsg{Path: m.filePath, ID: id}
+	return func() tea.Msg { return msg }
+}
@@ -0,0 +1,212 @@
+package liveeditor
+
+import (
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// FoldsChangedMsg is sent when folds are opened or closed. Folds are the
+// first lines of the folds now closed.
+type FoldsChangedMsg struct {
+	Path  string
+	Folds []int
+}
+
+// revs numbers the texts of every editor, so copies of a model edited apart
+// never share a revision
+var revs atomic.Int64
+
+func nextRev() int64 {
+	return revs.Add(1)
+}
+
+// foldCache holds the folds of one revision of the text. Copies of a model
+// share it, so View can fill it in.
+type foldCache struct {
+	rev   int64
+	folds []parser.Fold
+}
+
+// findFolds returns the text's folds, finding them again only after an edit
+func (m Model) findFolds() []parser.Fold {
+	if m.foldCache == nil {
+		return parser.FindFolds(m.lines)
+	}
+	if m.foldCache.rev != m.rev || m.rev == 0 {
+		*m.foldCache = foldCache{rev: m.rev, folds: parser.FindFolds(m.lines)}
+	}
+	return m.foldCache.folds
+}
+
+// Folds returns the first lines of the closed folds, in order
+func (m Model) Folds() []int {
+	var starts []int
+	for _, f := range m.findFolds() {
+		if m.folded[f.Start] && (len(starts) == 0 || starts[len(starts)-1] != f.Start) {
+			starts = append(starts, f.Start)
+		}
+	}
+	return starts
+}
+
+// SetFolds closes the folds starting at the given lines, and opens the rest
+func (m *Model) SetFolds(starts []int) {
+	m.folded = make(map[int]bool, len(starts))
+	for _, s := range starts {
+		m.folded[s] = true
+	}
+	m.cursorToFold()
+	m.ensureCursorVisible()
+}
+
+// cursorToFold moves a cursor hidden by a closed fold to the fold's line
+func (m *Model) cursorToFold() {
+	if start, ok := m.hidingFold(m.cursorRow, m.closedEnds()); ok {
+		m.cursorRow, m.cursorCol = start, 0
+	}
+}
+
+func (m Model) foldsChanged() tea.Cmd {
+	msg := FoldsChangedMsg{Path: m.filePath, Folds: m.Folds()}
+	return func() tea.Msg { return msg }
+}
+
+// closedEnds maps the first line of each closed fold to its last line
+func (m Model) closedEnds() map[int]int {
+	if len(m.folded) == 0 {
+		return nil
+	}
+	ends := make(map[int]int)
+	for _, f := range m.findFolds() {
+		if _, seen := ends[f.Start]; !seen && m.folded[f.Start] {
+			ends[f.Start] = f.End
+		}
+	}
+	return ends
+}
+
+// nextLine returns the line shown after row, skipping a closed fold
+func (m Model) nextLine(row int, ends map[int]int) int {
+	if end, ok := ends[row]; ok {
+		return end + 1
+	}
+	return row + 1
+}
+
+// hidingFold returns the first line of the outermost closed fold hiding row
+func (m Model) hidingFold(row int, ends map[int]int) (int, bool) {
+	start, found := row, false
+	for s, e := range ends {
+		if s < row && row <= e && s < start {
+			start, found = s, true
+		}
+	}
+	return start, found
+}
+
+// prevLine returns the line shown before row
+func (m Model) prevLine(row int, ends map[int]int) int {
+	if start, ok := m.hidingFold(row-1, ends); ok {
+		return start
+	}
+	return row - 1
+}
+
+// revealCursor opens the folds hiding the cursor, reporting whether any were
+func (m *Model) revealCursor() bool {
+	return m.revealLine(m.cursorRow)
+}
+
+func (m *Model) revealLine(row int) bool {
+	opened := false
+	for s, e := range m.closedEnds() {
+		if s < row && row <= e {
+			delete(m.folded, s)
+			opened = true
+		}
+	}
+	return opened
+}
+
+// fold runs a fold command: toggle, close, open, close_all or open_all
+func (m *Model) fold(action string) tea.Cmd {
+	if m.folded == nil {
+		m.folded = make(map[int]bool)
+	}
+	switch action {
+	case "toggle":
+		if m.folded[m.cursorRow] {
+			delete(m.folded, m.cursorRow)
+			break
+		}
+		m.closeFold()
+	case "close":
+		m.closeFold()
+	case "open":
+		delete(m.folded, m.cursorRow)
+	case "close_all":
+		for _, f := range m.findFolds() {
+			m.folded[f.Start] = true
+		}
+		m.cursorToFold()
+	case "open_all":
+		m.folded = make(map[int]bool)
+	}
+	m.ensureCursorVisible()
+	return m.foldsChanged()
+}
+
+// closeFold closes the smallest open fold containing the cursor
+func (m *Model) closeFold() {
+	var found *parser.Fold
+	for _, f := range m.findFolds() {
+		if f.Start <= m.cursorRow && m.cursorRow <= f.End && !m.folded[f.Start] {
+			f := f
+			found = &f
+		}
+	}
+	if found == nil {
+		return
+	}
+	m.folded[found.Start] = true
+	m.cursorRow, m.cursorCol = found.Start, 0
+}
+
+// linesInserted moves folds below an insertion of n lines at row at
+func (m *Model) linesInserted(at, n int) {
+	m.shiftFolds(func(s int) (int, bool) {
+		if s >= at {
+			return s + n, true
+		}
+		return s, true
+	})
+}
+
+// linesRemoved moves folds up after n lines at row at were removed, dropping
+// the ones that started there
+func (m *Model) linesRemoved(at, n int) {
+	m.shiftFolds(func(s int) (int, bool) {
+		switch {
+		case s >= at+n:
+			return s - n, true
+		case s >= at:
+			return 0, false
+		}
+		return s, true
+	})
+}
+
+func (m *Model) shiftFolds(move func(int) (int, bool)) {
+	if len(m.folded) == 0 {
+		return
+	}
+	folded := make(map[int]bool, len(m.folded))
+	for s := range m.folded {
+		if s, ok := move(s); ok {
+			folded[s] = true
+		}
+	}
+	m.folded = folded
+}
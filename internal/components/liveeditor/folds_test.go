@@ -0,0 +1,35 @@
+package liveeditor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFoldsFollowEdits(t *testing.T) {
+	m := New()
+	m.SetSize(80, 20)
+	m.SetFocused(true)
+	m.SetContent("# A\ntext\nmore\n# B\ntext", "note.md")
+
+	m.fold("close_all")
+	if got := m.Folds(); !slices.Equal(got, []int{0, 3}) {
+		t.Fatalf("Folds() = %v, want [0 3]", got)
+	}
+
+	// Typing a subheading makes a new fold
+	m.fold("open_all")
+	m.cursorRow, m.cursorCol = 1, 0
+	for _, r := range "## " {
+		m.insertRunes([]rune{r})
+	}
+	m.fold("close_all")
+	if got := m.Folds(); !slices.Equal(got, []int{0, 1, 3}) {
+		t.Errorf("Folds() after typing a heading = %v, want [0 1 3]", got)
+	}
+
+	m.SetContent("no headings", "note.md")
+	m.fold("close_all")
+	if got := m.Folds(); len(got) != 0 {
+		t.Errorf("Folds() of new content = %v, want none", got)
+	}
+}
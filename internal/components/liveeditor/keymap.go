@@ -0,0 +1,72 @@
+package liveeditor
+
+import "github.com/takahashinaoki/obsidiantui/internal/keymap"
+
+// keys are the normal-mode bindings shared by every editor
+var keys = DefaultKeymap()
+
+// DefaultKeymap returns the normal-mode bindings before any configuration
+func DefaultKeymap() *keymap.Map {
+	km := keymap.NewMap("editor")
+	bind := func(action, help string, seqs ...string) {
+		parsed := make([]keymap.Sequence, len(seqs))
+		for i, s := range seqs {
+			parsed[i] = keymap.MustParse(s)
+		}
+		km.Bind(action, help, parsed...)
+	}
+
+	bind("insert", "insert", "i")
+	bind("append", "append", "a")
+	bind("insert_line_start", "insert at line start", "I")
+	bind("append_line_end", "append at line end", "A")
+	bind("open_below", "open line below", "o")
+	bind("open_above", "open line above", "O")
+	bind("up", "up", "up", "k")
+	bind("down", "down", "down", "j")
+	bind("left", "left", "left", "h")
+	bind("right", "right", "right", "l")
+	bind("line_start", "line start", "home", "0")
+	bind("line_end", "line end", "end", "$")
+	bind("page_up", "page up", "ctrl+u", "pgup")
+	bind("page_down", "page down", "ctrl+d", "pgdown")
+	bind("top", "top", "g g")
+	bind("bottom", "bottom", "G")
+	bind("word_forward", "next word", "w")
+	bind("word_backward", "prev word", "b")
+	bind("delete_char", "delete char", "x")
+	bind("follow_link", "go to link", "g d", "enter", "ctrl+]")
+	bind("block_link", "copy block link", "g b")
+	bind("fold_toggle", "toggle fold", "z a")
+	bind("fold_close", "close fold", "z c")
+	bind("fold_open", "open fold", "z o")
+	bind("fold_close_all", "close all folds", "z M")
+	bind("fold_open_all", "open all folds", "z R")
+	bind("table_insert", "insert table", "t n")
+	bind("table_format", "format table", "t f")
+	bind("table_row_below", "add row below", "t o")
+	bind("table_row_above", "add row above", "t O")
+	bind("table_row_delete", "delete row", "t d")
+	bind("table_row_up", "move row up", "t K")
+	bind("table_row_down", "move row down", "t J")
+	bind("table_column_after", "add column after", "t a")
+	bind("table_column_before", "add column before", "t i")
+	bind("table_column_delete", "delete column", "t x")
+	bind("table_column_left", "move column left", "t H")
+	bind("table_column_right", "move column right", "t L")
+	bind("table_align_left", "align column left", "t <")
+	bind("table_align_center", "center column", "t =")
+	bind("table_align_right", "align column right", "t >")
+	bind("table_align_none", "clear column alignment", "t -")
+	return km
+}
+
+// SetKeymap changes the normal-mode bindings of all editors
+func SetKeymap(km *keymap.Map) {
+	keys = km
+}
+
+// Keymap returns the normal-mode bindings in use
+func Keymap() *keymap.Map {
+	return keys
+}
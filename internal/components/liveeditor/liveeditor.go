@@ -7,6 +7,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
 	"github.com/takahashinaoki/obsidiantui/internal/parser"
 )
 
@@ -24,6 +25,11 @@ type Model struct {
 	insertMode  bool
 	styledCache map[int]string
 	cacheValid  map[int]bool
+	keySeq      keymap.Resolver // start of a multi-key normal-mode command
+	folded      map[int]bool    // first lines of the closed folds
+	undo, redo  []snapshot      // whole-text edits, see undo.go
+	rev         int64           // changes with every edit, see folds.go
+	foldCache   *foldCache
 }
 
 var (
@@ -34,8 +40,10 @@ var (
 	mathStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
 	bulletStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
 	blockquoteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	tableStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	cursorStyle     = lipgloss.NewStyle().Reverse(true)
 	lineNumStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	foldMarkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
 	normalHeader    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Padding(0, 1)
 	insertHeader    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("34")).Padding(0, 1)
 )
@@ -57,6 +65,7 @@ func New() Model {
 		insertMode:  false,
 		styledCache: make(map[int]string),
 		cacheValid:  make(map[int]bool),
+		foldCache:   &foldCache{},
 	}
 }
 
@@ -79,13 +88,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		if msg.String() == "esc" {
 			m.insertMode = false
+			m.keySeq.Reset()
 			return m, nil
 		}
 
+		var cmd tea.Cmd
 		if !m.insertMode {
-			return m.handleNormalMode(msg)
+			m, cmd = m.handleNormalMode(msg)
+		} else {
+			m, cmd = m.handleInsertMode(msg)
+		}
+		// Moving or editing into a closed fold opens it
+		if m.revealCursor() {
+			m.ensureCursorVisible()
+			cmd = tea.Batch(cmd, m.foldsChanged())
 		}
-		return m.handleInsertMode(msg)
+		return m, cmd
 
 	case tea.MouseMsg:
 		switch msg.Button {
@@ -99,8 +117,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		case tea.MouseButtonLeft:
 			if msg.Action == tea.MouseActionPress {
-				// Y=0 is header, Y=1+ are content lines
-				clickRow := msg.Y - 1 + m.offsetRow
+				// Y=0 is header, Y=1+ are content lines, skipping closed folds
+				ends := m.closedEnds()
+				clickRow := m.offsetRow
+				for i := 1; i < msg.Y && clickRow < len(m.lines); i++ {
+					clickRow = m.nextLine(clickRow, ends)
+				}
+				if msg.Y < 1 {
+					clickRow = -1
+				}
 				if clickRow >= 0 && clickRow < len(m.lines) {
 					m.cursorRow = clickRow
 					// X: subtract line number width (4 digits + 1 space = 5)
@@ -123,62 +148,71 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m Model) handleNormalMode(msg tea.KeyMsg) (Model, tea.Cmd) {
-	switch msg.String() {
-	case "i":
+	_, action, _ := m.keySeq.Feed(msg.String(), keys)
+	switch action {
+	case "insert":
 		m.insertMode = true
-	case "a":
+	case "append":
 		m.insertMode = true
 		if m.cursorCol < utf8.RuneCountInString(m.currentLine()) {
 			m.cursorCol++
 		}
-	case "I":
+	case "insert_line_start":
 		m.insertMode = true
 		m.cursorCol = 0
-	case "A":
+	case "append_line_end":
 		m.insertMode = true
 		m.cursorCol = utf8.RuneCountInString(m.currentLine())
-	case "o":
+	case "open_below":
 		m.insertLineBelow()
 		m.insertMode = true
-	case "O":
+	case "open_above":
 		m.insertLineAbove()
 		m.insertMode = true
-	case "up", "k":
+	case "up":
 		m.moveCursorUp()
-	case "down", "j":
+	case "down":
 		m.moveCursorDown()
-	case "left", "h":
+	case "left":
 		m.moveCursorLeft()
-	case "right", "l":
+	case "right":
 		m.moveCursorRight()
-	case "home", "0":
+	case "line_start":
 		m.cursorCol = 0
-	case "end", "$":
+	case "line_end":
 		m.cursorCol = utf8.RuneCountInString(m.currentLine())
-	case "ctrl+u", "pgup":
+	case "page_up":
 		for i := 0; i < m.height/2; i++ {
 			m.moveCursorUp()
 		}
-	case "ctrl+d", "pgdown":
+	case "page_down":
 		for i := 0; i < m.height/2; i++ {
 			m.moveCursorDown()
 		}
-	case "g":
+	case "top":
 		m.cursorRow, m.cursorCol, m.offsetRow = 0, 0, 0
-	case "G":
+	case "bottom":
 		m.cursorRow = len(m.lines) - 1
 		m.cursorCol = 0
 		m.ensureCursorVisible()
-	case "w":
+	case "word_forward":
 		m.moveWordForward()
-	case "b":
+	case "word_backward":
 		m.moveWordBackward()
-	case "x":
+	case "delete_char":
 		m.deleteChar()
-	case "enter", "ctrl+]":
+	case "follow_link":
 		if link := m.getLinkAtCursor(); link != nil {
 			return m, func() tea.Msg { return LinkFollowMsg{Target: link.Target} }
 		}
+	case "block_link":
+		return m, m.blockLink()
+	case "fold_toggle", "fold_close", "fold_open", "fold_close_all", "fold_open_all":
+		return m, m.fold(strings.TrimPrefix(action, "fold_"))
+	default:
+		if strings.HasPrefix(action, "table_") {
+			m.tableCommand(action)
+		}
 	}
 	return m, nil
 }
@@ -194,7 +228,14 @@ func (m Model) handleInsertMode(msg tea.KeyMsg) (Model, tea.Cmd) {
 	case "right":
 		m.moveCursorRight()
 	case "enter":
-		m.insertNewline()
+		// In a table, Enter goes down a row and Tab across cells
+		if !m.tableNextRow() {
+			m.insertNewline()
+		}
+	case "tab":
+		m.tableNextCell()
+	case "shift+tab":
+		m.tablePrevCell()
 	case "backspace":
 		m.backspace()
 	case "delete":
@@ -213,15 +254,17 @@ func (m Model) handleInsertMode(msg tea.KeyMsg) (Model, tea.Cmd) {
 
 func (m *Model) invalidateCache(row int) {
 	m.cacheValid[row] = false
+	m.rev = nextRev()
 }
 
 func (m *Model) invalidateAllCache() {
 	m.cacheValid = make(map[int]bool)
+	m.rev = nextRev()
 }
 
 func (m *Model) moveCursorUp() {
 	if m.cursorRow > 0 {
-		m.cursorRow--
+		m.cursorRow = m.prevLine(m.cursorRow, m.closedEnds())
 		if lineLen := utf8.RuneCountInString(m.lines[m.cursorRow]); m.cursorCol > lineLen {
 			m.cursorCol = lineLen
 		}
@@ -230,8 +273,8 @@ func (m *Model) moveCursorUp() {
 }
 
 func (m *Model) moveCursorDown() {
-	if m.cursorRow < len(m.lines)-1 {
-		m.cursorRow++
+	if next := m.nextLine(m.cursorRow, m.closedEnds()); next < len(m.lines) {
+		m.cursorRow = next
 		if lineLen := utf8.RuneCountInString(m.lines[m.cursorRow]); m.cursorCol > lineLen {
 			m.cursorCol = lineLen
 		}
@@ -316,6 +359,7 @@ func (m *Model) insertNewline() {
 	before, after := string(line[:m.cursorCol]), string(line[m.cursorCol:])
 	m.lines[m.cursorRow] = before
 	m.lines = append(m.lines[:m.cursorRow+1], append([]string{after}, m.lines[m.cursorRow+1:]...)...)
+	m.linesInserted(m.cursorRow+1, 1)
 	m.cursorRow++
 	m.cursorCol = 0
 	m.modified = true
@@ -335,6 +379,7 @@ func (m *Model) backspace() {
 		m.cursorCol = utf8.RuneCountInString(prevLine)
 		m.lines[m.cursorRow-1] = prevLine + m.currentLine()
 		m.lines = append(m.lines[:m.cursorRow], m.lines[m.cursorRow+1:]...)
+		m.linesRemoved(m.cursorRow, 1)
 		m.cursorRow--
 		m.modified = true
 		m.invalidateAllCache()
@@ -350,14 +395,18 @@ func (m *Model) deleteChar() {
 	} else if m.cursorRow < len(m.lines)-1 {
 		m.lines[m.cursorRow] = m.currentLine() + m.lines[m.cursorRow+1]
 		m.lines = append(m.lines[:m.cursorRow+1], m.lines[m.cursorRow+2:]...)
+		m.linesRemoved(m.cursorRow+1, 1)
 		m.modified = true
 		m.invalidateAllCache()
 	}
 }
 
 func (m *Model) insertLineBelow() {
-	m.lines = append(m.lines[:m.cursorRow+1], append([]string{""}, m.lines[m.cursorRow+1:]...)...)
-	m.cursorRow++
+	// Below a closed fold means after the lines it hides
+	row := m.nextLine(m.cursorRow, m.closedEnds())
+	m.lines = append(m.lines[:row], append([]string{""}, m.lines[row:]...)...)
+	m.linesInserted(row, 1)
+	m.cursorRow = row
 	m.cursorCol = 0
 	m.modified = true
 	m.invalidateAllCache()
@@ -366,6 +415,7 @@ func (m *Model) insertLineBelow() {
 
 func (m *Model) insertLineAbove() {
 	m.lines = append(m.lines[:m.cursorRow], append([]string{""}, m.lines[m.cursorRow:]...)...)
+	m.linesInserted(m.cursorRow, 1)
 	m.cursorCol = 0
 	m.modified = true
 	m.invalidateAllCache()
@@ -377,10 +427,27 @@ func (m *Model) ensureCursorVisible() {
 	if visibleHeight < 1 {
 		visibleHeight = 1
 	}
+	ends := m.closedEnds()
+	if start, ok := m.hidingFold(m.offsetRow, ends); ok {
+		m.offsetRow = start
+	}
 	if m.cursorRow < m.offsetRow {
 		m.offsetRow = m.cursorRow
-	} else if m.cursorRow >= m.offsetRow+visibleHeight {
-		m.offsetRow = m.cursorRow - visibleHeight + 1
+		return
+	}
+	if len(ends) == 0 {
+		if m.cursorRow >= m.offsetRow+visibleHeight {
+			m.offsetRow = m.cursorRow - visibleHeight + 1
+		}
+		return
+	}
+	// Closed folds take one row each, so count the rows down to the cursor
+	rows := 1
+	for row := m.offsetRow; row < m.cursorRow; row = m.nextLine(row, ends) {
+		rows++
+	}
+	for ; rows > visibleHeight; rows-- {
+		m.offsetRow = m.nextLine(m.offsetRow, ends)
 	}
 }
 
@@ -394,6 +461,7 @@ func (m *Model) JumpToLine(line int) {
 	}
 	m.cursorRow = line
 	m.cursorCol = 0
+	m.revealLine(line)
 	m.ensureCursorVisible()
 }
 
@@ -431,8 +499,9 @@ func (m Model) View() string {
 		visibleHeight = 1
 	}
 
+	ends := m.closedEnds()
+	lineNum := m.offsetRow
 	for i := 0; i < visibleHeight; i++ {
-		lineNum := m.offsetRow + i
 		if lineNum >= len(m.lines) {
 			b.WriteString("~   \n")
 			continue
@@ -452,7 +521,12 @@ func (m Model) View() string {
 		} else {
 			b.WriteString(strconv.Itoa(n))
 		}
-		b.WriteByte(' ')
+		end, folded := ends[lineNum]
+		if folded {
+			b.WriteString(foldMarkStyle.Render("▸"))
+		} else {
+			b.WriteByte(' ')
+		}
 
 		// Line content
 		line := m.lines[lineNum]
@@ -461,7 +535,11 @@ func (m Model) View() string {
 		} else {
 			b.WriteString(m.getStyledLine(lineNum, line))
 		}
+		if folded {
+			b.WriteString(lineNumStyle.Render(" " + parser.FoldSummary(end-lineNum)))
+		}
 		b.WriteByte('\n')
+		lineNum = m.nextLine(lineNum, ends)
 	}
 
 	return b.String()
@@ -506,6 +584,14 @@ func (m Model) styleLine(line string) string {
 	if line[0] == '#' {
 		return headerStyle.Render(line)
 	}
+	if line[0] == '>' && strings.Contains(line, "[!") {
+		if c, ok := parser.ParseCallout(line); ok {
+			// The title in the callout's colour, after its > markers
+			marker := strings.Index(line, "[!")
+			style := headerStyle.Foreground(lipgloss.Color(parser.LookupCallout(c.Type).Color))
+			return blockquoteStyle.Render(line[:marker]) + style.Render(line[marker:])
+		}
+	}
 	if len(line) > 1 && line[0] == '>' && line[1] == ' ' {
 		return blockquoteStyle.Render(line)
 	}
@@ -515,10 +601,53 @@ func (m Model) styleLine(line string) string {
 	if len(line) >= 3 && line[0] == '`' && line[1] == '`' && line[2] == '`' {
 		return codeStyle.Render(line)
 	}
+	if parser.IsTableRow(line) {
+		return m.styleTableRow(line)
+	}
 
 	return m.styleInline(line)
 }
 
+// styleTableRow draws a table row's pipes, and a delimiter row, as a grid.
+// Every character keeps its width, so the cursor stays where it is.
+func (m Model) styleTableRow(line string) string {
+	if parser.IsTableDelimiter(line) {
+		first, last := strings.IndexRune(line, '|'), strings.LastIndex(line, "|")
+		var b strings.Builder
+		for i, r := range line {
+			switch {
+			case r == '-':
+				r = '─'
+			case r != '|':
+			case i == first:
+				r = '├'
+			case i == last:
+				r = '┤'
+			default:
+				r = '┼'
+			}
+			b.WriteRune(r)
+		}
+		return tableStyle.Render(b.String())
+	}
+
+	runes := []rune(line)
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '|':
+			b.WriteString(m.styleInline(string(runes[start:i])))
+			b.WriteString(tableStyle.Render("│"))
+			start = i + 1
+		}
+	}
+	b.WriteString(m.styleInline(string(runes[min(start, len(runes)):])))
+	return b.String()
+}
+
 func (m Model) styleInline(text string) string {
 	if len(text) == 0 {
 		return ""
@@ -614,6 +743,8 @@ func (m *Model) SetContent(content string, filePath string) {
 	}
 	m.filePath = filePath
 	m.cursorRow, m.cursorCol, m.offsetRow = 0, 0, 0
+	m.folded = nil
+	m.undo, m.redo = nil, nil
 	m.modified = false
 	m.links = parser.ExtractAllLinks(content)
 	m.invalidateAllCache()
@@ -636,10 +767,20 @@ func (m Model) Content() string {
 	return strings.Join(m.lines, "\n")
 }
 
+// Revision changes with every edit to the text
+func (m Model) Revision() int64 {
+	return m.rev
+}
+
 func (m Model) FilePath() string {
 	return m.filePath
 }
 
+// SetFilePath changes the note's path after it was renamed, keeping the edits
+func (m *Model) SetFilePath(filePath string) {
+	m.filePath = filePath
+}
+
 func (m Model) Modified() bool {
 	return m.modified
 }
@@ -665,3 +806,23 @@ func (m *Model) getLinkAtCursor() *parser.Link {
 func (m Model) CursorRow() int {
 	return m.cursorRow
 }
+
+func (m Model) CursorCol() int {
+	return m.cursorCol
+}
+
+func (m Model) ScrollOffset() int {
+	return m.offsetRow
+}
+
+// SetCursor restores a cursor and scroll position, clamped to the content
+func (m *Model) SetCursor(row, col, offset int) {
+	row = max(0, min(row, len(m.lines)-1))
+	m.cursorRow = row
+	m.cursorCol = max(0, min(col, utf8.RuneCountInString(m.lines[row])))
+	m.offsetRow = max(0, min(offset, row))
+	m.revealCursor()
+	if m.height > 0 {
+		m.ensureCursorVisible()
+	}
+}
@@ -0,0 +1,226 @@
+package liveeditor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+var tableAligns = map[string]parser.Align{
+	"left":   parser.AlignLeft,
+	"center": parser.AlignCenter,
+	"right":  parser.AlignRight,
+	"none":   parser.AlignNone,
+}
+
+// tableAtCursor returns the table the cursor is in, with the row and cell
+// it is on. On the delimiter row it is on the header.
+func (m Model) tableAtCursor() (t parser.Table, row, cell int, ok bool) {
+	t, ok = parser.FindTable(m.lines, m.cursorRow)
+	if !ok {
+		return t, 0, 0, false
+	}
+	row = max(t.Row(m.cursorRow), 0)
+	cell = min(parser.TableCellAt(m.lines[m.cursorRow], m.cursorCol), t.Columns()-1)
+	return t, row, cell, true
+}
+
+// setTable replaces the lines of the table with it formatted, and puts the
+// cursor at the start of a cell
+func (m *Model) setTable(t parser.Table, row, cell int) {
+	lines := t.Format()
+	old := t.End - t.Start + 1
+	if !slices.Equal(lines, m.lines[t.Start:t.End+1]) {
+		m.lines = slices.Replace(m.lines, t.Start, t.End+1, lines...)
+		switch {
+		case len(lines) > old:
+			m.linesInserted(t.Start+old, len(lines)-old)
+		case len(lines) < old:
+			m.linesRemoved(t.Start+len(lines), old-len(lines))
+		}
+		m.modified = true
+		m.invalidateAllCache()
+	}
+	m.cursorRow = t.Line(row)
+	m.cursorCol = parser.TableCellCol(m.lines[m.cursorRow], cell)
+	m.ensureCursorVisible()
+}
+
+// FormatTable lines up the columns of the table at the cursor
+func (m *Model) FormatTable() bool {
+	t, row, cell, ok := m.tableAtCursor()
+	if ok {
+		m.setTable(t, row, cell)
+	}
+	return ok
+}
+
+// InsertTable adds an empty table on the cursor's line when it is blank, or
+// after it, and starts typing in its first body cell
+func (m *Model) InsertTable(cols, rows int) {
+	at := m.cursorRow
+	replace := strings.TrimSpace(m.currentLine()) == ""
+	if !replace {
+		at = m.nextLine(m.cursorRow, m.closedEnds())
+	}
+
+	// Tables need blank lines around them
+	var block []string
+	if at > 0 && strings.TrimSpace(m.lines[at-1]) != "" {
+		block = append(block, "")
+	}
+	block = append(block, parser.NewTable(cols, rows).Format()...)
+	next := at
+	if replace {
+		next++
+	}
+	if next < len(m.lines) && strings.TrimSpace(m.lines[next]) != "" {
+		block = append(block, "")
+	}
+
+	if replace {
+		m.lines = slices.Replace(m.lines, at, at+1, block...)
+		m.linesInserted(at+1, len(block)-1)
+	} else {
+		m.lines = slices.Insert(m.lines, at, block...)
+		m.linesInserted(at, len(block))
+	}
+	start := at
+	if block[0] == "" {
+		start++
+	}
+	m.cursorRow = start + 2
+	m.cursorCol = parser.TableCellCol(m.lines[m.cursorRow], 0)
+	m.insertMode = true
+	m.modified = true
+	m.invalidateAllCache()
+	m.ensureCursorVisible()
+}
+
+// tableNextCell moves to the next cell, adding a row after the last one
+func (m *Model) tableNextCell() bool {
+	t, row, cell, ok := m.tableAtCursor()
+	if !ok {
+		return false
+	}
+	if cell++; cell == t.Columns() {
+		row, cell = row+1, 0
+		if row == len(t.Rows) {
+			t.InsertRow(row)
+		}
+	}
+	m.setTable(t, row, cell)
+	return true
+}
+
+// tablePrevCell moves to the previous cell
+func (m *Model) tablePrevCell() bool {
+	t, row, cell, ok := m.tableAtCursor()
+	if !ok {
+		return false
+	}
+	if cell--; cell < 0 {
+		if row == 0 {
+			cell = 0
+		} else {
+			row, cell = row-1, t.Columns()-1
+		}
+	}
+	m.setTable(t, row, cell)
+	return true
+}
+
+// tableNextRow moves to the same cell of the next row, adding a row after
+// the last one. On an empty last row it removes it and leaves the table.
+func (m *Model) tableNextRow() bool {
+	t, row, cell, ok := m.tableAtCursor()
+	if !ok {
+		return false
+	}
+	if row > 0 && row == len(t.Rows)-1 && !slices.ContainsFunc(t.Rows[row], func(c string) bool { return c != "" }) {
+		t.DeleteRow(row)
+		m.setTable(t, row-1, cell)
+		end := t.Line(len(t.Rows) - 1)
+		if end+1 == len(m.lines) || strings.TrimSpace(m.lines[end+1]) != "" {
+			m.lines = slices.Insert(m.lines, end+1, "")
+			m.linesInserted(end+1, 1)
+			m.invalidateAllCache()
+		}
+		m.cursorRow, m.cursorCol = end+1, 0
+		m.ensureCursorVisible()
+		return true
+	}
+	if row++; row == len(t.Rows) {
+		t.InsertRow(row)
+	}
+	m.setTable(t, row, cell)
+	return true
+}
+
+// tableCommand runs a table action of the normal-mode keymap on the table at
+// the cursor
+func (m *Model) tableCommand(action string) {
+	if action == "table_insert" {
+		m.InsertTable(3, 2)
+		return
+	}
+	t, row, cell, ok := m.tableAtCursor()
+	if !ok {
+		return
+	}
+	switch action {
+	case "table_format":
+	case "table_row_below":
+		row++
+		t.InsertRow(row)
+	case "table_row_above":
+		// Nothing goes above the header
+		row = max(row, 1)
+		t.InsertRow(row)
+	case "table_row_delete":
+		if row == 0 {
+			return
+		}
+		t.DeleteRow(row)
+		row = min(row, len(t.Rows)-1)
+	case "table_row_up":
+		if row < 2 {
+			return
+		}
+		t.Rows[row-1], t.Rows[row] = t.Rows[row], t.Rows[row-1]
+		row--
+	case "table_row_down":
+		if row == 0 || row == len(t.Rows)-1 {
+			return
+		}
+		t.Rows[row+1], t.Rows[row] = t.Rows[row], t.Rows[row+1]
+		row++
+	case "table_column_after":
+		cell++
+		t.InsertColumn(cell)
+	case "table_column_before":
+		t.InsertColumn(cell)
+	case "table_column_delete":
+		if t.Columns() == 1 {
+			return
+		}
+		t.DeleteColumn(cell)
+		cell = min(cell, t.Columns()-1)
+	case "table_column_left":
+		if cell == 0 {
+			return
+		}
+		t.SwapColumns(cell-1, cell)
+		cell--
+	case "table_column_right":
+		if cell == t.Columns()-1 {
+			return
+		}
+		t.SwapColumns(cell, cell+1)
+		cell++
+	case "table_align_left", "table_align_center", "table_align_right", "table_align_none":
+		t.Align[cell] = tableAligns[strings.TrimPrefix(action, "table_align_")]
+	}
+	m.setTable(t, row, cell)
+}
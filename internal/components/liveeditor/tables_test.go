@@ -0,0 +1,189 @@
+package liveeditor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+const tableNote = "| a | b |\n|---|---|\n| 1 | 2 |\n| 3 | 4 |"
+
+// tableAt returns a model editing tableNote with the cursor at row, col
+func tableAt(row, col int) Model {
+	m := newEditor(tableNote)
+	m.SetCursor(row, col, 0)
+	return m
+}
+
+func TestTableCommands(t *testing.T) {
+	const (
+		header = "| a   | b   |"
+		delim  = "| --- | --- |"
+		row1   = "| 1   | 2   |"
+		row2   = "| 3   | 4   |"
+		empty  = "|     |     |"
+	)
+
+	tests := []struct {
+		name     string
+		row, col int
+		keys     []string
+		want     []string
+		wantRow  int
+		wantCell int
+	}{
+		{"format", 2, 2, []string{"t", "f"}, []string{header, delim, row1, row2}, 2, 0},
+		{"row below", 2, 2, []string{"t", "o"}, []string{header, delim, row1, empty, row2}, 3, 0},
+		{"row above the header goes below it", 0, 2, []string{"t", "O"}, []string{header, delim, empty, row1, row2}, 2, 0},
+		{"row above", 3, 6, []string{"t", "O"}, []string{header, delim, row1, empty, row2}, 3, 1},
+		{"delete row", 2, 2, []string{"t", "d"}, []string{header, delim, row2}, 2, 0},
+		{"delete last row", 3, 2, []string{"t", "d"}, []string{header, delim, row1}, 2, 0},
+		{"move row down", 2, 2, []string{"t", "J"}, []string{header, delim, row2, row1}, 3, 0},
+		{"move row up", 3, 6, []string{"t", "K"}, []string{header, delim, row2, row1}, 2, 1},
+		{
+			"column after", 2, 2, []string{"t", "a"},
+			[]string{"| a   |     | b   |", "| --- | --- | --- |", "| 1   |     | 2   |", "| 3   |     | 4   |"}, 2, 1,
+		},
+		{
+			"column before", 2, 6, []string{"t", "i"},
+			[]string{"| a   |     | b   |", "| --- | --- | --- |", "| 1   |     | 2   |", "| 3   |     | 4   |"}, 2, 1,
+		},
+		{"delete column", 2, 2, []string{"t", "x"}, []string{"| b   |", "| --- |", "| 2   |", "| 4   |"}, 2, 0},
+		{
+			"move column right", 2, 2, []string{"t", "L"},
+			[]string{"| b   | a   |", delim, "| 2   | 1   |", "| 4   | 3   |"}, 2, 1,
+		},
+		{
+			"move column left", 2, 6, []string{"t", "H"},
+			[]string{"| b   | a   |", delim, "| 2   | 1   |", "| 4   | 3   |"}, 2, 0,
+		},
+		{
+			"align right", 2, 2, []string{"t", ">"},
+			[]string{"|   a | b   |", "| --: | --- |", "|   1 | 2   |", "|   3 | 4   |"}, 2, 0,
+		},
+		{
+			"align center", 2, 6, []string{"t", "="},
+			[]string{"| a   |  b  |", "| --- | :-: |", "| 1   |  2  |", "| 3   |  4  |"}, 2, 1,
+		},
+		{"align left", 2, 2, []string{"t", "<"}, []string{header, "| :-- | --- |", row1, row2}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeKeys(tableAt(tt.row, tt.col), tt.keys...)
+			if got, want := m.Content(), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("Content() =\n%s\nwant\n%s", got, want)
+			}
+			if m.CursorRow() != tt.wantRow {
+				t.Errorf("cursor on line %d, want %d", m.CursorRow(), tt.wantRow)
+			}
+			if cell := parser.TableCellAt(m.lines[m.CursorRow()], m.CursorCol()); cell != tt.wantCell {
+				t.Errorf("cursor in cell %d, want %d", cell, tt.wantCell)
+			}
+		})
+	}
+}
+
+func TestTableCommandsKeepTheTable(t *testing.T) {
+	// Commands that can't apply leave the note as it is
+	tests := []struct {
+		name     string
+		row, col int
+		keys     []string
+	}{
+		{"delete the header", 0, 2, []string{"t", "d"}},
+		{"move the first row above the header", 2, 2, []string{"t", "K"}},
+		{"move the last row down", 3, 2, []string{"t", "J"}},
+		{"move the first column left", 2, 2, []string{"t", "H"}},
+		{"move the last column right", 2, 6, []string{"t", "L"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeKeys(tableAt(tt.row, tt.col), tt.keys...)
+			if got := m.Content(); got != tableNote {
+				t.Errorf("Content() =\n%s\nwant it unchanged", got)
+			}
+		})
+	}
+
+	m := newEditor("| a |\n|---|\n| 1 |")
+	m.SetCursor(2, 2, 0)
+	if m = typeKeys(m, "t", "x"); m.Content() != "| a |\n|---|\n| 1 |" {
+		t.Errorf("the last column was deleted: %q", m.Content())
+	}
+
+	m = newEditor("not a table")
+	if m = typeKeys(m, "t", "o"); m.Content() != "not a table" || m.Modified() {
+		t.Errorf("a table command changed a line outside tables: %q", m.Content())
+	}
+}
+
+func TestInsertTable(t *testing.T) {
+	m := newEditor("text\n\nmore")
+	m.SetCursor(1, 0, 0)
+	m = typeKeys(m, "t", "n")
+
+	// The blank line is kept between the text and the table
+	want := strings.Join([]string{
+		"text",
+		"",
+		"| Column 1 | Column 2 | Column 3 |",
+		"| -------- | -------- | -------- |",
+		"|          |          |          |",
+		"|          |          |          |",
+		"",
+		"more",
+	}, "\n")
+	if got := m.Content(); got != want {
+		t.Errorf("Content() =\n%s\nwant\n%s", got, want)
+	}
+	if !m.InsertMode() || m.CursorRow() != 4 {
+		t.Errorf("cursor on line %d (insert mode %v), want typing in the first body cell", m.CursorRow(), m.InsertMode())
+	}
+}
+
+func TestTableInsertKeys(t *testing.T) {
+	type pos struct{ row, cell int }
+	cursor := func(m Model) pos {
+		return pos{m.CursorRow(), parser.TableCellAt(m.lines[m.CursorRow()], m.CursorCol())}
+	}
+
+	m := typeKeys(tableAt(2, 2), "i", "tab")
+	if got := cursor(m); got != (pos{2, 1}) {
+		t.Errorf("Tab went to %v, want the next cell", got)
+	}
+	m = typeKeys(m, "shift+tab", "shift+tab")
+	if got := cursor(m); got != (pos{0, 1}) {
+		t.Errorf("Shift+Tab twice went to %v, want the header's last cell", got)
+	}
+
+	// Tab in the last cell adds a row
+	m = typeKeys(tableAt(3, 6), "i", "tab")
+	if got := cursor(m); got != (pos{4, 0}) || len(m.lines) != 5 {
+		t.Errorf("Tab in the last cell went to %v with %d lines, want a new row", got, len(m.lines))
+	}
+
+	// Enter goes down a row, adds one after the last, and leaves the table
+	// from an empty last row, dropping it
+	m = typeKeys(tableAt(2, 6), "i", "enter")
+	if got := cursor(m); got != (pos{3, 1}) {
+		t.Errorf("Enter went to %v, want the same cell of the next row", got)
+	}
+	m = typeKeys(m, "enter")
+	if got := cursor(m); got != (pos{4, 1}) || len(m.lines) != 5 {
+		t.Errorf("Enter on the last row went to %v with %d lines, want a new row", got, len(m.lines))
+	}
+	m = typeKeys(m, "enter", "x")
+	want := "| a   | b   |\n| --- | --- |\n| 1   | 2   |\n| 3   | 4   |\nx"
+	if got := m.Content(); got != want {
+		t.Errorf("Content() after leaving the table =\n%s\nwant\n%s", got, want)
+	}
+
+	// Outside tables the keys do what they always do
+	m = newEditor("ab")
+	m.SetCursor(0, 1, 0)
+	m = typeKeys(m, "i", "enter")
+	if got := m.Content(); got != "a\nb" {
+		t.Errorf("Enter outside a table gave %q", got)
+	}
+}
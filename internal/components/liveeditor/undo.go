@@ -0,0 +1,81 @@
+package liveeditor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// maxUndo is how many edits undo goes back
+const maxUndo = 200
+
+// snapshot is the text and cursor on one side of an edit made with Edit.
+// It only applies while the text is still at rev: typing after an edit
+// drops the edits before it rather than undoing them with the typing.
+type snapshot struct {
+	lines    []string
+	row, col int
+	rev      int64
+}
+
+func (m Model) snapshot() snapshot {
+	return snapshot{lines: slices.Clone(m.lines), row: m.cursorRow, col: m.cursorCol}
+}
+
+// Edit replaces the whole text as one change that UndoEdit takes back, with
+// the cursor on line
+func (m *Model) Edit(content string, line int) {
+	before := m.snapshot()
+	m.setLines(strings.Split(content, "\n"))
+	m.JumpToLine(line)
+
+	before.rev = m.rev
+	m.undo = append(m.undo, before)
+	if len(m.undo) > maxUndo {
+		m.undo = slices.Delete(m.undo, 0, len(m.undo)-maxUndo)
+	}
+	m.redo = nil
+}
+
+// UndoEdit goes back to the text before the last Edit, or forward again
+// with redo. It returns the cursor's line, and false when there is no edit
+// to take back or the text was changed since.
+func (m *Model) UndoEdit(redo bool) (int, bool) {
+	from, to := &m.undo, &m.redo
+	if redo {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		return 0, false
+	}
+	s := (*from)[len(*from)-1]
+	if s.rev != m.rev {
+		m.undo, m.redo = nil, nil
+		return 0, false
+	}
+	*from = (*from)[:len(*from)-1]
+
+	current := m.snapshot()
+	m.setLines(s.lines)
+	m.cursorRow = min(s.row, len(m.lines)-1)
+	m.cursorCol = s.col
+	m.ensureCursorVisible()
+
+	current.rev = m.rev
+	*to = append(*to, current)
+	return m.cursorRow, true
+}
+
+// setLines replaces the text. The closed folds are opened, as their lines
+// may now start something else.
+func (m *Model) setLines(lines []string) {
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	m.lines = lines
+	m.folded = nil
+	m.modified = true
+	m.links = parser.ExtractAllLinks(m.Content())
+	m.invalidateAllCache()
+}
@@ -0,0 +1,94 @@
+package liveeditor
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// specialKeys are the keys typeKeys sends by name
+var specialKeys = map[string]tea.KeyType{
+	"esc":       tea.KeyEsc,
+	"enter":     tea.KeyEnter,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+}
+
+// typeKeys feeds keys to the editor, one key per rune except the special ones
+func typeKeys(m Model, keys ...string) Model {
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if t, ok := specialKeys[k]; ok {
+			msg = tea.KeyMsg{Type: t}
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func newEditor(content string) Model {
+	m := New()
+	m.SetSize(80, 20)
+	m.SetFocused(true)
+	m.SetContent(content, "note.md")
+	return m
+}
+
+func TestUndoEdit(t *testing.T) {
+	m := newEditor("# A\n# B")
+	m.Edit("# B\n# A", 1)
+	if !m.Modified() {
+		t.Error("Edit() did not mark the note modified")
+	}
+
+	if line, ok := m.UndoEdit(false); !ok || line != 0 {
+		t.Fatalf("UndoEdit() = %d, %v, want 0, true", line, ok)
+	}
+	if got := m.Content(); got != "# A\n# B" {
+		t.Errorf("Content() after undo = %q", got)
+	}
+	if line, ok := m.UndoEdit(true); !ok || line != 1 {
+		t.Fatalf("UndoEdit(redo) = %d, %v, want 1, true", line, ok)
+	}
+	if got := m.Content(); got != "# B\n# A" {
+		t.Errorf("Content() after redo = %q", got)
+	}
+	if _, ok := m.UndoEdit(true); ok {
+		t.Error("UndoEdit(redo) redid an edit twice")
+	}
+}
+
+func TestUndoEditAfterTyping(t *testing.T) {
+	m := newEditor("# A\n# B")
+	m.Edit("# B\n# A", 0)
+
+	// Moving around keeps the edit undoable, typing does not
+	m = typeKeys(m, "j", "k")
+	if _, ok := m.UndoEdit(false); !ok {
+		t.Fatal("UndoEdit() failed after cursor motion")
+	}
+	m.UndoEdit(true)
+
+	m = typeKeys(m, "x")
+	if _, ok := m.UndoEdit(false); ok {
+		t.Fatal("UndoEdit() undid an edit made before typing")
+	}
+	if got := m.Content(); got != " B\n# A" {
+		t.Errorf("Content() = %q, want the typing kept", got)
+	}
+}
+
+func TestUndoEditOpensFolds(t *testing.T) {
+	m := newEditor("# A\ntext\n# B\nmore")
+	m.Edit("# B\nmore\n# A\ntext", 0)
+	m.SetFolds([]int{2})
+	if got := m.Folds(); !slices.Equal(got, []int{2}) {
+		t.Fatalf("Folds() = %v, want [2]", got)
+	}
+
+	m.UndoEdit(false)
+	if got := m.Folds(); len(got) != 0 {
+		t.Errorf("Folds() after undo = %v, want the folds of the other text dropped", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
 )
 
 var (
@@ -17,14 +19,11 @@ var (
 	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("24")).Padding(1)
 	headerStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
 	emptyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	currentStyle   = lipgloss.NewStyle().Background(lipgloss.Color("237"))
 )
 
 // Heading represents a markdown heading
-type Heading struct {
-	Text   string
-	Level  int
-	Line   int // 0-indexed line number
-}
+type Heading = parser.Heading
 
 // Model is the outline view model
 type Model struct {
@@ -34,6 +33,9 @@ type Model struct {
 	height   int
 	active   bool
 	filePath string
+	docked   bool // shown as a sidebar rather than an overlay
+	focused  bool
+	current  int // heading of the section the editor's cursor is in, -1 if none
 }
 
 // JumpToLineMsg is sent when user selects a heading
@@ -41,62 +43,88 @@ type JumpToLineMsg struct {
 	Line int
 }
 
-// OutlineClosedMsg is sent when outline is closed
+// OutlineClosedMsg is sent when outline is closed, or when the sidebar
+// gives up focus
 type OutlineClosedMsg struct{}
 
+// EditOp is a change to the structure of the note made from the outline
+type EditOp int
+
+const (
+	Promote EditOp = iota
+	Demote
+	MoveUp
+	MoveDown
+	ExtractLink  // move the section to a new note, leaving a link
+	ExtractEmbed // move the section to a new note, leaving an embed
+	Undo         // take back the last of these edits
+	Redo
+)
+
+// EditSectionMsg asks for the section of the heading at Line to be changed
+type EditSectionMsg struct {
+	Line int
+	Op   EditOp
+}
+
 func New() Model {
-	return Model{}
+	return Model{current: -1}
+}
+
+// NewSidebar returns an outline shown beside the editor, which follows the
+// section the editor's cursor is in
+func NewSidebar() Model {
+	return Model{docked: true, current: -1}
 }
 
 // ParseHeadings extracts headings from markdown content
 func ParseHeadings(content string) []Heading {
-	var headings []Heading
-	lines := strings.Split(content, "\n")
-
-	inCodeBlock := false
-	for i, line := range lines {
-		// Check for code block
-		if strings.HasPrefix(strings.TrimSpace(line), "```") {
-			inCodeBlock = !inCodeBlock
-			continue
-		}
+	return parser.ExtractHeadings(content)
+}
 
-		if inCodeBlock {
-			continue
-		}
+func (m *Model) SetContent(content string, filePath string) {
+	m.headings = ParseHeadings(content)
+	m.filePath = filePath
+	m.cursor = 0
+}
 
-		// Check for heading
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "#") {
-			level := 0
-			for _, ch := range trimmed {
-				if ch == '#' {
-					level++
-				} else {
-					break
-				}
-			}
+// Sync updates the sidebar from the note being edited and the editor's
+// cursor line. The selection follows the cursor unless the sidebar has focus.
+func (m *Model) Sync(content, filePath string, line int) {
+	if filePath != m.filePath {
+		m.cursor = 0
+	}
+	m.headings = ParseHeadings(content)
+	m.filePath = filePath
+	m.current = -1
+	for i, h := range m.headings {
+		if h.Line > line {
+			break
+		}
+		m.current = i
+	}
+	if !m.focused && m.current >= 0 {
+		m.cursor = m.current
+	}
+	m.cursor = max(0, min(m.cursor, len(m.headings)-1))
+}
 
-			if level > 0 && level <= 6 {
-				text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
-				if text != "" {
-					headings = append(headings, Heading{
-						Text:  text,
-						Level: level,
-						Line:  i,
-					})
-				}
-			}
+// SelectLine moves the selection to the heading at line
+func (m *Model) SelectLine(line int) {
+	for i, h := range m.headings {
+		if h.Line == line {
+			m.cursor = i
+			return
 		}
 	}
+}
 
-	return headings
+func (m *Model) SetFocused(focused bool) {
+	m.focused = focused
 }
 
-func (m *Model) SetContent(content string, filePath string) {
-	m.headings = ParseHeadings(content)
-	m.filePath = filePath
-	m.cursor = 0
+func (m Model) Focused() bool {
+	return m.focused
 }
 
 func (m *Model) Show() {
@@ -113,7 +141,7 @@ func (m Model) Active() bool {
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
-	if !m.active {
+	if !m.active && !m.focused {
 		return m, nil
 	}
 
@@ -121,9 +149,35 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc", "q", "ctrl+o":
-			m.Hide()
+			if !m.docked {
+				m.Hide()
+			}
 			return m, func() tea.Msg { return OutlineClosedMsg{} }
 
+		case "<", "H":
+			return m, m.edit(Promote)
+
+		case ">", "L":
+			return m, m.edit(Demote)
+
+		case "K", "alt+up":
+			return m, m.edit(MoveUp)
+
+		case "J", "alt+down":
+			return m, m.edit(MoveDown)
+
+		case "x":
+			return m, m.edit(ExtractLink)
+
+		case "X":
+			return m, m.edit(ExtractEmbed)
+
+		case "u":
+			return m, func() tea.Msg { return EditSectionMsg{Op: Undo} }
+
+		case "U", "ctrl+r":
+			return m, func() tea.Msg { return EditSectionMsg{Op: Redo} }
+
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
@@ -137,7 +191,9 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		case "enter":
 			if m.cursor < len(m.headings) {
 				line := m.headings[m.cursor].Line
-				m.Hide()
+				if !m.docked {
+					m.Hide()
+				}
 				return m, func() tea.Msg { return JumpToLineMsg{Line: line} }
 			}
 
@@ -192,6 +248,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) edit(op EditOp) tea.Cmd {
+	if m.cursor >= len(m.headings) {
+		return nil
+	}
+	line := m.headings[m.cursor].Line
+	return func() tea.Msg { return EditSectionMsg{Line: line, Op: op} }
+}
+
 func (m *Model) jumpToLevel(level int) {
 	// Find next heading of the specified level from current position
 	for i := m.cursor + 1; i < len(m.headings); i++ {
@@ -210,6 +274,9 @@ func (m *Model) jumpToLevel(level int) {
 }
 
 func (m Model) View() string {
+	if m.docked {
+		return m.sidebarView()
+	}
 	if !m.active {
 		return ""
 	}
@@ -221,7 +288,7 @@ func (m Model) View() string {
 	b.WriteString(title + "\n")
 
 	// Stats
-	stats := headerStyle.Render("Enter: jump | 1/2/3: jump to H1/H2/H3")
+	stats := headerStyle.Render("Enter: jump | 1/2/3: jump to H1/H2/H3 | </>: level | J/K: move | x/X: extract | u/U: undo/redo")
 	b.WriteString(stats + "\n\n")
 
 	if len(m.headings) == 0 {
@@ -250,18 +317,7 @@ func (m Model) View() string {
 		// Indent based on level
 		indent := strings.Repeat("  ", heading.Level-1)
 
-		// Style based on level
-		var style lipgloss.Style
-		switch heading.Level {
-		case 1:
-			style = h1Style
-		case 2:
-			style = h2Style
-		case 3:
-			style = h3Style
-		default:
-			style = h4Style
-		}
+		style := levelStyle(heading.Level)
 
 		text := heading.Text
 		maxLen := m.width - len(indent) - 10
@@ -291,6 +347,57 @@ func (m Model) View() string {
 	return containerStyle.Width(m.width).Render(b.String())
 }
 
+// sidebarView lists the headings in the height of the sidebar, marking the
+// section the editor's cursor is in
+func (m Model) sidebarView() string {
+	box := lipgloss.NewStyle().Width(m.width).Height(m.height).MaxHeight(m.height)
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Outline") + "\n")
+
+	if len(m.headings) == 0 {
+		b.WriteString(emptyStyle.Render("No headings"))
+		return box.Render(b.String())
+	}
+
+	maxVisible := max(1, m.height-1)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.headings))
+
+	for i := start; i < end; i++ {
+		heading := m.headings[i]
+		line := ansi.Truncate(strings.Repeat(" ", heading.Level-1)+heading.Text, m.width-2, "…")
+		switch {
+		case i == m.cursor && m.focused:
+			line = selectedStyle.Render("▶ " + line)
+		case i == m.current:
+			line = levelStyle(heading.Level).Inherit(currentStyle).Render("• " + line)
+		default:
+			line = levelStyle(heading.Level).Render("  " + line)
+		}
+		b.WriteString(line)
+		if i < end-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return box.Render(b.String())
+}
+
+func levelStyle(level int) lipgloss.Style {
+	switch level {
+	case 1:
+		return h1Style
+	case 2:
+		return h2Style
+	case 3:
+		return h3Style
+	default:
+		return h4Style
+	}
+}
+
 func itoa(n int) string {
 	if n < 10 {
 		return string(rune('0' + n))
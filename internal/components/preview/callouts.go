@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// foldableCallouts returns the first lines of the callouts that fold and
+// show, in the order they are rendered
+func (m Model) foldableCallouts() []int {
+	lines := strings.Split(m.content, "\n")
+	folds := parser.FindFolds(lines)
+	callouts := parser.FindCallouts(lines)
+	var starts []int
+	for i, c := range callouts {
+		if c.Fold == "" || m.hidden(c.Start, folds) {
+			continue
+		}
+		// Callouts inside closed ones are not rendered
+		shown := true
+		for _, outer := range callouts[:i] {
+			if outer.Depth < c.Depth && outer.End >= c.Start && !m.calloutOpen(outer) {
+				shown = false
+				break
+			}
+		}
+		if shown {
+			starts = append(starts, c.Start)
+		}
+	}
+	return starts
+}
+
+// calloutOpen reports whether a callout shows its content in the preview
+func (m Model) calloutOpen(c parser.Callout) bool {
+	return (c.Fold == "-") == slices.Contains(m.toggled, c.Start)
+}
+
+// findCalloutRows finds the rendered rows of the titles of the callouts
+// that fold by their marks, and takes the marks out
+func (m *Model) findCalloutRows() {
+	m.calloutLines = m.foldableCallouts()
+	m.rendered, m.calloutRows = takeMarks(m.rendered, parser.CalloutMark, " ")
+	if len(m.calloutRows) > len(m.calloutLines) {
+		m.calloutRows = m.calloutRows[:len(m.calloutLines)]
+	}
+}
+
+// calloutAt returns the first line of the callout whose title is at a
+// rendered row
+func (m Model) calloutAt(row int) (int, bool) {
+	for i, r := range m.calloutRows {
+		if r == row {
+			return m.calloutLines[i], true
+		}
+	}
+	return 0, false
+}
+
+// calloutInView returns the first line of the first callout whose title is
+// in view
+func (m Model) calloutInView() (int, bool) {
+	for i, r := range m.calloutRows {
+		if r >= m.viewport.YOffset && r < m.viewport.YOffset+m.viewport.Height {
+			return m.calloutLines[i], true
+		}
+	}
+	return 0, false
+}
+
+// toggleCallout opens or closes the callout starting at line, for as long
+// as the note is shown. The note itself keeps its + or -.
+func (m *Model) toggleCallout(line int) {
+	if i := slices.Index(m.toggled, line); i >= 0 {
+		m.toggled = slices.Delete(m.toggled, i, i+1)
+	} else {
+		m.toggled = append(m.toggled, line)
+	}
+	offset := m.viewport.YOffset
+	m.renderContent()
+	m.viewport.SetYOffset(offset)
+}
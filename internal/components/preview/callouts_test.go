@@ -0,0 +1,68 @@
+package preview
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+func TestCalloutRows(t *testing.T) {
+	content := strings.Join([]string{
+		"> [!note]+ First",
+		"> a body line ending in an arrow " + parser.CalloutOpen,
+		"",
+		"> [!tip] Not foldable " + parser.CalloutClosed,
+		"",
+		"> [!warning]- Second",
+		"> hidden",
+		"",
+		"> [!info]+ Third",
+		"> shown " + parser.CalloutMark,
+	}, "\n")
+
+	m := New()
+	m.SetSize(80, 60)
+	m.SetContent(content, "a.md")
+
+	if want := []int{0, 5, 8}; !slices.Equal(m.calloutLines, want) {
+		t.Fatalf("calloutLines = %v, want %v", m.calloutLines, want)
+	}
+	if len(m.calloutRows) != len(m.calloutLines) {
+		t.Fatalf("calloutRows = %v, want one row per callout", m.calloutRows)
+	}
+	if strings.Contains(m.rendered, parser.CalloutMark) {
+		t.Error("the rendered note still has callout marks")
+	}
+
+	rows := strings.Split(ansi.Strip(m.rendered), "\n")
+	for i, title := range []string{"First", "Second", "Third"} {
+		if row := rows[m.calloutRows[i]]; !strings.Contains(row, title) {
+			t.Errorf("callout row %d is %q, want the title %q", m.calloutRows[i], row, title)
+		}
+		if line, ok := m.calloutAt(m.calloutRows[i]); !ok || line != m.calloutLines[i] {
+			t.Errorf("calloutAt(%d) = %d, %v, want %d", m.calloutRows[i], line, ok, m.calloutLines[i])
+		}
+	}
+
+	// Toggling the third callout leaves the second one closed
+	m.toggleCallout(8)
+	if rendered := ansi.Strip(m.rendered); strings.Contains(rendered, "shown") || strings.Contains(rendered, "hidden") {
+		t.Errorf("after toggling the third callout:\n%s", rendered)
+	}
+}
+
+func TestCalloutTitleWidth(t *testing.T) {
+	m := New()
+	m.SetSize(80, 40)
+	m.SetContent("> [!note]- Title\n> body", "a.md")
+
+	rows := strings.Split(ansi.Strip(m.rendered), "\n")
+	title := rows[m.calloutRows[0]]
+	border := rows[m.calloutRows[0]-1]
+	if ansi.StringWidth(title) != ansi.StringWidth(border) {
+		t.Errorf("title row %q is not as wide as the box %q", title, border)
+	}
+}
@@ -0,0 +1,75 @@
+package preview
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// EmbedOpenMsg asks to open the source of an embed at the line it starts
+type EmbedOpenMsg struct {
+	Path string
+	Line int
+}
+
+// expandEmbeds replaces ![[note]], ![[note#Heading]] and ![[note#^id]] with
+// what they embed, remembering where each came from
+func (m *Model) expandEmbeds(content string) string {
+	m.embeds = nil
+	if m.vault == nil {
+		return content
+	}
+	content, m.embeds = m.vault.ExpandNoteEmbeds(m.filePath, content, m.maxEmbedDepth)
+	return content
+}
+
+// findEmbedRows finds the rendered rows of the embeds' headers by their
+// marks, and takes the marks out
+func (m *Model) findEmbedRows() {
+	m.rendered, m.embedRows = takeMarks(m.rendered, vault.EmbedMark, "")
+	if len(m.embedRows) > len(m.embeds) {
+		m.embedRows = m.embedRows[:len(m.embeds)]
+	}
+}
+
+// takeMarks replaces mark in rendered text with repl, returning the rows it
+// was on
+func takeMarks(rendered, mark, repl string) (string, []int) {
+	if !strings.Contains(rendered, mark) {
+		return rendered, nil
+	}
+	var rows []int
+	lines := strings.Split(rendered, "\n")
+	for r, line := range lines {
+		if strings.Contains(line, mark) {
+			rows = append(rows, r)
+			lines[r] = strings.ReplaceAll(line, mark, repl)
+		}
+	}
+	return strings.Join(lines, "\n"), rows
+}
+
+// embedAt returns the embed whose header is at a rendered row
+func (m Model) embedAt(row int) (vault.Embed, bool) {
+	for i, r := range m.embedRows {
+		if r == row {
+			return m.embeds[i], true
+		}
+	}
+	return vault.Embed{}, false
+}
+
+// embedInView returns the first embed whose header is in view
+func (m Model) embedInView() (vault.Embed, bool) {
+	for i, r := range m.embedRows {
+		if r >= m.viewport.YOffset && r < m.viewport.YOffset+m.viewport.Height {
+			return m.embeds[i], true
+		}
+	}
+	return vault.Embed{}, false
+}
+
+func openEmbed(e vault.Embed) tea.Cmd {
+	return func() tea.Msg { return EmbedOpenMsg{Path: e.Path, Line: e.Line} }
+}
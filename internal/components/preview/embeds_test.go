@@ -0,0 +1,30 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestEmbedRows(t *testing.T) {
+	v := vaulttest.New(t, map[string]string{"b.md": "embedded"})
+
+	m := New()
+	m.SetVault(v)
+	m.SetSize(80, 40)
+	m.SetContent("📎 a paperclip in the note\n\n![[b]]", "a.md")
+
+	rows := strings.Split(ansi.Strip(m.rendered), "\n")
+	if len(m.embedRows) != 1 {
+		t.Fatalf("embedRows = %v, want one row", m.embedRows)
+	}
+	if row := rows[m.embedRows[0]]; !strings.Contains(row, "📎 b") {
+		t.Errorf("embed row %d is %q, want b's header", m.embedRows[0], row)
+	}
+	if strings.Contains(m.rendered, vault.EmbedMark) {
+		t.Error("the rendered note still has embed marks")
+	}
+}
@@ -0,0 +1,118 @@
+package preview
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// FoldsChangedMsg is sent when folds are opened or closed in the preview.
+// Folds are the first lines of the folds now closed, as in the editor.
+type FoldsChangedMsg struct {
+	Path  string
+	Folds []int
+}
+
+// Folds returns the first lines of the closed folds
+func (m Model) Folds() []int {
+	return slices.Clone(m.folds)
+}
+
+// SetFolds collapses the folds starting at the given lines of the note
+func (m *Model) SetFolds(starts []int) {
+	starts = slices.Clone(starts)
+	slices.Sort(starts)
+	if slices.Equal(starts, m.folds) {
+		return
+	}
+	m.folds = starts
+	if m.content != "" {
+		offset := m.viewport.YOffset
+		m.renderContent()
+		m.viewport.SetYOffset(offset)
+	}
+}
+
+// foldAction runs a fold action of the preview's keymap. Closing or opening
+// all folds works on the whole note, the others on the section of the
+// heading at the top of the view.
+func (m *Model) foldAction(action string) tea.Cmd {
+	lines := strings.Split(m.content, "\n")
+	folds := parser.FindFolds(lines)
+	var starts []int
+	switch action {
+	case "fold_close_all":
+		for _, f := range folds {
+			if len(starts) == 0 || starts[len(starts)-1] != f.Start {
+				starts = append(starts, f.Start)
+			}
+		}
+	case "fold_open_all":
+	case "fold_toggle", "fold_close", "fold_open":
+		heading, row, ok := m.headingAtTop(lines, folds)
+		if !ok {
+			return nil
+		}
+		closed := slices.Contains(m.folds, heading)
+		starts = slices.DeleteFunc(slices.Clone(m.folds), func(s int) bool { return s == heading })
+		if action == "fold_close" || (action == "fold_toggle" && !closed) {
+			starts = append(starts, heading)
+		}
+		m.SetFolds(starts)
+		m.viewport.SetYOffset(row)
+		return m.foldsChanged()
+	default:
+		return nil
+	}
+	m.SetFolds(starts)
+	return m.foldsChanged()
+}
+
+func (m Model) foldsChanged() tea.Cmd {
+	msg := FoldsChangedMsg{Path: m.filePath, Folds: m.Folds()}
+	return func() tea.Msg { return msg }
+}
+
+// headingAtTop finds the last heading shown at or above the top of the
+// view, returning its line in the note and in the rendered text. Headings
+// are looked for in order in the rendered text, skipping those folded away.
+func (m Model) headingAtTop(lines []string, folds []parser.Fold) (line, row int, ok bool) {
+	rendered := strings.Split(ansi.Strip(m.rendered), "\n")
+	pos := 0
+	for _, f := range folds {
+		text := strings.TrimSpace(lines[f.Start])
+		if !strings.HasPrefix(text, "#") || m.hidden(f.Start, folds) {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimLeft(text, "#"))
+		for r := pos; r < len(rendered); r++ {
+			if !strings.HasSuffix(strings.TrimSuffix(strings.TrimSpace(rendered[r]), " ⋯"), text) {
+				continue
+			}
+			if r > m.viewport.YOffset {
+				// With none above, the first heading in view will do
+				if !ok && r < m.viewport.YOffset+m.viewport.Height {
+					return f.Start, r, true
+				}
+				return line, row, ok
+			}
+			line, row, ok = f.Start, r, true
+			pos = r + 1
+			break
+		}
+	}
+	return line, row, ok
+}
+
+// hidden reports whether line is inside a closed fold
+func (m Model) hidden(line int, folds []parser.Fold) bool {
+	for _, f := range folds {
+		if f.Start < line && line <= f.End && slices.Contains(m.folds, f.Start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package preview
+
+import "github.com/takahashinaoki/obsidiantui/internal/keymap"
+
+// keys are the bindings shared by every preview
+var keys = DefaultKeymap()
+
+// DefaultKeymap returns the preview bindings before any configuration
+func DefaultKeymap() *keymap.Map {
+	km := keymap.NewMap("preview")
+	bind := func(action, help string, seqs ...string) {
+		parsed := make([]keymap.Sequence, len(seqs))
+		for i, s := range seqs {
+			parsed[i] = keymap.MustParse(s)
+		}
+		km.Bind(action, help, parsed...)
+	}
+
+	bind("fold_toggle", "toggle fold", "z a")
+	bind("fold_close", "close fold", "z c")
+	bind("fold_open", "open fold", "z o")
+	bind("fold_close_all", "close all folds", "z M")
+	bind("fold_open_all", "open all folds", "z R")
+	bind("callout_toggle", "fold callout", "c")
+	return km
+}
+
+// SetKeymap installs the bindings used by every preview
+func SetKeymap(km *keymap.Map) {
+	keys = km
+}
+
+// Keymap returns the bindings in use
+func Keymap() *keymap.Map {
+	return keys
+}
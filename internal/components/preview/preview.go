@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
 	"github.com/takahashinaoki/obsidiantui/internal/parser"
 	"github.com/takahashinaoki/obsidiantui/internal/vault"
 )
@@ -53,6 +54,13 @@ type Model struct {
 	selectedLink int
 	vault        *vault.Vault
 	maxEmbedDepth int
+	folds        []int // first lines of the closed folds
+	keySeq       keymap.Resolver // start of a multi-key command such as z a
+	embeds       []vault.Embed
+	embedRows    []int // rendered rows of the embeds' headers
+	toggled      []int // callouts folded the other way from the note, by first line
+	calloutLines []int // first lines of the callouts that fold, as rendered
+	calloutRows  []int // rendered rows of their titles
 }
 
 type KeyMap struct {
@@ -112,6 +120,19 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		_, action, pending := m.keySeq.Feed(msg.String(), keys)
+		if pending {
+			return m, nil
+		}
+		switch action {
+		case "fold_toggle", "fold_close", "fold_open", "fold_close_all", "fold_open_all":
+			return m, m.foldAction(action)
+		case "callout_toggle":
+			if line, ok := m.calloutInView(); ok {
+				m.toggleCallout(line)
+			}
+			return m, nil
+		}
 		switch {
 		case key.Matches(msg, DefaultKeyMap.Up):
 			m.viewport.LineUp(1)
@@ -151,6 +172,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 					return LinkFollowMsg{Target: link.Target}
 				}
 			}
+			// With no link selected, open the first embed in view
+			if e, ok := m.embedInView(); ok {
+				return m, openEmbed(e)
+			}
 			return m, nil
 		}
 
@@ -163,6 +188,19 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		case tea.MouseButtonWheelDown:
 			m.viewport.LineDown(3)
 			return m, nil
+		case tea.MouseButtonLeft:
+			// Y=0 is the header and Y=1 the border, so clicks on an
+			// embed's header open its source and those on a callout's
+			// title fold it
+			if msg.Action == tea.MouseActionPress {
+				if e, ok := m.embedAt(m.viewport.YOffset + msg.Y - 2); ok {
+					return m, openEmbed(e)
+				}
+				if line, ok := m.calloutAt(m.viewport.YOffset + msg.Y - 2); ok {
+					m.toggleCallout(line)
+					return m, nil
+				}
+			}
 		}
 		// Let viewport handle other mouse events
 		m.viewport, cmd = m.viewport.Update(msg)
@@ -203,6 +241,9 @@ func (m Model) View() string {
 }
 
 func (m *Model) SetContent(content string, filePath string) {
+	if filePath != m.filePath {
+		m.toggled = nil
+	}
 	m.content = content
 	m.filePath = filePath
 	m.links = parser.ExtractWikiLinks(content)
@@ -218,13 +259,15 @@ func (m *Model) renderContent() {
 		width = 40
 	}
 
-	// Expand embedded notes before rendering
-	contentWithEmbeds := m.expandEmbeds(m.content, 0, make(map[string]bool))
+	// Expand embedded notes before rendering, and hide block ids as Obsidian does
+	content := parser.ToggleCallouts(m.content, m.toggled)
+	contentWithEmbeds := parser.StripBlockIDs(m.expandEmbeds(parser.CollapseFolds(content, m.folds)))
 
 	var err error
 	m.renderer, err = parser.NewMarkdownRenderer(width)
 	if err != nil {
 		m.rendered = contentWithEmbeds
+		m.findEmbedRows()
 		m.viewport.SetContent(m.rendered)
 		return
 	}
@@ -235,6 +278,8 @@ func (m *Model) renderContent() {
 	} else {
 		m.rendered = rendered
 	}
+	m.findEmbedRows()
+	m.findCalloutRows()
 	m.viewport.SetContent(m.rendered)
 }
 
@@ -265,6 +310,11 @@ func (m Model) FilePath() string {
 	return m.filePath
 }
 
+// SetFilePath changes the note's path after it was renamed
+func (m *Model) SetFilePath(filePath string) {
+	m.filePath = filePath
+}
+
 func (m *Model) GetSelectedLink() *parser.Link {
 	if m.selectedLink >= 0 && m.selectedLink < len(m.links) {
 		return &m.links[m.selectedLink]
@@ -283,70 +333,10 @@ func (m *Model) ScrollToLine(line int) {
 	}
 }
 
-// expandEmbeds replaces ![[note]] with the embedded note content
-func (m *Model) expandEmbeds(content string, depth int, seen map[string]bool) string {
-	if m.vault == nil || depth >= m.maxEmbedDepth {
-		return content
-	}
-
-	embeds := parser.ExtractEmbedLinks(content)
-	if len(embeds) == 0 {
-		return content
-	}
-
-	// Process embeds from end to start to preserve positions
-	result := content
-	for i := len(embeds) - 1; i >= 0; i-- {
-		embed := embeds[i]
-
-		// Try to resolve the embed target
-		target := embed.Target
-		resolvedPath := m.vault.FindFile(target + ".md")
-		if resolvedPath == "" {
-			resolvedPath = m.vault.FindFile(target)
-		}
-
-		var replacement string
-		if resolvedPath == "" {
-			// Broken embed
-			replacement = fmt.Sprintf("\n> **⚠ Embed not found: %s**\n", target)
-		} else if seen[resolvedPath] {
-			// Circular reference
-			replacement = fmt.Sprintf("\n> **⚠ Circular embed: %s**\n", target)
-		} else {
-			// Read the embedded file
-			file, ok := m.vault.Files[resolvedPath]
-			if !ok || file.Content == "" {
-				replacement = fmt.Sprintf("\n> **⚠ Cannot read: %s**\n", target)
-			} else {
-				// Mark as seen to prevent cycles
-				seen[resolvedPath] = true
-
-				// Recursively expand embeds in the embedded content
-				embeddedContent := m.expandEmbeds(file.Content, depth+1, seen)
-
-				// Format with visual indicator
-				displayName := target
-				if embed.AltText != "" {
-					displayName = embed.AltText
-				}
-
-				// Create a blockquote-style embed
-				lines := strings.Split(embeddedContent, "\n")
-				var quotedLines []string
-				quotedLines = append(quotedLines, fmt.Sprintf("\n---\n**📎 %s**\n", displayName))
-				for _, line := range lines {
-					quotedLines = append(quotedLines, "> "+line)
-				}
-				quotedLines = append(quotedLines, "\n---\n")
-
-				replacement = strings.Join(quotedLines, "\n")
-			}
-		}
-
-		// Replace the embed with the replacement text
-		result = result[:embed.StartPos] + replacement + result[embed.EndPos:]
-	}
+func (m Model) ScrollOffset() int {
+	return m.viewport.YOffset
+}
 
-	return result
+func (m *Model) SetScrollOffset(offset int) {
+	m.viewport.SetYOffset(offset)
 }
@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("166")).Padding(0, 1)
+	messageStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	keyStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	labelStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("166")).Padding(1)
+)
+
+// Choice is one answer to a prompt, picked by its key or with Enter
+type Choice struct {
+	Key   string
+	Label string
+	ID    string
+}
+
+// ChoiceMsg is sent when a choice is picked. Context is whatever was passed
+// to Show, so callers can tell prompts apart.
+type ChoiceMsg struct {
+	ID      string
+	Context string
+}
+
+// PromptClosedMsg is sent when the prompt is dismissed without a choice
+type PromptClosedMsg struct {
+	Context string
+}
+
+// Model asks the user to pick one of a few choices
+type Model struct {
+	title   string
+	message string
+	choices []Choice
+	context string
+	cursor  int
+	width   int
+	active  bool
+}
+
+func New() Model {
+	return Model{}
+}
+
+// Show opens the prompt; context is echoed back in ChoiceMsg
+func (m *Model) Show(title, message, context string, choices []Choice) {
+	m.active = true
+	m.title = title
+	m.message = message
+	m.context = context
+	m.choices = choices
+	m.cursor = 0
+}
+
+func (m *Model) Hide() {
+	m.active = false
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+// Context returns the context of the open prompt
+func (m Model) Context() string {
+	return m.context
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		m.Hide()
+		context := m.context
+		return m, func() tea.Msg { return PromptClosedMsg{Context: context} }
+	case "up", "k", "left", "shift+tab":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "j", "right", "tab":
+		if m.cursor < len(m.choices)-1 {
+			m.cursor++
+		}
+		return m, nil
+	case "enter":
+		if m.cursor < len(m.choices) {
+			return m.choose(m.choices[m.cursor])
+		}
+		return m, nil
+	}
+
+	for _, c := range m.choices {
+		if c.Key != "" && keyMsg.String() == c.Key {
+			return m.choose(c)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) choose(c Choice) (Model, tea.Cmd) {
+	m.Hide()
+	msg := ChoiceMsg{ID: c.ID, Context: m.context}
+	return m, func() tea.Msg { return msg }
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title) + "\n\n")
+	if m.message != "" {
+		b.WriteString(messageStyle.Width(m.width-4).Render(m.message) + "\n\n")
+	}
+
+	for i, c := range m.choices {
+		line := "  " + keyStyle.Render(c.Key) + "  " + labelStyle.Render(c.Label)
+		if i == m.cursor {
+			line = selectedStyle.Render("▶ " + c.Key + "  " + c.Label)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + hintStyle.Render("Enter: choose | Esc: cancel"))
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+}
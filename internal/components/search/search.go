@@ -47,6 +47,7 @@ type KeyMap struct {
 	Down   key.Binding
 	Enter  key.Binding
 	Cancel key.Binding
+	Save   key.Binding
 }
 
 var DefaultKeyMap = KeyMap{
@@ -54,6 +55,7 @@ var DefaultKeyMap = KeyMap{
 	Down:   key.NewBinding(key.WithKeys("down", "ctrl+n"), key.WithHelp("down", "down")),
 	Enter:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
 	Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	Save:   key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("C-s", "bookmark search")),
 }
 
 type FileSelectedMsg struct {
@@ -62,6 +64,11 @@ type FileSelectedMsg struct {
 
 type SearchClosedMsg struct{}
 
+// SaveSearchMsg asks for the current query to be bookmarked
+type SaveSearchMsg struct {
+	Query string
+}
+
 func New(v *vault.Vault) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Search files..."
@@ -105,6 +112,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case key.Matches(msg, DefaultKeyMap.Save):
+			if query := strings.TrimSpace(m.textinput.Value()); query != "" {
+				return m, func() tea.Msg {
+					return SaveSearchMsg{Query: query}
+				}
+			}
+			return m, nil
+
 		case key.Matches(msg, DefaultKeyMap.Enter):
 			if len(m.results) > 0 && m.cursor < len(m.results) {
 				selected := m.results[m.cursor]
@@ -119,17 +134,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		var cmd tea.Cmd
 		m.textinput, cmd = m.textinput.Update(msg)
-
-		query := m.textinput.Value()
-		if query != "" {
-			m.results = m.vault.Search(query)
-			if len(m.results) > 20 {
-				m.results = m.results[:20]
-			}
-		} else {
-			m.results = nil
-		}
-		m.cursor = 0
+		m.runQuery()
 
 		return m, cmd
 	}
@@ -137,6 +142,19 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *Model) runQuery() {
+	query := m.textinput.Value()
+	if query != "" {
+		m.results = m.vault.Search(query)
+		if len(m.results) > 20 {
+			m.results = m.results[:20]
+		}
+	} else {
+		m.results = nil
+	}
+	m.cursor = 0
+}
+
 func (m Model) View() string {
 	if !m.active {
 		return ""
@@ -195,6 +213,16 @@ func (m *Model) Activate() tea.Cmd {
 	return m.textinput.Focus()
 }
 
+// ActivateWithQuery opens the search with query already run, e.g. from a
+// bookmarked search
+func (m *Model) ActivateWithQuery(query string) tea.Cmd {
+	cmd := m.Activate()
+	m.textinput.SetValue(query)
+	m.textinput.CursorEnd()
+	m.runQuery()
+	return cmd
+}
+
 func (m *Model) Deactivate() {
 	m.active = false
 	m.textinput.Blur()
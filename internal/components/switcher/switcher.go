@@ -0,0 +1,415 @@
+package switcher
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/fuzzy"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Padding(0, 1)
+	inputStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	matchStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	detailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	createStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("57")).Padding(1)
+)
+
+const (
+	maxResults = 50
+
+	// recencyBoost is added to the score of the most recently opened note,
+	// and one less for each older one
+	recencyBoost = 20
+)
+
+type kind int
+
+const (
+	kindNote kind = iota
+	kindAlias
+	kindHeading
+	kindBlock
+	kindCreate
+)
+
+// result is one row of the list. text is what was matched and is shown with
+// the matched positions highlighted.
+type result struct {
+	kind      kind
+	path      string
+	text      string
+	positions []int
+	line      int
+	score     int
+}
+
+// NoteSelectedMsg is sent when a note is picked. Line is the heading's or
+// the block's line, or -1 to open the note where it was left.
+type NoteSelectedMsg struct {
+	Path string
+	Line int
+}
+
+// CreateNoteMsg is sent to create a note named after the query
+type CreateNoteMsg struct {
+	Name string
+}
+
+// SwitcherClosedMsg is sent when the switcher is closed without a choice
+type SwitcherClosedMsg struct{}
+
+// Model is a fuzzy finder over note paths, aliases and headings, like
+// Obsidian's quick switcher
+type Model struct {
+	textinput textinput.Model
+	notes     []vault.File
+	recent    map[string]int // path -> rank, 0 for the most recent
+	results   []result
+	cursor    int
+	width     int
+	height    int
+	active    bool
+}
+
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "Find or create a note... (note#heading for headings)"
+	ti.CharLimit = 256
+	ti.Width = 40
+
+	return Model{textinput: ti}
+}
+
+// Show opens the switcher over notes; recent is most recently opened first
+func (m *Model) Show(notes []vault.File, recent []string) tea.Cmd {
+	m.active = true
+	m.notes = notes
+	m.recent = make(map[string]int, len(recent))
+	for i, p := range recent {
+		if _, ok := m.recent[p]; !ok {
+			m.recent[p] = i
+		}
+	}
+	m.textinput.SetValue("")
+	m.search()
+	return m.textinput.Focus()
+}
+
+func (m *Model) Hide() {
+	m.active = false
+	m.textinput.Blur()
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func noteName(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+func (m Model) boost(path string) int {
+	if rank, ok := m.recent[path]; ok {
+		return max(0, recencyBoost-rank)
+	}
+	return 0
+}
+
+// search refreshes the results for the current query
+func (m *Model) search() {
+	query := strings.TrimSpace(m.textinput.Value())
+	m.results = m.results[:0:0]
+	m.cursor = 0
+
+	if query == "" {
+		m.recentResults()
+		return
+	}
+
+	if notePart, headingPart, ok := strings.Cut(query, "#"); ok {
+		if blockPart, isBlock := strings.CutPrefix(strings.TrimSpace(headingPart), "^"); isBlock {
+			m.blockResults(notePart, blockPart)
+		} else {
+			m.headingResults(notePart, headingPart)
+		}
+	} else {
+		m.noteResults(query)
+	}
+
+	sort.SliceStable(m.results, func(i, j int) bool {
+		a, b := m.results[i], m.results[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return len(a.text) < len(b.text)
+	})
+	if len(m.results) > maxResults {
+		m.results = m.results[:maxResults]
+	}
+
+	if !strings.Contains(query, "#") && !m.exists(query) {
+		m.results = append(m.results, result{kind: kindCreate, text: query})
+	}
+}
+
+// recentResults lists recent notes first, then the rest by path
+func (m *Model) recentResults() {
+	for _, n := range m.notes {
+		m.results = append(m.results, result{kind: kindNote, path: n.RelativePath, text: noteName(n.RelativePath), line: -1})
+	}
+	sort.SliceStable(m.results, func(i, j int) bool {
+		ri, iok := m.recent[m.results[i].path]
+		rj, jok := m.recent[m.results[j].path]
+		if iok != jok {
+			return iok
+		}
+		return iok && ri < rj
+	})
+	if len(m.results) > maxResults {
+		m.results = m.results[:maxResults]
+	}
+}
+
+// noteResults matches the query against each note's path and aliases,
+// keeping the best match per note
+func (m *Model) noteResults(query string) {
+	for _, n := range m.notes {
+		// Scores go negative for matches with long gaps, so found says
+		// whether there was one
+		var best result
+		found := false
+		name := noteName(n.RelativePath)
+		if score, pos, ok := fuzzy.Match(query, name); ok {
+			best = result{kind: kindNote, path: n.RelativePath, text: name, positions: pos, line: -1, score: score}
+			found = true
+		}
+		for _, alias := range n.Aliases {
+			if score, pos, ok := fuzzy.Match(query, alias); ok && (!found || score > best.score) {
+				best = result{kind: kindAlias, path: n.RelativePath, text: alias, positions: pos, line: -1, score: score}
+				found = true
+			}
+		}
+		if found {
+			best.score += m.boost(n.RelativePath)
+			m.results = append(m.results, best)
+		}
+	}
+}
+
+// headingResults matches headings of the notes matching notePart
+func (m *Model) headingResults(notePart, headingPart string) {
+	notePart = strings.TrimSpace(notePart)
+	headingPart = strings.TrimSpace(headingPart)
+	for _, n := range m.notes {
+		noteScore := 0
+		if notePart != "" {
+			score, _, ok := fuzzy.Match(notePart, noteName(n.RelativePath))
+			if !ok {
+				continue
+			}
+			noteScore = score
+		}
+		for _, h := range n.Headings {
+			score, pos, ok := fuzzy.Match(headingPart, h.Text)
+			if !ok {
+				continue
+			}
+			m.results = append(m.results, result{
+				kind:      kindHeading,
+				path:      n.RelativePath,
+				text:      h.Text,
+				positions: pos,
+				line:      h.Line,
+				score:     score + noteScore + m.boost(n.RelativePath),
+			})
+		}
+	}
+}
+
+// blockResults matches the ids and text of the blocks marked with ^id in the
+// notes matching notePart
+func (m *Model) blockResults(notePart, blockPart string) {
+	notePart = strings.TrimSpace(notePart)
+	for _, n := range m.notes {
+		noteScore := 0
+		if notePart != "" {
+			score, _, ok := fuzzy.Match(notePart, noteName(n.RelativePath))
+			if !ok {
+				continue
+			}
+			noteScore = score
+		}
+		for _, b := range n.Blocks {
+			text := "^" + b.ID + " " + b.Text
+			score, pos, ok := fuzzy.Match(blockPart, text)
+			if !ok {
+				continue
+			}
+			m.results = append(m.results, result{
+				kind:      kindBlock,
+				path:      n.RelativePath,
+				text:      text,
+				positions: pos,
+				line:      b.Line,
+				score:     score + noteScore + m.boost(n.RelativePath),
+			})
+		}
+	}
+}
+
+// exists reports whether query already names a note
+func (m Model) exists(query string) bool {
+	q := strings.ToLower(noteName(query))
+	for _, n := range m.notes {
+		if strings.ToLower(noteName(n.RelativePath)) == q {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.Hide()
+			return m, func() tea.Msg { return SwitcherClosedMsg{} }
+
+		case "up", "ctrl+p":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+
+		case "down", "ctrl+n":
+			if m.cursor < len(m.results)-1 {
+				m.cursor++
+			}
+			return m, nil
+
+		case "enter":
+			if m.cursor >= len(m.results) {
+				return m, nil
+			}
+			r := m.results[m.cursor]
+			m.Hide()
+			if r.kind == kindCreate {
+				return m, func() tea.Msg { return CreateNoteMsg{Name: r.text} }
+			}
+			return m, func() tea.Msg { return NoteSelectedMsg{Path: r.path, Line: r.line} }
+		}
+	}
+
+	var cmd tea.Cmd
+	prev := m.textinput.Value()
+	m.textinput, cmd = m.textinput.Update(msg)
+	if m.textinput.Value() != prev {
+		m.search()
+	}
+
+	return m, cmd
+}
+
+// highlight renders text with the runes at positions emphasised
+func highlight(text string, positions []int, base lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(text)
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var b strings.Builder
+	var chunk []rune
+	chunkMatched := false
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		if chunkMatched {
+			b.WriteString(matchStyle.Inherit(base).Render(string(chunk)))
+		} else {
+			b.WriteString(base.Render(string(chunk)))
+		}
+		chunk = chunk[:0]
+	}
+	for i, r := range []rune(text) {
+		if matched[i] != chunkMatched {
+			flush()
+			chunkMatched = matched[i]
+		}
+		chunk = append(chunk, r)
+	}
+	flush()
+	return b.String()
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Quick Switcher") + "\n")
+	b.WriteString(inputStyle.Render(m.textinput.View()) + "\n\n")
+
+	maxVisible := max(5, m.height-10)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.results))
+
+	for i := start; i < end; i++ {
+		r := m.results[i]
+
+		style := normalStyle
+		prefix := "  "
+		if i == m.cursor {
+			style = selectedStyle
+			prefix = "▶ "
+		}
+
+		line := style.Render(prefix)
+		switch r.kind {
+		case kindNote:
+			line += highlight(r.text, r.positions, style)
+		case kindAlias:
+			line += highlight(r.text, r.positions, style) + detailStyle.Render(" → "+noteName(r.path))
+		case kindHeading, kindBlock:
+			line += highlight(r.text, r.positions, style) + detailStyle.Render(" in "+noteName(r.path))
+		case kindCreate:
+			line += createStyle.Inherit(style).Render("+ Create note: " + r.text)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(m.results) == 0 {
+		b.WriteString(hintStyle.Render("  No notes found"))
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.textinput.Width = max(10, width-10)
+}
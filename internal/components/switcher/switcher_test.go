@@ -0,0 +1,40 @@
+package switcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+func TestSearchKeepsLongGapMatches(t *testing.T) {
+	long := "a" + strings.Repeat("x", 60) + "z"
+	m := New()
+	m.Show([]vault.File{
+		{RelativePath: long + ".md"},
+		{RelativePath: "other.md", Aliases: []string{"b" + strings.Repeat("y", 60) + "q"}},
+		{RelativePath: "nomatch.md"},
+	}, nil)
+
+	tests := []struct {
+		query string
+		want  string // path of the only note result
+		kind  kind
+	}{
+		{"az", long + ".md", kindNote},
+		{"bq", "other.md", kindAlias},
+	}
+	for _, tt := range tests {
+		m.textinput.SetValue(tt.query)
+		m.search()
+		var got []result
+		for _, r := range m.results {
+			if r.kind != kindCreate {
+				got = append(got, r)
+			}
+		}
+		if len(got) != 1 || got[0].path != tt.want || got[0].kind != tt.kind {
+			t.Errorf("search(%q) = %+v, want %s", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package vaultpicker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Padding(0, 1)
+	inputStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1)
+	selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+	normalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	pathStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	currentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	containerStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("57")).Padding(1)
+)
+
+// Vault is a known vault shown in the picker
+type Vault struct {
+	Name string
+	Path string
+}
+
+// VaultSelectedMsg is sent when a vault is picked, or a folder path is typed
+// and confirmed
+type VaultSelectedMsg struct {
+	Path string
+}
+
+// VaultPickerClosedMsg is sent when the picker is closed without a choice
+type VaultPickerClosedMsg struct{}
+
+// Model lists known vaults, filtered by name or path
+type Model struct {
+	textinput textinput.Model
+	vaults    []Vault
+	filtered  []Vault
+	current   string
+	cursor    int
+	width     int
+	height    int
+	active    bool
+}
+
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "Filter vaults or type a folder path..."
+	ti.CharLimit = 256
+	ti.Width = 40
+
+	return Model{textinput: ti}
+}
+
+// Show opens the picker with the cursor on the current vault
+func (m *Model) Show(vaults []Vault, current string) tea.Cmd {
+	m.active = true
+	m.vaults = vaults
+	m.current = current
+	m.cursor = 0
+	m.textinput.SetValue("")
+	m.filter()
+	for i, v := range m.filtered {
+		if v.Path == current {
+			m.cursor = i
+		}
+	}
+	return m.textinput.Focus()
+}
+
+func (m *Model) Hide() {
+	m.active = false
+	m.textinput.Blur()
+}
+
+func (m Model) Active() bool {
+	return m.active
+}
+
+func (m *Model) filter() {
+	query := strings.ToLower(m.textinput.Value())
+	m.filtered = nil
+	for _, v := range m.vaults {
+		if query == "" ||
+			strings.Contains(strings.ToLower(v.Name), query) ||
+			strings.Contains(strings.ToLower(v.Path), query) {
+			m.filtered = append(m.filtered, v)
+		}
+	}
+	m.cursor = max(0, min(m.cursor, len(m.filtered)-1))
+}
+
+// typedPath returns the input as a folder path when it looks like a path and
+// names an existing directory, expanding a leading ~
+func (m Model) typedPath() string {
+	path := strings.TrimSpace(m.textinput.Value())
+	if !strings.HasPrefix(path, "~") && !strings.ContainsRune(path, filepath.Separator) {
+		return ""
+	}
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.Hide()
+			return m, func() tea.Msg { return VaultPickerClosedMsg{} }
+
+		case "up", "ctrl+p":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+
+		case "down", "ctrl+n":
+			if m.cursor < len(m.filtered)-1 {
+				m.cursor++
+			}
+			return m, nil
+
+		case "enter":
+			path := m.typedPath()
+			if path == "" && m.cursor < len(m.filtered) {
+				path = m.filtered[m.cursor].Path
+			}
+			if path == "" {
+				return m, nil
+			}
+			m.Hide()
+			return m, func() tea.Msg { return VaultSelectedMsg{Path: path} }
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textinput, cmd = m.textinput.Update(msg)
+	m.filter()
+
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Switch Vault") + "\n")
+	b.WriteString(inputStyle.Render(m.textinput.View()) + "\n\n")
+
+	maxVisible := max(3, (m.height-10)/2)
+	start := 0
+	if m.cursor >= maxVisible {
+		start = m.cursor - maxVisible + 1
+	}
+	end := min(start+maxVisible, len(m.filtered))
+
+	for i := start; i < end; i++ {
+		v := m.filtered[i]
+
+		name := "  " + v.Name
+		style := normalStyle
+		if i == m.cursor {
+			name = "▶ " + v.Name
+			style = selectedStyle
+		}
+		line := style.Render(name)
+		if v.Path == m.current {
+			line += currentStyle.Render(" (open)")
+		}
+		b.WriteString(line + "\n")
+		b.WriteString("    " + pathStyle.Render(v.Path) + "\n")
+	}
+
+	if path := m.typedPath(); path != "" {
+		b.WriteString(hintStyle.Render("  Enter: open folder "+path) + "\n")
+	} else if len(m.filtered) == 0 {
+		b.WriteString(hintStyle.Render("  No vaults found") + "\n")
+	}
+
+	return containerStyle.Width(m.width).Render(b.String())
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.textinput.Width = max(10, width-10)
+}
@@ -0,0 +1,283 @@
+// Package diff computes line diffs between versions of a note.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind says whether a diff line is kept, removed or added
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Line is one line of an edit script
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Hunk is a group of nearby changes with surrounding context. Starts are
+// 1-based line numbers in the old and new text.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// SplitLines splits text into lines so that joining them with "\n" gives the
+// original text back
+func SplitLines(s string) []string {
+	return strings.Split(s, "\n")
+}
+
+// Lines returns the shortest edit script turning a into b. It uses the
+// linear-space variant of Myers' algorithm, which splits the texts at the
+// middle snake of the edit graph and diffs the two halves, so memory stays
+// O(N+M) however different the texts are.
+func Lines(a, b []string) []Line {
+	if len(a)+len(b) == 0 {
+		return nil
+	}
+	n := len(a) + len(b) + 4
+	d := &differ{a: a, b: b, vf: make([]int, n), vb: make([]int, n)}
+	d.compare(0, len(a), 0, len(b))
+	return d.edits
+}
+
+type differ struct {
+	a, b   []string
+	vf, vb []int // furthest x on each diagonal, forward and from the end
+	edits  []Line
+}
+
+// compare appends the edit script turning a[a0:a1] into b[b0:b1]
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.edits = append(d.edits, Line{Kind: Equal, Text: d.a[a0]})
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && d.a[a1-1-suffix] == d.b[b1-1-suffix] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	switch {
+	case a0 == a1:
+		for _, t := range d.b[b0:b1] {
+			d.edits = append(d.edits, Line{Kind: Insert, Text: t})
+		}
+	case b0 == b1:
+		for _, t := range d.a[a0:a1] {
+			d.edits = append(d.edits, Line{Kind: Delete, Text: t})
+		}
+	default:
+		// With the common ends gone the halves each need at least one edit
+		// fewer than the whole, so this always gets smaller
+		x, y, u, v := d.middleSnake(a0, a1, b0, b1)
+		d.compare(a0, x, b0, y)
+		for _, t := range d.a[x:u] {
+			d.edits = append(d.edits, Line{Kind: Equal, Text: t})
+		}
+		d.compare(u, a1, v, b1)
+	}
+
+	for _, t := range d.a[a1 : a1+suffix] {
+		d.edits = append(d.edits, Line{Kind: Equal, Text: t})
+	}
+}
+
+// middleSnake finds the diagonal run in the middle of a shortest edit path
+// from (a0, b0) to (a1, b1), running forward and backward paths until they
+// meet. It returns the run's start (x, y) and end (u, v).
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	half := (n + m + 1) / 2
+	off := half + 1
+	vf, vb := d.vf[:2*off+1], d.vb[:2*off+1]
+	vf[off+1], vb[off+1] = 0, 0
+
+	for e := 0; e <= half; e++ {
+		for k := -e; k <= e; k += 2 {
+			var px int
+			if k == -e || (k != e && vf[off+k-1] < vf[off+k+1]) {
+				px = vf[off+k+1]
+			} else {
+				px = vf[off+k-1] + 1
+			}
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && d.a[a0+px] == d.b[b0+py] {
+				px++
+				py++
+			}
+			vf[off+k] = px
+			// The backward paths have made e-1 edits so far
+			if r := delta - k; odd && r >= -(e-1) && r <= e-1 && px+vb[off+r] >= n {
+				return a0 + sx, b0 + sy, a0 + px, b0 + py
+			}
+		}
+		for r := -e; r <= e; r += 2 {
+			var px int
+			if r == -e || (r != e && vb[off+r-1] < vb[off+r+1]) {
+				px = vb[off+r+1]
+			} else {
+				px = vb[off+r-1] + 1
+			}
+			py := px - r
+			sx, sy := px, py
+			for px < n && py < m && d.a[a1-1-px] == d.b[b1-1-py] {
+				px++
+				py++
+			}
+			vb[off+r] = px
+			if k := delta - r; !odd && k >= -e && k <= e && px+vf[off+k] >= n {
+				return a1 - px, b1 - py, a1 - sx, b1 - sy
+			}
+		}
+	}
+	// Unreachable: the paths meet within half the edits
+	return a0, b0, a0, b0
+}
+
+// Compute diffs two texts and groups the changes into hunks with the given
+// number of context lines
+func Compute(a, b string, context int) []Hunk {
+	if a == b {
+		return nil
+	}
+	return Hunks(Lines(SplitLines(a), SplitLines(b)), context)
+}
+
+// Hunks groups an edit script into hunks. Changes separated by no more than
+// twice the context are merged into one hunk.
+func Hunks(edits []Line, context int) []Hunk {
+	// Line positions in the old and new text before each edit
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.Kind != Insert {
+			oldPos[i+1]++
+		}
+		if e.Kind != Delete {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	prevEnd := 0
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		start := max(i-context, prevEnd)
+		end := i
+		for {
+			for end < len(edits) && edits[end].Kind != Equal {
+				end++
+			}
+			j := end
+			for j < len(edits) && edits[j].Kind == Equal {
+				j++
+			}
+			if j < len(edits) && j-end <= 2*context {
+				end = j
+				continue
+			}
+			end = min(end+context, len(edits))
+			break
+		}
+
+		hunks = append(hunks, Hunk{
+			OldStart: oldPos[start] + 1,
+			OldLines: oldPos[end] - oldPos[start],
+			NewStart: newPos[start] + 1,
+			NewLines: newPos[end] - newPos[start],
+			Lines:    edits[start:end],
+		})
+		prevEnd = end
+		i = end
+	}
+	return hunks
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@" line
+func (h Hunk) Header() string {
+	oldStart, newStart := h.OldStart, h.NewStart
+	if h.OldLines == 0 {
+		oldStart--
+	}
+	if h.NewLines == 0 {
+		newStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, h.OldLines, newStart, h.NewLines)
+}
+
+// String renders the hunk in unified diff format, header included
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header() + "\n")
+	for _, l := range h.Lines {
+		switch l.Kind {
+		case Equal:
+			b.WriteString(" ")
+		case Delete:
+			b.WriteString("-")
+		case Insert:
+			b.WriteString("+")
+		}
+		b.WriteString(l.Text + "\n")
+	}
+	return b.String()
+}
+
+// Unified renders hunks as a unified diff between two named texts
+func Unified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("--- " + oldName + "\n")
+	b.WriteString("+++ " + newName + "\n")
+	for _, h := range hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Apply applies the hunks for which selected returns true to old, leaving the
+// other regions of old unchanged. The hunks must come from diffing old.
+func Apply(old string, hunks []Hunk, selected func(i int) bool) string {
+	a := SplitLines(old)
+	var out []string
+	pos := 0
+
+	for i, h := range hunks {
+		if !selected(i) {
+			continue
+		}
+		start := h.OldStart - 1
+		out = append(out, a[pos:start]...)
+		for _, l := range h.Lines {
+			if l.Kind != Delete {
+				out = append(out, l.Text)
+			}
+		}
+		pos = start + h.OldLines
+	}
+	out = append(out, a[pos:]...)
+
+	return strings.Join(out, "\n")
+}
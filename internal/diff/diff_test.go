@@ -0,0 +1,186 @@
+package diff
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both texts from an edit script
+func apply(edits []Line) (a, b []string) {
+	for _, e := range edits {
+		if e.Kind != Insert {
+			a = append(a, e.Text)
+		}
+		if e.Kind != Delete {
+			b = append(b, e.Text)
+		}
+	}
+	return a, b
+}
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			default:
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func checkScript(t *testing.T, a, b []string, edits []Line) {
+	t.Helper()
+	gotA, gotB := apply(edits)
+	if !equalLines(gotA, a) || !equalLines(gotB, b) {
+		t.Fatalf("script does not turn %q into %q: %v", a, b, edits)
+	}
+	changes := 0
+	for _, e := range edits {
+		if e.Kind != Equal {
+			changes++
+		}
+	}
+	if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+		t.Errorf("Lines(%q, %q) makes %d changes, want %d", a, b, changes, want)
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string // one letter per line: = kept, - deleted, + inserted
+	}{
+		{"both empty", "", "", "="},
+		{"identical", "a\nb\nc", "a\nb\nc", "==="},
+		{"insert at start", "b\nc", "a\nb\nc", "+=="},
+		{"insert at end", "a\nb", "a\nb\nc", "==+"},
+		{"delete in middle", "a\nb\nc", "a\nc", "=-="},
+		{"replace line", "a\nb\nc", "a\nx\nc", "=-+="},
+		{"all different", "a\nb", "x\ny", "--++"},
+		{"moved line", "a\nb\nc\nd", "b\nc\nd\na", "-===+"},
+		{"classic", "a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SplitLines(tt.a), SplitLines(tt.b)
+			edits := Lines(a, b)
+			checkScript(t, a, b, edits)
+			if tt.want == "" {
+				return
+			}
+			var got strings.Builder
+			for _, e := range edits {
+				got.WriteByte("=-+"[e.Kind])
+			}
+			if got.String() != tt.want {
+				t.Errorf("Lines() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesEmptyInputs(t *testing.T) {
+	if edits := Lines(nil, nil); edits != nil {
+		t.Errorf("Lines(nil, nil) = %v, want nil", edits)
+	}
+	b := []string{"a", "b"}
+	checkScript(t, nil, b, Lines(nil, b))
+	checkScript(t, b, nil, Lines(b, nil))
+}
+
+func TestLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	text := func() []string {
+		lines := make([]string, r.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+	for range 500 {
+		a, b := text(), text()
+		checkScript(t, a, b, Lines(a, b))
+	}
+}
+
+func TestLinesLargeDifferentTexts(t *testing.T) {
+	// Keeping every round's diagonals would take about 1.6 GB here
+	a := make([]string, 5000)
+	b := make([]string, 5000)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+	b[2500] = a[1000]
+	edits := Lines(a, b)
+	gotA, gotB := apply(edits)
+	if !equalLines(gotA, a) || !equalLines(gotB, b) {
+		t.Fatal("script does not turn a into b")
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name              string
+		base, mine, their string
+		want              string
+		conflicts         int
+	}{
+		{
+			name: "clean merge",
+			base: "a\nb\nc\nd\ne", mine: "A\nb\nc\nd\ne", their: "a\nb\nc\nd\nE",
+			want: "A\nb\nc\nd\nE",
+		},
+		{
+			name: "only theirs changed",
+			base: "a\nb", mine: "a\nb", their: "a\nb\nc",
+			want: "a\nb\nc",
+		},
+		{
+			name: "identical edits",
+			base: "a\nb\nc", mine: "a\nX\nc", their: "a\nX\nc",
+			want: "a\nX\nc",
+		},
+		{
+			name: "overlapping conflict",
+			base: "a\nb\nc", mine: "a\nmine\nc", their: "a\ntheirs\nc",
+			want:      "a\n" + MarkerMine + "\nmine\n" + MarkerSep + "\ntheirs\n" + MarkerTheirs + "\nc",
+			conflicts: 1,
+		},
+		{
+			name: "delete against edit",
+			base: "a\nb\nc", mine: "a\nc", their: "a\nB\nc",
+			want:      "a\n" + MarkerMine + "\n" + MarkerSep + "\nB\n" + MarkerTheirs + "\nc",
+			conflicts: 1,
+		},
+		{
+			name: "empty inputs",
+			base: "", mine: "", their: "",
+			want: "",
+		},
+		{
+			name: "empty base",
+			base: "", mine: "", their: "new",
+			want: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge3(tt.base, tt.mine, tt.their)
+			if got.Text != tt.want || got.Conflicts != tt.conflicts {
+				t.Errorf("Merge3() = %q with %d conflicts, want %q with %d", got.Text, got.Conflicts, tt.want, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package diff
+
+import "strings"
+
+// Conflict markers written into merged text where both sides changed the
+// same lines differently
+const (
+	MarkerMine   = "<<<<<<< mine"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> theirs"
+)
+
+// MergeResult is the outcome of a three-way merge
+type MergeResult struct {
+	Text      string
+	Conflicts int
+}
+
+// Merge3 merges the changes made in mine and theirs relative to base.
+// Regions changed on only one side take that side; regions changed on both
+// sides identically are kept once; anything else becomes a conflict block
+// delimited by MarkerMine, MarkerSep and MarkerTheirs.
+func Merge3(base, mine, theirs string) MergeResult {
+	o, a, b := SplitLines(base), SplitLines(mine), SplitLines(theirs)
+	matchA := matches(o, a)
+	matchB := matches(o, b)
+
+	var out []string
+	conflicts := 0
+	i, ia, ib := 0, 0, 0
+
+	for {
+		// Lines unchanged on both sides
+		k := 0
+		for i+k < len(o) && matchA[i+k] == ia+k && matchB[i+k] == ib+k {
+			k++
+		}
+		if k > 0 {
+			out = append(out, o[i:i+k]...)
+			i, ia, ib = i+k, ia+k, ib+k
+			continue
+		}
+
+		// Next base line kept by both sides ends the changed region
+		j := i
+		for j < len(o) && (matchA[j] < 0 || matchB[j] < 0) {
+			j++
+		}
+		endA, endB := len(a), len(b)
+		if j < len(o) {
+			endA, endB = matchA[j], matchB[j]
+		}
+		if j == i && endA == ia && endB == ib {
+			break
+		}
+
+		chunkO, chunkA, chunkB := o[i:j], a[ia:endA], b[ib:endB]
+		switch {
+		case equalLines(chunkA, chunkO):
+			out = append(out, chunkB...)
+		case equalLines(chunkB, chunkO), equalLines(chunkA, chunkB):
+			out = append(out, chunkA...)
+		default:
+			conflicts++
+			out = append(out, MarkerMine)
+			out = append(out, chunkA...)
+			out = append(out, MarkerSep)
+			out = append(out, chunkB...)
+			out = append(out, MarkerTheirs)
+		}
+		i, ia, ib = j, endA, endB
+	}
+
+	return MergeResult{Text: strings.Join(out, "\n"), Conflicts: conflicts}
+}
+
+// matches maps each line of o to the index of the same line in a, or -1 if
+// the line was removed
+func matches(o, a []string) []int {
+	m := make([]int, len(o))
+	i, j := 0, 0
+	for _, l := range Lines(o, a) {
+		switch l.Kind {
+		case Equal:
+			m[i] = j
+			i++
+			j++
+		case Delete:
+			m[i] = -1
+			i++
+		case Insert:
+			j++
+		}
+	}
+	return m
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
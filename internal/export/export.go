@@ -0,0 +1,239 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Format identifies a note export format
+type Format string
+
+const (
+	FormatHTML     Format = "html"     // one standalone HTML page per note
+	FormatPrint    Format = "pdf"      // a single print-ready HTML document
+	FormatMarkdown Format = "markdown" // flattened Markdown with standard links
+)
+
+// Formats lists the supported export formats
+var Formats = []Format{FormatHTML, FormatPrint, FormatMarkdown}
+
+// maxEmbedDepth matches the nesting limit used by the preview pane
+const maxEmbedDepth = 3
+
+// wikiLinkPattern also takes the "!" of embeds, which are only left in a
+// note when nested too deep to expand
+var wikiLinkPattern = regexp.MustCompile(`!?\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+
+// Options controls how notes are exported
+type Options struct {
+	Format Format
+	OutDir string
+	Theme  string // "light" or "dark"
+	CSS    string // custom stylesheet replacing the theme when set
+}
+
+// Selection chooses the notes to export; all set criteria must match
+type Selection struct {
+	Note   string // a single note
+	Folder string // every note below this folder
+	Tag    string // notes carrying this tag or one of its subtags
+}
+
+// SelectNotes returns the vault-relative paths of the selected notes
+func SelectNotes(v *vault.Vault, sel Selection) []string {
+	var notes []string
+
+	folder := strings.Trim(filepath.ToSlash(sel.Folder), "/")
+	tag := strings.TrimPrefix(sel.Tag, "#")
+
+	for _, file := range v.Notes() {
+		relPath := file.RelativePath
+		if sel.Note != "" && relPath != sel.Note {
+			continue
+		}
+		if folder != "" && !strings.HasPrefix(filepath.ToSlash(relPath), folder+"/") {
+			continue
+		}
+		if tag != "" && !hasTag(file.Tags, tag) {
+			continue
+		}
+		notes = append(notes, relPath)
+	}
+
+	sort.Strings(notes)
+	return notes
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Export writes the notes to opts.OutDir and returns the paths it created
+func Export(v *vault.Vault, notes []string, opts Options) ([]string, error) {
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("no notes selected")
+	}
+	if err := os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return nil, err
+	}
+
+	switch opts.Format {
+	case FormatHTML:
+		return exportHTML(v, notes, opts)
+	case FormatPrint:
+		return exportPrint(v, notes, opts)
+	case FormatMarkdown:
+		return exportMarkdown(v, notes, opts)
+	}
+	return nil, fmt.Errorf("unknown export format: %s", opts.Format)
+}
+
+// Note is an exported note with embeds expanded and frontmatter removed
+type Note struct {
+	Path        string
+	Title       string
+	Frontmatter map[string]string
+	Body        string // keeps the ^id markers, see HTMLRenderer.Render
+}
+
+// LoadNote reads a note and expands its embeds the same way the preview does.
+// When allow is set, only notes it accepts are inlined.
+func LoadNote(v *vault.Vault, relPath string, allow func(relPath string) bool) (*Note, error) {
+	content, err := v.ReadFile(relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, _ := parser.ExtractFrontmatter(content)
+	_, body := splitFrontmatter(content)
+	body = v.ExpandEmbedsFiltered(relPath, body, maxEmbedDepth, allow)
+
+	title := strings.Trim(fm["title"], `"'`)
+	if title == "" {
+		title = parser.ExtractTitle(parser.StripBlockIDs(body))
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(relPath), ".md")
+	}
+
+	return &Note{Path: relPath, Title: title, Frontmatter: fm, Body: body}, nil
+}
+
+// Resolver maps a wiki link target to the exported href, reporting false for
+// targets that are missing or not part of the export
+type Resolver func(target string) (href string, ok bool)
+
+// ResolveTarget finds the note a wiki link points at and splits off the
+// #heading or #^block fragment
+func ResolveTarget(v *vault.Vault, target string) (relPath, fragment string) {
+	name := strings.TrimSpace(target)
+	if idx := strings.Index(name, "#"); idx != -1 {
+		fragment = name[idx+1:]
+		name = name[:idx]
+	}
+	if name == "" {
+		return "", fragment
+	}
+
+	relPath = v.FindFile(name + ".md")
+	if relPath == "" {
+		relPath = v.FindFile(name)
+	}
+	return relPath, fragment
+}
+
+// RewriteWikiLinks turns [[target|alias]] into standard Markdown links using
+// resolve; unresolved links become their plain display text. Embeds left as
+// written become plain links too, not images.
+func RewriteWikiLinks(content string, resolve Resolver) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := wikiLinkPattern.FindStringSubmatch(match)
+		target := parts[1]
+
+		text := parts[2]
+		if text == "" {
+			text = strings.TrimSuffix(target, ".md")
+			text = strings.Replace(text, "#", " > ", 1)
+		}
+
+		href, ok := resolve(target)
+		if !ok {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	})
+}
+
+// RelativeHref returns a URL-escaped link from one exported file to another
+func RelativeHref(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(to))
+	if err != nil {
+		rel = to
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return path.Join(segments...)
+}
+
+// Slug converts heading text to the anchor ID used in exported HTML
+func Slug(text string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastDash = false
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			if !lastDash && b.Len() > 0 {
+				b.WriteRune('-')
+				lastDash = true
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// fragmentAnchor converts a link fragment to an HTML anchor
+func fragmentAnchor(fragment string) string {
+	if fragment == "" {
+		return ""
+	}
+	if strings.HasPrefix(fragment, "^") {
+		return "#" + fragment[1:]
+	}
+	return "#" + Slug(fragment)
+}
+
+func withExt(relPath, ext string) string {
+	return strings.TrimSuffix(filepath.ToSlash(relPath), filepath.Ext(relPath)) + ext
+}
+
+func writeFile(outDir, relPath, content string) (string, error) {
+	full := filepath.Join(outDir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return full, nil
+}
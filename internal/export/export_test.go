@@ -0,0 +1,29 @@
+package export
+
+import "testing"
+
+func TestRewriteWikiLinks(t *testing.T) {
+	resolve := func(target string) (string, bool) {
+		if target == "missing" {
+			return "", false
+		}
+		return target + ".html", true
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"see [[a]]", "see [a](a.html)"},
+		{"see [[a|the A]]", "see [the A](a.html)"},
+		{"see [[a#Part]]", "see [a > Part](a#Part.html)"},
+		{"see [[missing]]", "see missing"},
+		// Embeds nested too deep to expand are links, not images
+		{"> > ![[e]]", "> > [e](e.html)"},
+		{"![[e|E]] and ![[missing]]", "[E](e.html) and missing"},
+	}
+	for _, tt := range tests {
+		if got := RewriteWikiLinks(tt.in, resolve); got != tt.want {
+			t.Errorf("RewriteWikiLinks(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package export
+
+import (
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	gmparser "github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// HTMLRenderer converts note Markdown to HTML with Obsidian-style math and anchors
+type HTMLRenderer struct {
+	md  goldmark.Markdown
+	ids *headingIDs
+}
+
+// NewHTMLRenderer creates a renderer; heading anchors stay unique across every
+// note rendered with it
+func NewHTMLRenderer() *HTMLRenderer {
+	return &HTMLRenderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM, extension.Footnote),
+			goldmark.WithParserOptions(gmparser.WithAutoHeadingID()),
+			goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+		),
+		ids: &headingIDs{seen: make(map[string]bool)},
+	}
+}
+
+// ResetAnchors forgets generated heading anchors; call it between pages
+func (r *HTMLRenderer) ResetAnchors() {
+	r.ids = &headingIDs{seen: make(map[string]bool)}
+}
+
+// SetAnchorPrefix starts the heading anchors generated from now on with
+// prefix, keeping the headings of notes printed together apart
+func (r *HTMLRenderer) SetAnchorPrefix(prefix string) {
+	r.ids.prefix = prefix
+}
+
+// Render converts Markdown to an HTML fragment. Block markers become anchors
+// that [[note#^id]] links point at.
+func (r *HTMLRenderer) Render(markdown string) (string, error) {
+	markdown = parser.ReplaceBlockIDs(markdown, func(id string) string {
+		return `<span id="` + r.ids.prefix + id + `"></span>`
+	})
+	markdown = parser.RenderTeX(markdown)
+
+	var buf bytes.Buffer
+	ctx := gmparser.NewContext(gmparser.WithIDs(r.ids))
+	if err := r.md.Convert([]byte(markdown), &buf, gmparser.WithContext(ctx)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// headingIDs generates heading anchors with Slug, keeping non-ASCII headings linkable
+type headingIDs struct {
+	seen   map[string]bool
+	prefix string
+}
+
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := Slug(string(value))
+	if base == "" {
+		base = "heading"
+	}
+
+	base = h.prefix + base
+	id := base
+	for i := 1; h.seen[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	h.seen[id] = true
+	return []byte(id)
+}
+
+func (h *headingIDs) Put(value []byte) {
+	h.seen[string(value)] = true
+}
+
+// Page wraps an HTML fragment in a standalone document
+func Page(title, css, body string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>\n" + css + "\n</style>\n</head>\n<body>\n")
+	b.WriteString(body)
+	b.WriteString("\n</body>\n</html>\n")
+	return b.String()
+}
+
+func (o Options) stylesheet() string {
+	if o.CSS != "" {
+		return o.CSS
+	}
+	css := ThemeCSS(o.Theme)
+	if o.Format == FormatPrint {
+		css += printCSS
+	}
+	return css
+}
+
+func exportHTML(v *vault.Vault, notes []string, opts Options) ([]string, error) {
+	included := make(map[string]bool)
+	for _, n := range notes {
+		included[n] = true
+	}
+
+	renderer := NewHTMLRenderer()
+	css := opts.stylesheet()
+
+	var written []string
+	for _, relPath := range notes {
+		note, err := LoadNote(v, relPath, nil)
+		if err != nil {
+			return written, err
+		}
+
+		// Anchors are unique per page
+		renderer.ResetAnchors()
+
+		outPath := withExt(relPath, ".html")
+		body := RewriteWikiLinks(note.Body, func(target string) (string, bool) {
+			to, fragment := ResolveTarget(v, target)
+			if to == "" && fragment != "" {
+				return fragmentAnchor(fragment), true
+			}
+			if !included[to] {
+				return "", false
+			}
+			return RelativeHref(outPath, withExt(to, ".html")) + fragmentAnchor(fragment), true
+		})
+
+		rendered, err := renderer.Render(body)
+		if err != nil {
+			return written, err
+		}
+
+		page := Page(note.Title, css, "<article class=\"note\">\n"+rendered+"</article>")
+		full, err := writeFile(opts.OutDir, outPath, page)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, full)
+	}
+
+	return written, nil
+}
+
+// exportPrint renders every note into one document with a page break between
+// notes, ready for the browser's "Save as PDF"
+func exportPrint(v *vault.Vault, notes []string, opts Options) ([]string, error) {
+	included := make(map[string]bool)
+	for _, n := range notes {
+		included[n] = true
+	}
+
+	renderer := NewHTMLRenderer()
+
+	var body strings.Builder
+	title := "Export"
+	for i, relPath := range notes {
+		note, err := LoadNote(v, relPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			title = note.Title
+		}
+
+		content := RewriteWikiLinks(note.Body, func(target string) (string, bool) {
+			to, fragment := ResolveTarget(v, target)
+			if to == "" && fragment != "" {
+				// [[#Heading]] links within the note
+				return printAnchor(relPath, fragment), true
+			}
+			if !included[to] {
+				return "", false
+			}
+			return printAnchor(to, fragment), true
+		})
+
+		renderer.SetAnchorPrefix(noteAnchor(relPath) + "-")
+		rendered, err := renderer.Render(content)
+		if err != nil {
+			return nil, err
+		}
+
+		body.WriteString("<section class=\"note\" id=\"" + noteAnchor(relPath) + "\">\n")
+		body.WriteString(rendered)
+		body.WriteString("</section>\n")
+	}
+
+	name := "export.html"
+	if len(notes) == 1 {
+		name = withExt(notes[0], ".html")
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+
+	full, err := writeFile(opts.OutDir, name, Page(title, opts.stylesheet(), body.String()))
+	if err != nil {
+		return nil, err
+	}
+	return []string{full}, nil
+}
+
+func noteAnchor(relPath string) string {
+	return "note-" + Slug(strings.ReplaceAll(withExt(relPath, ""), "/", "-"))
+}
+
+// printAnchor returns the in-page link to a note printed with exportPrint, or
+// to a heading or block of it
+func printAnchor(relPath, fragment string) string {
+	switch {
+	case fragment == "":
+		return "#" + noteAnchor(relPath)
+	case strings.HasPrefix(fragment, "^"):
+		return "#" + noteAnchor(relPath) + "-" + fragment[1:]
+	}
+	return "#" + noteAnchor(relPath) + "-" + Slug(fragment)
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestExportPrintLinksWithinNote(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "# A\n\nSee [[#Details]] and [[b#Intro]].\n\n## Details\n\ntext\n",
+		"b.md": "# B\n\n## Intro\n\n## Details\n",
+	})
+
+	out := t.TempDir()
+	files, err := Export(v, []string{"a.md", "b.md"}, Options{Format: FormatPrint, OutDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		`href="#note-a-details"`,
+		`id="note-a-details"`,
+		`href="#note-b-intro"`,
+		`id="note-b-intro"`,
+		`id="note-b-details"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %s", want)
+		}
+	}
+}
+
+func TestExportHTMLEmbedAfterText(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "B ![[c]] after\n",
+		"c.md": "C\n",
+	})
+
+	files, err := Export(v, []string{"a.md"}, Options{Format: FormatHTML, OutDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	if strings.Contains(page, "<h2") {
+		t.Errorf("the line before the embed became a heading:\n%s", page)
+	}
+	if n := strings.Count(page, "<hr"); n != 2 {
+		t.Errorf("page has %d rules, want 2 around the embed:\n%s", n, page)
+	}
+}
+
+func TestExportBlockAnchors(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "# A\n\nSee [[b#^blk]] and [[#^own]].\n\nmine ^own\n",
+		"b.md": "# B\n\nquoted ^blk\n\n```\ncode ^notid\n```\n",
+	})
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	out := t.TempDir()
+	if _, err := Export(v, []string{"a.md", "b.md"}, Options{Format: FormatHTML, OutDir: out}); err != nil {
+		t.Fatal(err)
+	}
+	a, b := read(filepath.Join(out, "a.html")), read(filepath.Join(out, "b.html"))
+	for _, tt := range []struct{ page, name, want string }{
+		{a, "a.html", `href="b.html#blk"`},
+		{a, "a.html", `href="#own"`},
+		{a, "a.html", `<span id="own"></span>`},
+		{b, "b.html", `quoted <span id="blk"></span>`},
+		{b, "b.html", "code ^notid"},
+	} {
+		if !strings.Contains(tt.page, tt.want) {
+			t.Errorf("%s lacks %s", tt.name, tt.want)
+		}
+	}
+	if strings.Contains(a, "mine ^own") || strings.Contains(b, "quoted ^blk") {
+		t.Error("block markers left in the pages")
+	}
+
+	out = t.TempDir()
+	files, err := Export(v, []string{"a.md", "b.md"}, Options{Format: FormatPrint, OutDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := read(files[0])
+	for _, want := range []string{
+		`href="#note-b-blk"`,
+		`id="note-b-blk"`,
+		`href="#note-a-own"`,
+		`id="note-a-own"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("print page lacks %s", want)
+		}
+	}
+}
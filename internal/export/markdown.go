@@ -0,0 +1,60 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// splitFrontmatter separates the raw --- frontmatter block from the body
+func splitFrontmatter(content string) (block, body string) {
+	if !strings.HasPrefix(content, "---") {
+		return "", content
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[:i+1], ""), strings.Join(lines[i+1:], "")
+		}
+	}
+	return "", content
+}
+
+// exportMarkdown writes a flattened bundle: embeds are inlined and wiki links
+// become standard relative Markdown links
+func exportMarkdown(v *vault.Vault, notes []string, opts Options) ([]string, error) {
+	included := make(map[string]bool)
+	for _, n := range notes {
+		included[n] = true
+	}
+
+	var written []string
+	for _, relPath := range notes {
+		content, err := v.ReadFile(relPath)
+		if err != nil {
+			return written, err
+		}
+
+		frontmatter, body := splitFrontmatter(content)
+		body = v.ExpandEmbeds(relPath, body, maxEmbedDepth)
+		body = RewriteWikiLinks(body, func(target string) (string, bool) {
+			to, fragment := ResolveTarget(v, target)
+			if to == "" && fragment != "" {
+				return fragmentAnchor(fragment), true
+			}
+			if !included[to] {
+				return "", false
+			}
+			return RelativeHref(relPath, to) + fragmentAnchor(fragment), true
+		})
+
+		full, err := writeFile(opts.OutDir, relPath, frontmatter+body)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, full)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,57 @@
+package export
+
+// ThemeNames lists the built-in stylesheets
+var ThemeNames = []string{"light", "dark"}
+
+const baseCSS = `
+body { margin: 0 auto; max-width: 46em; padding: 2em 1.5em; line-height: 1.6;
+  font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", "Hiragino Sans", "Noto Sans JP", sans-serif; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin-top: 1.6em; }
+pre, code { font-family: "SFMono-Regular", Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { padding: 0.8em 1em; overflow-x: auto; border-radius: 6px; }
+code { padding: 0.1em 0.3em; border-radius: 4px; }
+pre code { padding: 0; }
+blockquote { margin: 1em 0; padding: 0.2em 1em; border-left: 4px solid; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border: 1px solid; }
+img { max-width: 100%; }
+hr { border: 0; border-top: 1px solid; }
+`
+
+var themes = map[string]string{
+	"light": baseCSS + `
+body { background: #ffffff; color: #222222; }
+a { color: #705dcf; }
+pre, code { background: #f4f4f6; }
+blockquote { border-color: #705dcf; color: #555555; background: #f8f7fd; }
+th, td, hr { border-color: #dddddd; }
+`,
+	"dark": baseCSS + `
+body { background: #1e1e1e; color: #dcddde; }
+a { color: #a88bfa; }
+pre, code { background: #2a2a2e; }
+blockquote { border-color: #a88bfa; color: #b0b0b0; background: #26242e; }
+th, td, hr { border-color: #444444; }
+`,
+}
+
+// printCSS is appended for print-ready exports
+const printCSS = `
+@page { size: A4; margin: 2cm; }
+@media print {
+  body { max-width: none; padding: 0; background: #ffffff; color: #000000; }
+  section.note { page-break-after: always; }
+  section.note:last-child { page-break-after: auto; }
+  pre, blockquote, table, img { page-break-inside: avoid; }
+  h1, h2, h3 { page-break-after: avoid; }
+  a { color: inherit; text-decoration: underline; }
+}
+`
+
+// ThemeCSS returns the stylesheet for a theme, falling back to light
+func ThemeCSS(name string) string {
+	if css, ok := themes[name]; ok {
+		return css
+	}
+	return themes["light"]
+}
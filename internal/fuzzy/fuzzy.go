@@ -0,0 +1,151 @@
+// Package fuzzy scores how well a short pattern matches a longer text, in the
+// style of fzf: the pattern's characters must appear in order, and matches
+// that are consecutive or start at word boundaries score higher.
+package fuzzy
+
+import (
+	"unicode"
+)
+
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary     = 8 // after a space, dash, underscore, dot...
+	bonusDelimiter    = 9 // after a path separator
+	bonusCamel        = 7 // lower to upper case, letter to digit
+	bonusConsecutive  = 4 // minimum bonus inside a run of matches
+	bonusFirstCharMul = 2 // the first pattern character counts double
+)
+
+// Match scores pattern against text, case-insensitively. It reports false
+// if the text does not contain the pattern's characters in order. Positions
+// are the rune indices of the matched characters in text.
+func Match(pattern, text string) (score int, positions []int, ok bool) {
+	p := lowerRunes(pattern)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	orig := []rune(text)
+	t := lowerRunes(text)
+	if !subsequence(p, t) {
+		return 0, nil, false
+	}
+
+	m, n := len(p), len(t)
+	bonus := make([]int, n)
+	for j := range t {
+		bonus[j] = bonusAt(orig, j)
+	}
+
+	// best[i][j] is the best score with p[i] matched at t[j]; from[i][j] is
+	// where p[i-1] was matched on that path. run[i][j] is the bonus carried
+	// through a run of consecutive matches.
+	const none = -1 << 30
+	best := make([][]int, m)
+	from := make([][]int, m)
+	run := make([][]int, m)
+	for i := range best {
+		best[i] = make([]int, n)
+		from[i] = make([]int, n)
+		run[i] = make([]int, n)
+		for j := range best[i] {
+			best[i][j] = none
+		}
+	}
+
+	for j := 0; j < n; j++ {
+		if t[j] == p[0] {
+			best[0][j] = scoreMatch + bonus[j]*bonusFirstCharMul
+			run[0][j] = bonus[j]
+		}
+	}
+
+	for i := 1; i < m; i++ {
+		gap, gapFrom := none, -1
+		for j := i; j < n; j++ {
+			// Best score of p[i-1] at some k < j-1, less the gap to j
+			if gap != none {
+				gap += scoreGapExtension
+			}
+			if k := j - 2; k >= 0 && best[i-1][k] != none && best[i-1][k]+scoreGapStart > gap {
+				gap, gapFrom = best[i-1][k]+scoreGapStart, k
+			}
+
+			if t[j] != p[i] {
+				continue
+			}
+
+			if gap != none {
+				best[i][j] = gap + scoreMatch + bonus[j]
+				from[i][j] = gapFrom
+				run[i][j] = bonus[j]
+			}
+			if prev := best[i-1][j-1]; prev != none {
+				b := max(bonus[j], run[i-1][j-1], bonusConsecutive)
+				if s := prev + scoreMatch + b; s >= best[i][j] {
+					best[i][j] = s
+					from[i][j] = j - 1
+					run[i][j] = b
+				}
+			}
+		}
+	}
+
+	end := -1
+	for j := 0; j < n; j++ {
+		if best[m-1][j] != none && (end < 0 || best[m-1][j] > best[m-1][end]) {
+			end = j
+		}
+	}
+
+	positions = make([]int, m)
+	for i, j := m-1, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return best[m-1][end], positions, true
+}
+
+func lowerRunes(s string) []rune {
+	r := []rune(s)
+	for i, c := range r {
+		r[i] = unicode.ToLower(c)
+	}
+	return r
+}
+
+func subsequence(p, t []rune) bool {
+	i := 0
+	for _, c := range t {
+		if c == p[i] {
+			i++
+			if i == len(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bonusAt(text []rune, j int) int {
+	if j == 0 {
+		return bonusBoundary
+	}
+	prev, cur := text[j-1], text[j]
+	switch {
+	case prev == '/' || prev == '\\':
+		return bonusDelimiter
+	case !isWord(prev) && isWord(cur):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur),
+		!unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,78 @@
+package fuzzy
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+		positions     []int
+	}{
+		{"", "anything", true, nil},
+		{"abc", "abc", true, []int{0, 1, 2}},
+		{"ABC", "xaBc", true, []int{1, 2, 3}},
+		{"dn", "daily notes", true, []int{0, 6}},
+		{"nt", "notes/todo", true, []int{0, 6}},
+		{"cb", "ColorBox", true, []int{0, 5}},
+		{"日記", "2024 日記", true, []int{5, 6}},
+		{"abc", "acb", false, nil},
+		{"x", "", false, nil},
+	}
+	for _, tt := range tests {
+		_, pos, ok := Match(tt.pattern, tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(pos, tt.positions) {
+			t.Errorf("Match(%q, %q) = %v, %v; want %v, %v", tt.pattern, tt.text, pos, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestMatchLongGap(t *testing.T) {
+	// The gap penalty outweighs the matches, but it is still a match
+	text := "a" + strings.Repeat("x", 60) + "z"
+	score, pos, ok := Match("az", text)
+	if !ok {
+		t.Fatal("Match() reported no match")
+	}
+	if score >= 0 {
+		t.Errorf("score = %d, want a negative score for this test", score)
+	}
+	if want := []int{0, len(text) - 1}; !reflect.DeepEqual(pos, want) {
+		t.Errorf("positions = %v, want %v", pos, want)
+	}
+}
+
+func TestMatchOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		texts   []string // best match first
+	}{
+		{"consecutive before spread", "note", []string{"notes", "n-o-t-e", "nxoxtxe"}},
+		{"word start before middle", "log", []string{"log/today", "dialog"}},
+		{"after a path separator", "todo", []string{"work/todo", "worktodo"}},
+		{"camel case boundary", "fb", []string{"FooBar", "foobar"}},
+		{"shorter gap", "ac", []string{"abc", "abbbbbbbbbbc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := make(map[string]int)
+			for _, text := range tt.texts {
+				score, _, ok := Match(tt.pattern, text)
+				if !ok {
+					t.Fatalf("Match(%q, %q) reported no match", tt.pattern, text)
+				}
+				scores[text] = score
+			}
+			got := append([]string(nil), tt.texts...)
+			sort.SliceStable(got, func(i, j int) bool { return scores[got[i]] > scores[got[j]] })
+			if !reflect.DeepEqual(got, tt.texts) {
+				t.Errorf("order = %v (scores %v), want %v", got, scores, tt.texts)
+			}
+		})
+	}
+}
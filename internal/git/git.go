@@ -0,0 +1,249 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotRepository is returned when the vault is not inside a git work tree
+var ErrNotRepository = errors.New("not a git repository")
+
+// dataExclude keeps the app's own data folder (exports, history, swap files)
+// out of status and commits
+const dataExclude = ":(exclude).obsidiantui"
+
+// Status is a one-letter summary of a file's state in the work tree
+type Status string
+
+const (
+	StatusModified  Status = "M"
+	StatusAdded     Status = "A"
+	StatusDeleted   Status = "D"
+	StatusRenamed   Status = "R"
+	StatusUntracked Status = "?"
+	StatusConflict  Status = "U"
+)
+
+// Repo runs git commands for a vault directory
+type Repo struct {
+	Dir    string // vault directory
+	Root   string // top level of the work tree
+	prefix string // Dir relative to Root, with a trailing slash
+}
+
+// Open finds the git work tree containing dir
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+
+	r := &Repo{Dir: dir}
+	root, err := r.run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, ErrNotRepository
+	}
+	r.Root = strings.TrimSpace(root)
+
+	prefix, err := r.run("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	r.prefix = strings.TrimSpace(prefix)
+
+	return r, nil
+}
+
+// Init creates a repository in dir and opens it
+func Init(dir string) (*Repo, error) {
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("git init: %s", strings.TrimSpace(string(out)))
+	}
+	return Open(dir)
+}
+
+func (r *Repo) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// vaultPath converts a path relative to the work tree root into one relative
+// to the vault, reporting false for files outside the vault
+func (r *Repo) vaultPath(rootPath string) (string, bool) {
+	if !strings.HasPrefix(rootPath, r.prefix) {
+		return "", false
+	}
+	return filepath.FromSlash(strings.TrimPrefix(rootPath, r.prefix)), true
+}
+
+// Status returns the state of every changed file in the vault, keyed by
+// vault-relative path
+func (r *Repo) Status() (map[string]Status, error) {
+	out, err := r.run("status", "--porcelain=v1", "-z", "--untracked-files=all", "--", ".", dataExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Status)
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		x, y := entry[0], entry[1]
+		path := entry[3:]
+
+		var status Status
+		switch {
+		case x == '?' && y == '?':
+			status = StatusUntracked
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			status = StatusConflict
+		case x == 'R' || y == 'R':
+			status = StatusRenamed
+			i++ // the original path follows a rename entry
+		case x == 'A':
+			status = StatusAdded
+		case x == 'D' || y == 'D':
+			status = StatusDeleted
+		default:
+			status = StatusModified
+		}
+
+		if rel, ok := r.vaultPath(path); ok {
+			result[rel] = status
+		}
+	}
+
+	return result, nil
+}
+
+// Diff returns the unified diff of a vault file against HEAD; untracked files
+// are shown as entirely added
+func (r *Repo) Diff(relPath string) (string, error) {
+	if _, err := r.run("ls-files", "--error-unmatch", "--", relPath); err == nil {
+		if out, err := r.run("diff", "HEAD", "--no-color", "--", relPath); err == nil {
+			return out, nil
+		}
+	}
+
+	// Untracked, or no commit yet: diff against an empty file. --no-index
+	// exits non-zero whenever the files differ, so only the output matters.
+	out, _ := r.run("diff", "--no-index", "--no-color", "--", "/dev/null", relPath)
+	return out, nil
+}
+
+// vaultSpec limits a command to the vault, which may be a folder of a larger
+// work tree, leaving out the app's data folder
+var vaultSpec = []string{"--", ".", dataExclude}
+
+// StageAll stages every change inside the vault
+func (r *Repo) StageAll() error {
+	_, err := r.run(append([]string{"add", "-A"}, vaultSpec...)...)
+	return err
+}
+
+// Stage stages the given vault files
+func (r *Repo) Stage(paths ...string) error {
+	_, err := r.run(append([]string{"add", "-A", "--"}, paths...)...)
+	return err
+}
+
+// HasStagedChanges reports whether the index differs from HEAD inside the
+// vault
+func (r *Repo) HasStagedChanges() bool {
+	_, err := r.run(append([]string{"diff", "--cached", "--quiet"}, vaultSpec...)...)
+	return err != nil
+}
+
+// Commit records the staged changes inside the vault; changes staged
+// elsewhere in the work tree stay staged
+func (r *Repo) Commit(message string) error {
+	_, err := r.run(append([]string{"commit", "-q", "-m", message}, vaultSpec...)...)
+	return err
+}
+
+// AutoMessage describes the staged changes, e.g.
+// "vault backup: 2024-01-02 15:04 (3 files: a.md, b.md, c.md)"
+func (r *Repo) AutoMessage(now time.Time) string {
+	out, _ := r.run(append([]string{"diff", "--cached", "--name-only", "-z"}, vaultSpec...)...)
+
+	var names []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			names = append(names, filepath.Base(p))
+		}
+	}
+	sort.Strings(names)
+
+	msg := "vault backup: " + now.Format("2006-01-02 15:04")
+	switch {
+	case len(names) == 1:
+		msg += " (" + names[0] + ")"
+	case len(names) > 1 && len(names) <= 3:
+		msg += fmt.Sprintf(" (%d files: %s)", len(names), strings.Join(names, ", "))
+	case len(names) > 3:
+		msg += fmt.Sprintf(" (%d files: %s, ...)", len(names), strings.Join(names[:3], ", "))
+	}
+	return msg
+}
+
+// CommitAll stages everything in the vault and commits it with an
+// auto-generated message. It returns the message, or "" if nothing changed.
+func (r *Repo) CommitAll(now time.Time) (string, error) {
+	if err := r.StageAll(); err != nil {
+		return "", err
+	}
+	if !r.HasStagedChanges() {
+		return "", nil
+	}
+
+	msg := r.AutoMessage(now)
+	if err := r.Commit(msg); err != nil {
+		return "", err
+	}
+	return msg, nil
+}
+
+// Pull fetches and integrates changes from the upstream branch
+func (r *Repo) Pull() (string, error) {
+	out, err := r.run("pull", "--no-edit")
+	return strings.TrimSpace(out), err
+}
+
+// Push sends local commits to the upstream branch
+func (r *Repo) Push() error {
+	_, err := r.run("push")
+	return err
+}
+
+// Branch returns the current branch name
+func (r *Repo) Branch() string {
+	out, err := r.run("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
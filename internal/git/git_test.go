@@ -0,0 +1,245 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setup makes a bare remote and two clones of it, a and b, with one commit
+// of note.md. Each clone's vault is a folder inside the work tree.
+func setup(t *testing.T) (a, b *Repo) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not on PATH")
+	}
+
+	// Keep the user's configuration out of the tests
+	config := map[string]string{
+		"user.name":          "Test",
+		"user.email":         "test@example.com",
+		"init.defaultBranch": "main",
+		"pull.rebase":        "false",
+		"commit.gpgSign":     "false",
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_COUNT", strconv.Itoa(len(config)))
+	i := 0
+	for k, v := range config {
+		t.Setenv("GIT_CONFIG_KEY_"+strconv.Itoa(i), k)
+		t.Setenv("GIT_CONFIG_VALUE_"+strconv.Itoa(i), v)
+		i++
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	gitRun(t, dir, "init", "-q", "--bare", remote)
+
+	clone := func(name string) *Repo {
+		gitRun(t, dir, "clone", "-q", remote, name)
+		vaultDir := filepath.Join(dir, name, "vault")
+		if err := os.MkdirAll(vaultDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		r, err := Open(vaultDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	a = clone("a")
+	writeNote(t, a, "note.md", "one\ntwo\nthree\n")
+	if _, err := a.CommitAll(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, a.Dir, "push", "-q", "-u", "origin", "main")
+
+	b = clone("b")
+	return a, b
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeNote(t *testing.T, r *Repo, name, content string) {
+	t.Helper()
+	path := filepath.Join(r.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not on PATH")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	if _, err := Open(t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Open() error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	a, _ := setup(t)
+
+	writeNote(t, a, "note.md", "one\ntwo\nchanged\n")
+	writeNote(t, a, "sub/new.md", "new\n")
+	writeNote(t, a, ".obsidiantui/history/x.md", "ignored\n")
+	writeNote(t, a, "../outside.md", "not in the vault\n")
+
+	got, err := a.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Status{
+		"note.md":                      StatusModified,
+		filepath.Join("sub", "new.md"): StatusUntracked,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Status() = %v, want %v", got, want)
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("Status()[%q] = %q, want %q", path, got[path], status)
+		}
+	}
+
+	if err := a.Stage("sub/new.md"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = a.Status()
+	if got[filepath.Join("sub", "new.md")] != StatusAdded {
+		t.Errorf("staged file status = %q, want %q", got[filepath.Join("sub", "new.md")], StatusAdded)
+	}
+}
+
+func TestCommitAll(t *testing.T) {
+	a, _ := setup(t)
+
+	now := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+	msg, err := a.CommitAll(now)
+	if err != nil || msg != "" {
+		t.Fatalf("CommitAll() with no changes = %q, %v; want \"\", nil", msg, err)
+	}
+
+	writeNote(t, a, "b.md", "b\n")
+	writeNote(t, a, "a.md", "a\n")
+	msg, err = a.CommitAll(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "vault backup: 2024-01-02 15:04 (2 files: a.md, b.md)"; msg != want {
+		t.Errorf("CommitAll() = %q, want %q", msg, want)
+	}
+	if status, _ := a.Status(); len(status) != 0 {
+		t.Errorf("Status() after commit = %v, want none", status)
+	}
+	if branch := a.Branch(); branch != "main" {
+		t.Errorf("Branch() = %q, want main", branch)
+	}
+}
+
+func TestCommitAllLeavesChangesOutsideTheVault(t *testing.T) {
+	a, _ := setup(t)
+
+	// Staged by the user elsewhere in the work tree
+	writeNote(t, a, "../outside.md", "staged\n")
+	gitRun(t, a.Root, "add", "outside.md")
+	msg, err := a.CommitAll(time.Now())
+	if err != nil || msg != "" {
+		t.Fatalf("CommitAll() with changes only outside the vault = %q, %v; want \"\", nil", msg, err)
+	}
+
+	writeNote(t, a, "a.md", "a\n")
+	writeNote(t, a, ".obsidiantui/swap/a.md", "swap\n")
+	msg, err = a.CommitAll(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(msg, "(a.md)") {
+		t.Errorf("CommitAll() = %q, want only a.md", msg)
+	}
+
+	out, err := a.run("show", "--name-only", "--format=", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out); got != "vault/a.md" {
+		t.Errorf("committed %q, want vault/a.md", got)
+	}
+	out, err = a.run("diff", "--cached", "--name-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out); got != "outside.md" {
+		t.Errorf("staged after commit = %q, want outside.md still staged", got)
+	}
+}
+
+func TestPushPull(t *testing.T) {
+	a, b := setup(t)
+
+	writeNote(t, a, "from-a.md", "hello\n")
+	if _, err := a.CommitAll(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Push(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Pull(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(b.Dir, "from-a.md"))
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf("pulled from-a.md = %q, %v; want %q", data, err, "hello\n")
+	}
+}
+
+func TestPullConflict(t *testing.T) {
+	a, b := setup(t)
+
+	writeNote(t, a, "note.md", "one\ntwo\nfrom a\n")
+	if _, err := a.CommitAll(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Push(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeNote(t, b, "note.md", "one\ntwo\nfrom b\n")
+	if _, err := b.CommitAll(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// b is behind, so its push is refused until it pulls
+	if err := b.Push(); err == nil {
+		t.Error("Push() of a diverged branch succeeded")
+	}
+
+	if _, err := b.Pull(); err == nil {
+		t.Fatal("Pull() of conflicting changes succeeded")
+	}
+	status, err := b.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status["note.md"] != StatusConflict {
+		t.Errorf("Status()[note.md] = %q, want %q", status["note.md"], StatusConflict)
+	}
+}
@@ -0,0 +1,146 @@
+// Package history keeps timestamped snapshots of notes so earlier versions
+// can be compared and restored, like Obsidian's File Recovery plugin.
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	timeLayout  = "20060102T150405.000000000Z"
+	snapshotExt = ".md"
+
+	DefaultMaxVersions = 50
+	DefaultRetention   = 30 * 24 * time.Hour
+)
+
+// Version is one stored snapshot of a note
+type Version struct {
+	Path string // note path relative to the vault
+	Time time.Time
+	file string
+}
+
+// Store saves snapshots under dir, one folder per note
+type Store struct {
+	dir string
+
+	MaxVersions int           // versions kept per note, 0 for no limit
+	Retention   time.Duration // versions older than this are pruned, 0 keeps them forever
+}
+
+// NewStore returns a store rooted at dir, usually vault.DataDir("history")
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:         dir,
+		MaxVersions: DefaultMaxVersions,
+		Retention:   DefaultRetention,
+	}
+}
+
+func (s *Store) noteDir(relPath string) string {
+	return filepath.Join(s.dir, relPath)
+}
+
+// Save stores content as a new version of relPath unless it matches the
+// latest version. It reports whether a snapshot was written.
+func (s *Store) Save(relPath, content string, now time.Time) (bool, error) {
+	if relPath == "" {
+		return false, errors.New("history: empty note path")
+	}
+
+	versions, err := s.Versions(relPath)
+	if err != nil {
+		return false, err
+	}
+	if len(versions) > 0 {
+		if latest, err := s.Load(versions[0]); err == nil && latest == content {
+			return false, nil
+		}
+	}
+
+	dir := s.noteDir(relPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+
+	// Keep names strictly increasing even if the clock does not move
+	stamp := now.UTC()
+	if len(versions) > 0 && !stamp.After(versions[0].Time) {
+		stamp = versions[0].Time.Add(time.Nanosecond)
+	}
+
+	name := stamp.Format(timeLayout) + snapshotExt
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		return false, err
+	}
+
+	return true, s.Prune(relPath, now)
+}
+
+// Versions lists the stored versions of relPath, newest first
+func (s *Store) Versions(relPath string) ([]Version, error) {
+	dir := s.noteDir(relPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []Version
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+		t, err := time.Parse(timeLayout, strings.TrimSuffix(name, snapshotExt))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{Path: relPath, Time: t, file: filepath.Join(dir, name)})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// Load returns the content of a stored version
+func (s *Store) Load(v Version) (string, error) {
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Prune removes versions of relPath beyond the count and age limits. The
+// newest version is always kept.
+func (s *Store) Prune(relPath string, now time.Time) error {
+	versions, err := s.Versions(relPath)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range versions {
+		if i == 0 {
+			continue
+		}
+		tooMany := s.MaxVersions > 0 && i >= s.MaxVersions
+		tooOld := s.Retention > 0 && now.Sub(v.Time) > s.Retention
+		if tooMany || tooOld {
+			if err := os.Remove(v.file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
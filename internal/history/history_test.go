@@ -0,0 +1,145 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// contents returns the stored versions of relPath, newest first
+func contents(t *testing.T, s *Store, relPath string) []string {
+	t.Helper()
+	versions, err := s.Versions(relPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range versions {
+		content, err := s.Load(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, content)
+	}
+	return got
+}
+
+func TestSave(t *testing.T) {
+	s := NewStore(t.TempDir())
+	path := filepath.Join("sub", "note.md")
+
+	steps := []struct {
+		content string
+		saved   bool
+	}{
+		{"one", true},
+		{"one", false}, // same as the latest version
+		{"two", true},
+		{"one", true}, // same as an older version only
+	}
+	for _, st := range steps {
+		// The clock does not move: names stay distinct and in order
+		saved, err := s.Save(path, st.content, start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != st.saved {
+			t.Errorf("Save(%q) = %v, want %v", st.content, saved, st.saved)
+		}
+	}
+
+	got := contents(t, s, path)
+	if want := []string{"one", "two", "one"}; !slices.Equal(got, want) {
+		t.Errorf("versions = %q, want %q", got, want)
+	}
+
+	if _, err := s.Save("", "x", start); err == nil {
+		t.Error("Save() accepted an empty path")
+	}
+	if versions, err := s.Versions("missing.md"); err != nil || versions != nil {
+		t.Errorf("Versions() of a note without history = %v, %v", versions, err)
+	}
+}
+
+func TestVersionsSkipsOtherFiles(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if _, err := s.Save("note.md", "one", start); err != nil {
+		t.Fatal(err)
+	}
+	dir := s.noteDir("note.md")
+	for _, name := range []string{"notes.txt", "not-a-time.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "20240101T000000.000000000Z.md"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := s.Versions("note.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || !versions[0].Time.Equal(start) || versions[0].Path != "note.md" {
+		t.Errorf("Versions() = %+v, want the one snapshot", versions)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		retention time.Duration
+		want      int
+	}{
+		{"no limits", 0, 0, 5},
+		{"count", 3, 0, 3},
+		{"age", 0, 60 * time.Hour, 2},
+		{"count and age", 1, 60 * time.Hour, 1},
+		// The newest version is kept however old it is
+		{"everything too old", 0, time.Hour, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(t.TempDir())
+			s.MaxVersions, s.Retention = 0, 0
+
+			// One version a day; the newest is a day old at now
+			for i := range 5 {
+				if _, err := s.Save("note.md", string(rune('a'+i)), start.Add(time.Duration(i)*24*time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			now := start.Add(5 * 24 * time.Hour)
+
+			s.MaxVersions, s.Retention = tt.max, tt.retention
+			if err := s.Prune("note.md", now); err != nil {
+				t.Fatal(err)
+			}
+			got := contents(t, s, "note.md")
+			if len(got) != tt.want {
+				t.Errorf("%d versions left, want %d", len(got), tt.want)
+			}
+			if len(got) > 0 && got[0] != "e" {
+				t.Errorf("newest version left = %q, want e", got[0])
+			}
+		})
+	}
+}
+
+func TestSavePrunes(t *testing.T) {
+	s := NewStore(t.TempDir())
+	s.MaxVersions = 2
+	for i, content := range []string{"a", "b", "c"} {
+		if _, err := s.Save("note.md", content, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := contents(t, s, "note.md"); !slices.Equal(got, []string{"c", "b"}) {
+		t.Errorf("versions = %q, want c, b", got)
+	}
+}
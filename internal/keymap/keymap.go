@@ -0,0 +1,411 @@
+// Package keymap binds key sequences to named actions so bindings can be
+// changed from the config file. A sequence is one or more keys pressed one
+// after another, like vim's "gg" or a leader key followed by a letter.
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sequence is keys in the form bubbletea reports them, e.g. {"ctrl+x", "g"}
+type Sequence []string
+
+func (s Sequence) String() string {
+	return strings.Join(s, " ")
+}
+
+func (s Sequence) equal(o Sequence) bool {
+	if len(s) != len(o) {
+		return false
+	}
+	for i := range s {
+		if s[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefix reports whether p is a proper prefix of s
+func (s Sequence) hasPrefix(p Sequence) bool {
+	return len(p) < len(s) && s[:len(p)].equal(p)
+}
+
+// modifiers maps the ways a modifier may be written to bubbletea's form
+var modifiers = map[string]string{
+	"ctrl": "ctrl", "c": "ctrl", "control": "ctrl",
+	"alt": "alt", "m": "alt", "a": "alt", "meta": "alt", "option": "alt",
+	"shift": "shift", "s": "shift",
+}
+
+// keyAliases maps other names for keys to bubbletea's names
+var keyAliases = map[string]string{
+	"space": " ", "spc": " ", "<space>": " ",
+	"return": "enter", "cr": "enter", "<cr>": "enter",
+	"escape": "esc", "<esc>": "esc",
+	"del": "delete", "bs": "backspace",
+	"pagedown": "pgdown", "pgdn": "pgdown", "pageup": "pgup",
+	"<tab>": "tab",
+}
+
+// Parse reads a sequence of space-separated keys, such as "ctrl+x g" or
+// "<leader> f". Modifiers may also be written Emacs style, as in "C-x" or
+// "M-b". "<leader>" is replaced by leader.
+func Parse(text string, leader Sequence) (Sequence, error) {
+	var seq Sequence
+	for _, field := range strings.Fields(text) {
+		if strings.EqualFold(field, "<leader>") {
+			if len(leader) == 0 {
+				return nil, fmt.Errorf("%q uses <leader> but no leader key is set", text)
+			}
+			seq = append(seq, leader...)
+			continue
+		}
+		k, err := parseKey(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", text, err)
+		}
+		seq = append(seq, k)
+	}
+	if len(seq) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	return seq, nil
+}
+
+// MustParse is Parse for default bindings, which are known to be valid
+func MustParse(text string) Sequence {
+	seq, err := Parse(text, nil)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
+
+func parseKey(field string) (string, error) {
+	if alias, ok := keyAliases[strings.ToLower(field)]; ok {
+		return alias, nil
+	}
+	if utf8.RuneCountInString(field) == 1 {
+		return field, nil
+	}
+
+	// Split off modifiers, allowing "+" or "-" as the last key itself
+	var mods []string
+	rest := field
+	for {
+		i := strings.IndexAny(rest, "+-")
+		if i <= 0 || i == len(rest)-1 {
+			break
+		}
+		mod, ok := modifiers[strings.ToLower(rest[:i])]
+		if !ok {
+			break
+		}
+		mods = append(mods, mod)
+		rest = rest[i+1:]
+	}
+
+	base := rest
+	if alias, ok := keyAliases[strings.ToLower(base)]; ok {
+		base = alias
+	} else if utf8.RuneCountInString(base) > 1 {
+		base = strings.ToLower(base)
+	}
+	if base == " " && len(mods) > 0 {
+		base = "space" // bubbletea names modified space "ctrl+space"
+	}
+	for _, mod := range mods {
+		if mod == "ctrl" {
+			base = strings.ToLower(base) // terminals can't tell C-x from C-X
+		}
+	}
+
+	// bubbletea orders modifiers ctrl, alt, shift
+	sort.Slice(mods, func(i, j int) bool { return modOrder(mods[i]) < modOrder(mods[j]) })
+	for i := 1; i < len(mods); i++ {
+		if mods[i] == mods[i-1] {
+			return "", fmt.Errorf("modifier %s repeated in %q", mods[i], field)
+		}
+	}
+	if len(mods) == 0 && utf8.RuneCountInString(base) > 1 && !namedKey(base) {
+		return "", fmt.Errorf("unknown key %q (separate keys of a sequence with spaces)", field)
+	}
+	return strings.Join(append(mods, base), "+"), nil
+}
+
+func modOrder(mod string) int {
+	return strings.Index("ctrl alt shift", mod)
+}
+
+var namedKeys = []string{
+	"enter", "esc", "tab", "backspace", "delete", "insert", "up", "down", "left", "right",
+	"home", "end", "pgup", "pgdown",
+}
+
+func namedKey(k string) bool {
+	for _, n := range namedKeys {
+		if k == n {
+			return true
+		}
+	}
+	return len(k) >= 2 && k[0] == 'f' && strings.Trim(k[1:], "0123456789") == ""
+}
+
+// displayNames are short forms of keys for help text
+var displayNames = map[string]string{
+	" ": "SPC", "space": "SPC", "enter": "Enter", "esc": "Esc", "tab": "Tab",
+	"backspace": "BS", "delete": "Del", "insert": "Ins", "home": "Home", "end": "End",
+	"pgup": "PgUp", "pgdown": "PgDn", "up": "↑", "down": "↓", "left": "←", "right": "→",
+}
+
+// Format writes a sequence the way help text in this app does, e.g. "C-x g"
+func Format(seq Sequence) string {
+	parts := make([]string, len(seq))
+	for i, k := range seq {
+		parts[i] = formatKey(k)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatKey(k string) string {
+	var prefix string
+	for {
+		switch {
+		case strings.HasPrefix(k, "ctrl+") && len(k) > 5:
+			prefix += "C-"
+			k = k[5:]
+			continue
+		case strings.HasPrefix(k, "alt+") && len(k) > 4:
+			prefix += "M-"
+			k = k[4:]
+			continue
+		case strings.HasPrefix(k, "shift+") && len(k) > 6:
+			prefix += "S-"
+			k = k[6:]
+			continue
+		}
+		break
+	}
+	if name, ok := displayNames[k]; ok {
+		return prefix + name
+	}
+	if len(k) >= 2 && k[0] == 'f' && strings.Trim(k[1:], "0123456789") == "" {
+		return prefix + strings.ToUpper(k)
+	}
+	return prefix + k
+}
+
+// Map binds actions to sequences in one context, such as the editor's
+// normal mode
+type Map struct {
+	Name    string
+	actions []string
+	seqs    map[string][]Sequence
+	help    map[string]string
+	custom  map[string]bool // actions bound by Apply
+}
+
+func NewMap(name string) *Map {
+	return &Map{Name: name, seqs: make(map[string][]Sequence), help: make(map[string]string), custom: make(map[string]bool)}
+}
+
+// Bind sets the sequences of an action, replacing any it had, and its help
+// text unless help is empty
+func (m *Map) Bind(action, help string, seqs ...Sequence) {
+	if _, ok := m.seqs[action]; !ok {
+		m.actions = append(m.actions, action)
+	}
+	m.seqs[action] = seqs
+	if help != "" {
+		m.help[action] = help
+	}
+}
+
+// Actions returns the action names in the order they were bound
+func (m *Map) Actions() []string {
+	return m.actions
+}
+
+// Has reports whether action is known to the map
+func (m *Map) Has(action string) bool {
+	_, ok := m.seqs[action]
+	return ok
+}
+
+// Keys returns the sequences bound to action
+func (m *Map) Keys(action string) []Sequence {
+	return m.seqs[action]
+}
+
+// Help returns the description of action
+func (m *Map) Help(action string) string {
+	return m.help[action]
+}
+
+// Label is the first sequence of action formatted for help, or ""
+func (m *Map) Label(action string) string {
+	if seqs := m.seqs[action]; len(seqs) > 0 {
+		return Format(seqs[0])
+	}
+	return ""
+}
+
+// unbind removes seq from every action still using its default keys
+func (m *Map) unbind(seq Sequence) {
+	for action, seqs := range m.seqs {
+		if m.custom[action] {
+			continue
+		}
+		kept := seqs[:0:0]
+		for _, s := range seqs {
+			if !s.equal(seq) {
+				kept = append(kept, s)
+			}
+		}
+		m.seqs[action] = kept
+	}
+}
+
+// Apply rebinds the actions named in overrides, which maps action names to
+// sequences in Parse's syntax. A sequence the user binds is first taken
+// from the default bindings in maps, so rebinding a key moves it; two
+// actions the user binds to the same keys are left for Conflicts to report.
+// With allowNew, unknown action names are bound too; otherwise they are
+// reported. Problems are returned and the bad entries skipped.
+func (m *Map) Apply(overrides map[string][]string, leader Sequence, allowNew bool, maps ...*Map) []error {
+	var errs []error
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !allowNew && !m.Has(name) {
+			errs = append(errs, fmt.Errorf("%s: unknown action %q", m.Name, name))
+			continue
+		}
+		var seqs []Sequence
+		for _, text := range overrides[name] {
+			seq, err := Parse(text, leader)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: %w", m.Name, name, err))
+				continue
+			}
+			seqs = append(seqs, seq)
+		}
+		for _, seq := range seqs {
+			for _, other := range maps {
+				other.unbind(seq)
+			}
+		}
+		m.Bind(name, "", seqs...)
+		m.custom[name] = true
+	}
+	return errs
+}
+
+// Conflict is two actions whose bindings clash: the same sequence, or one
+// sequence starting another so the longer one can never be typed
+type Conflict struct {
+	Seq, Other       Sequence
+	Action, OtherAct string // qualified by map name, e.g. "editor.top"
+}
+
+func (c Conflict) Error() string {
+	if c.Seq.equal(c.Other) {
+		return fmt.Sprintf("%q is bound to both %s and %s", Format(c.Seq), c.Action, c.OtherAct)
+	}
+	return fmt.Sprintf("%q (%s) hides %q (%s)", Format(c.Seq), c.Action, Format(c.Other), c.OtherAct)
+}
+
+// Conflicts finds clashing bindings among maps that are active at the same
+// time
+func Conflicts(maps ...*Map) []Conflict {
+	type binding struct {
+		seq    Sequence
+		action string
+	}
+	var all []binding
+	for _, m := range maps {
+		for _, action := range m.actions {
+			for _, seq := range m.seqs[action] {
+				all = append(all, binding{seq, m.Name + "." + action})
+			}
+		}
+	}
+
+	var conflicts []Conflict
+	for i := range all {
+		for j := range all {
+			a, b := all[i], all[j]
+			if a.action == b.action {
+				continue
+			}
+			if (i < j && a.seq.equal(b.seq)) || b.seq.hasPrefix(a.seq) {
+				conflicts = append(conflicts, Conflict{Seq: a.seq, Other: b.seq, Action: a.action, OtherAct: b.action})
+			}
+		}
+	}
+	return conflicts
+}
+
+// Resolver matches keys against maps as they are pressed, remembering the
+// start of a sequence until it completes
+type Resolver struct {
+	pending Sequence
+}
+
+// Feed adds a pressed key. It returns the map and action of a completed
+// sequence, or pending while the keys so far start a longer one. When the
+// key neither completes nor continues a sequence, the keys before it are
+// dropped and it is tried on its own; if it is still not bound, Feed
+// returns nothing and the key should be handled as usual.
+func (r *Resolver) Feed(key string, maps ...*Map) (m *Map, action string, pending bool) {
+	keys := append(r.pending[:len(r.pending):len(r.pending)], key)
+	if m, action, pending = match(keys, maps); m != nil || pending {
+		r.pending = nil
+		if pending {
+			r.pending = keys
+		}
+		return m, action, pending
+	}
+
+	hadPending := len(r.pending) > 0
+	r.pending = nil
+	if hadPending {
+		return r.Feed(key, maps...)
+	}
+	return nil, "", false
+}
+
+func match(keys Sequence, maps []*Map) (*Map, string, bool) {
+	pending := false
+	for _, m := range maps {
+		for _, action := range m.actions {
+			for _, seq := range m.seqs[action] {
+				if seq.equal(keys) {
+					return m, action, false
+				}
+				if seq.hasPrefix(keys) {
+					pending = true
+				}
+			}
+		}
+	}
+	return nil, "", pending
+}
+
+// Pending returns the keys of an unfinished sequence
+func (r *Resolver) Pending() Sequence {
+	return r.pending
+}
+
+// Reset forgets an unfinished sequence
+func (r *Resolver) Reset() {
+	r.pending = nil
+}
@@ -0,0 +1,247 @@
+package keymap
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	leader := Sequence{" "}
+	tests := []struct {
+		text string
+		want Sequence
+		err  string
+	}{
+		{text: "g g", want: Sequence{"g", "g"}},
+		{text: "ctrl+x g", want: Sequence{"ctrl+x", "g"}},
+		{text: "C-x M-b", want: Sequence{"ctrl+x", "alt+b"}},
+		{text: "C-X", want: Sequence{"ctrl+x"}},
+		{text: "shift+alt+ctrl+Up", want: Sequence{"ctrl+alt+shift+up"}},
+		{text: "<leader> f", want: Sequence{" ", "f"}},
+		{text: "space Return ESC", want: Sequence{" ", "enter", "esc"}},
+		{text: "C-space", want: Sequence{"ctrl+space"}},
+		{text: "F12", want: Sequence{"f12"}},
+		{text: "+ -", want: Sequence{"+", "-"}},
+		{text: "ctrl+-", want: Sequence{"ctrl+-"}},
+		{text: "G", want: Sequence{"G"}},
+		{text: "gg", err: "unknown key"},
+		{text: "ctrl+ctrl+x", err: "repeated"},
+		{text: "  ", err: "empty"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text, leader)
+		switch {
+		case tt.err != "":
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.text, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("Parse(%q) error = %v", tt.text, err)
+		case !got.equal(tt.want):
+			t.Errorf("Parse(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := Parse("<leader> f", nil); err == nil {
+		t.Error("Parse() accepted <leader> without a leader key")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		seq  Sequence
+		want string
+	}{
+		{Sequence{"ctrl+x", "g"}, "C-x g"},
+		{Sequence{" ", "f"}, "SPC f"},
+		{Sequence{"ctrl+alt+shift+up"}, "C-M-S-↑"},
+		{Sequence{"f5", "pgdown"}, "F5 PgDn"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.seq); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.seq, got, tt.want)
+		}
+	}
+}
+
+// editorMap binds gg, G, dd, d and x
+func editorMap() *Map {
+	m := NewMap("editor")
+	m.Bind("top", "go to top", MustParse("g g"))
+	m.Bind("bottom", "go to bottom", MustParse("G"))
+	m.Bind("delete_line", "delete line", MustParse("d d"))
+	m.Bind("delete", "delete", MustParse("x"), MustParse("delete"))
+	return m
+}
+
+func TestResolverFeed(t *testing.T) {
+	m := editorMap()
+	leader := NewMap("leader")
+	leader.Bind("find", "find", MustParse("space f"))
+
+	type step struct {
+		key     string
+		action  string
+		pending bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"single key", []step{{"G", "bottom", false}}},
+		{"sequence", []step{{"g", "", true}, {"g", "top", false}}},
+		{"leader sequence", []step{{" ", "", true}, {"f", "find", false}}},
+		{"unbound key", []step{{"q", "", false}}},
+		// A key that breaks a sequence is tried on its own
+		{"broken sequence", []step{{"g", "", true}, {"x", "delete", false}}},
+		{"broken by the start of another", []step{{"g", "", true}, {"d", "", true}, {"d", "delete_line", false}}},
+		{"broken by an unbound key", []step{{"d", "", true}, {"q", "", false}, {"G", "bottom", false}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Resolver
+			for _, s := range tt.steps {
+				got, action, pending := r.Feed(s.key, m, leader)
+				if action != s.action || pending != s.pending {
+					t.Fatalf("Feed(%q) = %q, %v; want %q, %v", s.key, action, pending, s.action, s.pending)
+				}
+				if action != "" && got == nil {
+					t.Fatalf("Feed(%q) returned no map for %q", s.key, action)
+				}
+				if pending != (len(r.Pending()) > 0) {
+					t.Fatalf("Pending() = %q after Feed(%q)", r.Pending(), s.key)
+				}
+			}
+		})
+	}
+
+	// Reset drops the keys of an unfinished sequence
+	var r Resolver
+	r.Feed("g", m)
+	r.Reset()
+	if _, action, _ := r.Feed("G", m); action != "bottom" {
+		t.Errorf("Feed(G) after Reset = %q, want bottom", action)
+	}
+}
+
+func TestResolverPrefixAndExact(t *testing.T) {
+	// d is both a complete binding and the start of dd: the complete one
+	// wins, so dd can never be typed, which Conflicts reports
+	m := editorMap()
+	m.Bind("cut", "cut", MustParse("d"))
+
+	var r Resolver
+	if _, action, pending := r.Feed("d", m); action != "cut" || pending {
+		t.Errorf("Feed(d) = %q, %v; want cut", action, pending)
+	}
+
+	conflicts := Conflicts(m)
+	if len(conflicts) != 1 {
+		t.Fatalf("Conflicts() = %v, want one", conflicts)
+	}
+	c := conflicts[0]
+	if c.Action != "editor.cut" || c.OtherAct != "editor.delete_line" {
+		t.Errorf("conflict between %s and %s, want editor.cut hiding editor.delete_line", c.Action, c.OtherAct)
+	}
+	if want := `"d" (editor.cut) hides "d d" (editor.delete_line)`; c.Error() != want {
+		t.Errorf("Error() = %q, want %q", c.Error(), want)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	global := NewMap("global")
+	global.Bind("quit", "quit", MustParse("ctrl+q"))
+	global.Bind("search", "search", MustParse("/"))
+
+	tests := []struct {
+		name string
+		bind func(m *Map)
+		want []string
+	}{
+		{"none", func(*Map) {}, nil},
+		{
+			"same keys in two maps",
+			func(m *Map) { m.Bind("filter", "", MustParse("/")) },
+			[]string{`"/" is bound to both global.search and editor.filter`},
+		},
+		{
+			"prefix across maps",
+			func(m *Map) { m.Bind("quit_all", "", MustParse("ctrl+q a")) },
+			[]string{`"C-q" (global.quit) hides "C-q a" (editor.quit_all)`},
+		},
+		{
+			"one action with two sequences",
+			func(m *Map) { m.Bind("top", "", MustParse("g g"), MustParse("g")) },
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := editorMap()
+			tt.bind(m)
+			var got []string
+			for _, c := range Conflicts(global, m) {
+				got = append(got, c.Error())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Conflicts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	global := NewMap("global")
+	global.Bind("search", "search", MustParse("/"))
+	global.Bind("quit", "quit", MustParse("ctrl+q"))
+
+	m := editorMap()
+	errs := m.Apply(map[string][]string{
+		"delete": {"/"},                 // taken from global.search
+		"top":    {"<leader> g", "g g"}, // keeps its default too
+		"bogus":  {"z"},
+		"bottom": {"C-C-x"},
+	}, Sequence{" "}, false, global, m)
+
+	if len(errs) != 2 {
+		t.Errorf("Apply() problems = %v, want the unknown action and the bad key", errs)
+	}
+	if got := m.Keys("delete"); len(got) != 1 || got[0].String() != "/" {
+		t.Errorf("delete keys = %q, want only /", got)
+	}
+	if got := global.Keys("search"); len(got) != 0 {
+		t.Errorf("search keeps %q after delete took it", got)
+	}
+	if got := global.Label("quit"); got != "C-q" {
+		t.Errorf("quit = %q, want its default", got)
+	}
+	if got := m.Label("top"); got != "SPC g" {
+		t.Errorf("top = %q, want SPC g", got)
+	}
+	// A bad sequence leaves the action without keys rather than the default
+	if got := m.Keys("bottom"); len(got) != 0 {
+		t.Errorf("bottom keys = %q, want none", got)
+	}
+	if m.Has("bogus") {
+		t.Error("Apply() bound an unknown action")
+	}
+
+	// A user binding is not taken by a later one to the same keys: the
+	// clash is left for Conflicts
+	m.Apply(map[string][]string{"delete_line": {"/"}}, nil, false, global, m)
+	if got := m.Keys("delete"); len(got) != 1 {
+		t.Errorf("delete lost its user binding: %q", got)
+	}
+	if len(Conflicts(m)) == 0 {
+		t.Error("Conflicts() missed two user bindings of /")
+	}
+
+	cmds := NewMap("commands")
+	if errs := cmds.Apply(map[string][]string{"daily-note": {"<leader> d"}}, Sequence{" "}, true); len(errs) != 0 {
+		t.Fatalf("Apply(allowNew) problems = %v", errs)
+	}
+	if !slices.Equal(cmds.Actions(), []string{"daily-note"}) {
+		t.Errorf("Actions() = %q, want daily-note", cmds.Actions())
+	}
+}
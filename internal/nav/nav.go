@@ -0,0 +1,157 @@
+// Package nav records where the user has been: a browser-style back/forward
+// history of locations and a list of recently accessed notes.
+package nav
+
+import (
+	"time"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// DefaultMaxEntries bounds the back/forward history
+const DefaultMaxEntries = 100
+
+// DefaultMaxRecent bounds the recent files list
+const DefaultMaxRecent = 50
+
+// Location is a position in a note
+type Location struct {
+	Path string
+	Line int // 0-based
+	Col  int
+	Time time.Time
+}
+
+// History is a list of visited locations with a current entry, like a
+// browser's back and forward buttons
+type History struct {
+	entries []Location
+	index   int // current entry, -1 when empty
+	max     int
+}
+
+func NewHistory() *History {
+	return &History{index: -1, max: DefaultMaxEntries}
+}
+
+// Restore replaces the history with saved entries
+func (h *History) Restore(entries []Location, index int) {
+	h.entries = append([]Location(nil), entries...)
+	h.index = min(max(index, 0), len(h.entries)-1)
+}
+
+// Entries returns the visited locations, oldest first
+func (h *History) Entries() []Location {
+	return h.entries
+}
+
+// Index returns the current entry, or -1
+func (h *History) Index() int {
+	return h.index
+}
+
+// Current returns the current entry
+func (h *History) Current() (Location, bool) {
+	if h.index < 0 {
+		return Location{}, false
+	}
+	return h.entries[h.index], true
+}
+
+// Update records the position within the current entry, e.g. before leaving it
+func (h *History) Update(loc Location) {
+	if h.index >= 0 && h.entries[h.index].Path == loc.Path {
+		h.entries[h.index] = loc
+	}
+}
+
+// Visit adds loc after the current entry, dropping any forward entries.
+// Visiting the current location again does nothing.
+func (h *History) Visit(loc Location) {
+	if cur, ok := h.Current(); ok && cur.Path == loc.Path && cur.Line == loc.Line {
+		return
+	}
+
+	h.entries = append(h.entries[:h.index+1], loc)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+	h.index = len(h.entries) - 1
+}
+
+// Back moves to the previous entry, first saving cur as the position of the
+// current one
+func (h *History) Back(cur Location) (Location, bool) {
+	return h.Go(h.index-1, cur)
+}
+
+// Forward moves to the next entry, first saving cur as the position of the
+// current one
+func (h *History) Forward(cur Location) (Location, bool) {
+	return h.Go(h.index+1, cur)
+}
+
+// Go moves to entry i, as picked from a jump list
+func (h *History) Go(i int, cur Location) (Location, bool) {
+	if i < 0 || i >= len(h.entries) {
+		return Location{}, false
+	}
+	h.Update(cur)
+	h.index = i
+	return h.entries[i], true
+}
+
+// Rename points entries at a note or folder that moved from oldPath to
+// newPath, keeping their positions
+func (h *History) Rename(oldPath, newPath string) {
+	for i := range h.entries {
+		h.entries[i].Path = vault.RenamedPath(h.entries[i].Path, oldPath, newPath)
+	}
+}
+
+// Recent lists notes by last access, most recent first
+type Recent struct {
+	paths []string
+	max   int
+}
+
+func NewRecent() *Recent {
+	return &Recent{max: DefaultMaxRecent}
+}
+
+// Restore replaces the list with saved paths
+func (r *Recent) Restore(paths []string) {
+	r.paths = append([]string(nil), paths...)
+}
+
+// Touch moves path to the front of the list
+func (r *Recent) Touch(path string) {
+	r.Remove(path)
+	r.paths = append([]string{path}, r.paths...)
+	if len(r.paths) > r.max {
+		r.paths = r.paths[:r.max]
+	}
+}
+
+// Remove drops path from the list, e.g. when the note is deleted
+func (r *Recent) Remove(path string) {
+	for i, p := range r.paths {
+		if p == path {
+			r.paths = append(r.paths[:i], r.paths[i+1:]...)
+			return
+		}
+	}
+}
+
+// Rename updates the list after a note or folder moved from oldPath to
+// newPath
+func (r *Recent) Rename(oldPath, newPath string) {
+	for i := range r.paths {
+		r.paths[i] = vault.RenamedPath(r.paths[i], oldPath, newPath)
+	}
+}
+
+// Paths returns the notes, most recent first
+func (r *Recent) Paths() []string {
+	return r.paths
+}
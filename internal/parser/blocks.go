@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strings"
+)
+
+var blockIDRe = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)\s*$`)
+
+// BlockID is a block marked with ^id, which [[note#^id]] links to
+type BlockID struct {
+	ID   string
+	Line int    // the block's first line
+	Text string // the block's first line, for showing it
+}
+
+// ExtractBlockIDs returns the ^id markers outside code blocks, in order
+func ExtractBlockIDs(content string) []BlockID {
+	lines := strings.Split(content, "\n")
+	code := codeLines(lines)
+	var ids []BlockID
+	for i, line := range lines {
+		m := blockIDRe.FindStringSubmatch(line)
+		if m == nil || code[i] {
+			continue
+		}
+		start, end := blockRange(lines, code, i)
+		text := ""
+		for _, l := range lines[start:end] {
+			if text = strings.TrimSpace(StripBlockID(l)); text != "" {
+				break
+			}
+		}
+		ids = append(ids, BlockID{ID: m[1], Line: start, Text: text})
+	}
+	return ids
+}
+
+// BlockAnchor is where the ^id marker of a block is or goes
+type BlockAnchor struct {
+	Line int    // the line ending with the marker, or the block's last line
+	Own  bool   // the marker is on a line of its own after Line
+	ID   string // the block's id, if it has one
+}
+
+// FindBlockAnchor finds the block at line: a paragraph or a list item, which
+// take the marker at the end of their last line, or a table, a quote or a code
+// block, which take it on a line of its own after them. Headings have none, as
+// links go to them by name.
+func FindBlockAnchor(lines []string, line int) (BlockAnchor, bool) {
+	if line < 0 || line >= len(lines) {
+		return BlockAnchor{}, false
+	}
+	code := codeLines(lines)
+	text := strings.TrimSpace(lines[line])
+
+	// On a marker of its own, the block is the one before it
+	if m := blockIDRe.FindStringSubmatch(text); m != nil && !code[line] && strings.TrimSpace(StripBlockID(text)) == "" {
+		prev := line - 1
+		for prev >= 0 && strings.TrimSpace(lines[prev]) == "" {
+			prev--
+		}
+		if prev < 0 {
+			return BlockAnchor{}, false
+		}
+		return BlockAnchor{Line: prev, Own: true, ID: m[1]}, true
+	}
+	if text == "" || (headingLevel(lines[line]) > 0 && !code[line]) {
+		return BlockAnchor{}, false
+	}
+
+	a := BlockAnchor{Line: line}
+	switch {
+	case code[line]:
+		a.Line, a.Own = codeBlockEnd(lines, code, line), true
+	case strings.HasPrefix(text, ">") || strings.HasPrefix(text, "|"):
+		for a.Line+1 < len(lines) && !code[a.Line+1] && strings.HasPrefix(strings.TrimSpace(lines[a.Line+1]), text[:1]) {
+			a.Line++
+		}
+		a.Own = true
+	case listItemRe.MatchString(lines[line]):
+	default:
+		for a.Line+1 < len(lines) && !code[a.Line+1] && !endsParagraph(lines[a.Line+1]) {
+			a.Line++
+		}
+	}
+
+	if !a.Own {
+		if m := blockIDRe.FindStringSubmatch(lines[a.Line]); m != nil {
+			a.ID = m[1]
+		}
+		return a, true
+	}
+	for next := a.Line + 1; next < len(lines); next++ {
+		t := strings.TrimSpace(lines[next])
+		if t == "" {
+			continue
+		}
+		if m := blockIDRe.FindStringSubmatch(t); m != nil && strings.TrimSpace(StripBlockID(t)) == "" {
+			a.ID = m[1]
+		}
+		break
+	}
+	return a, true
+}
+
+// AddBlockID marks the block at a with id. It returns the new lines and how
+// many were inserted after a.Line.
+func AddBlockID(lines []string, a BlockAnchor, id string) ([]string, int) {
+	out := append([]string(nil), lines...)
+	if !a.Own {
+		out[a.Line] = strings.TrimRight(out[a.Line], " \t") + " ^" + id
+		return out, 0
+	}
+
+	insert := []string{"", "^" + id}
+	if a.Line+1 < len(lines) && strings.TrimSpace(lines[a.Line+1]) != "" {
+		insert = append(insert, "")
+	}
+	out = append(out[:a.Line+1], append(insert, lines[a.Line+1:]...)...)
+	return out, len(insert)
+}
+
+// NewBlockID returns a random id, like Obsidian's, that lines don't use yet
+func NewBlockID(lines []string) string {
+	return newBlockID(lines, randomBlockID)
+}
+
+// newBlockID draws ids from random until one is unused
+func newBlockID(lines []string, random func() string) string {
+	used := make(map[string]bool)
+	for _, b := range ExtractBlockIDs(strings.Join(lines, "\n")) {
+		used[strings.ToLower(b.ID)] = true
+	}
+	for {
+		if id := random(); !used[id] {
+			return id
+		}
+	}
+}
+
+func randomBlockID() string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	id := make([]byte, 6)
+	for i := range id {
+		id[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(id)
+}
+
+// StripBlockID removes a ^id marker from the end of a line
+func StripBlockID(line string) string {
+	if loc := blockIDRe.FindStringIndex(line); loc != nil {
+		return strings.TrimRight(line[:loc[0]], " \t")
+	}
+	return line
+}
+
+// endsParagraph reports whether line isn't part of the paragraph above it
+func endsParagraph(line string) bool {
+	return strings.TrimSpace(line) == "" || headingLevel(line) > 0 || listItemRe.MatchString(line) || isFence(line)
+}
+
+// codeBlockEnd returns the closing fence of the code block at line
+func codeBlockEnd(lines []string, code []bool, line int) int {
+	open := false
+	for i, l := range lines {
+		if !code[i] || !isFence(l) {
+			continue
+		}
+		if open && i >= line {
+			return i
+		}
+		open = !open
+	}
+	return len(lines) - 1
+}
+
+func blockLine(lines []string, code []bool, id string) int {
+	for i, line := range lines {
+		if m := blockIDRe.FindStringSubmatch(line); m != nil && !code[i] && strings.EqualFold(m[1], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// blockRange returns the block the marker at line belongs to
+func blockRange(lines []string, code []bool, line int) (start, end int) {
+	if strings.TrimSpace(StripBlockID(lines[line])) != "" {
+		if m := listItemRe.FindStringSubmatch(lines[line]); m != nil {
+			return line, listItemEnd(lines, line, indentWidth(m[1])) + 1
+		}
+		if headingLevel(lines[line]) > 0 {
+			return line, line + 1
+		}
+		end = line + 1
+	} else {
+		// A marker on its own line, after a table, a quote or a code block
+		end = line
+		for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+	}
+
+	start = end
+	for start > 0 {
+		prev := lines[start-1]
+		if code[start-1] {
+			start--
+			continue
+		}
+		if strings.TrimSpace(prev) == "" || headingLevel(prev) > 0 {
+			break
+		}
+		start--
+	}
+	return start, end
+}
+
+// StripBlockIDs removes the ^id markers outside code blocks, which Obsidian
+// hides when reading. Markers on their own line leave the line blank.
+func StripBlockIDs(content string) string {
+	return ReplaceBlockIDs(content, func(string) string { return "" })
+}
+
+// ReplaceBlockIDs is StripBlockIDs but puts what anchor returns for the id
+// at the end of the marker's line, or alone on it
+func ReplaceBlockIDs(content string, anchor func(id string) string) string {
+	lines := strings.Split(content, "\n")
+	code := codeLines(lines)
+	for i, line := range lines {
+		m := blockIDRe.FindStringSubmatch(line)
+		if code[i] || m == nil {
+			continue
+		}
+		text, a := StripBlockID(line), anchor(m[1])
+		switch {
+		case a == "":
+		case strings.TrimSpace(text) == "":
+			text = a
+		default:
+			text += " " + a
+		}
+		lines[i] = text
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var blockNote = []string{
+	"# Title",          // 0
+	"A paragraph",      // 1
+	"that ends ^para",  // 2
+	"",                 // 3
+	"- item one ^one",  // 4
+	"  - nested",       // 5
+	"- item two",       // 6
+	"",                 // 7
+	"```",              // 8
+	"code ^notid",      // 9
+	"```",              // 10
+	"",                 // 11
+	"> quote",          // 12
+	"",                 // 13
+	"^quote",           // 14
+	"## Heading ^head", // 15
+}
+
+func TestExtractBlockIDs(t *testing.T) {
+	want := []BlockID{
+		{ID: "para", Line: 1, Text: "A paragraph"},
+		{ID: "one", Line: 4, Text: "- item one"},
+		{ID: "quote", Line: 12, Text: "> quote"},
+		{ID: "head", Line: 15, Text: "## Heading"},
+	}
+	if got := ExtractBlockIDs(strings.Join(blockNote, "\n")); !slices.Equal(got, want) {
+		t.Errorf("ExtractBlockIDs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindBlockAnchor(t *testing.T) {
+	tests := []struct {
+		name string
+		line int
+		want BlockAnchor
+		ok   bool
+	}{
+		{"paragraph with an id", 1, BlockAnchor{Line: 2, ID: "para"}, true},
+		{"list item with an id", 4, BlockAnchor{Line: 4, ID: "one"}, true},
+		{"nested list item", 5, BlockAnchor{Line: 5}, true},
+		{"list item", 6, BlockAnchor{Line: 6}, true},
+		{"inside a code fence", 9, BlockAnchor{Line: 10, Own: true}, true},
+		{"quote with an id of its own", 12, BlockAnchor{Line: 12, Own: true, ID: "quote"}, true},
+		{"on the marker", 14, BlockAnchor{Line: 12, Own: true, ID: "quote"}, true},
+		{"heading", 0, BlockAnchor{}, false},
+		{"blank line", 3, BlockAnchor{}, false},
+		{"past the end", len(blockNote), BlockAnchor{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FindBlockAnchor(blockNote, tt.line)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("FindBlockAnchor(%d) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAddBlockID(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		line     int
+		want     []string
+		inserted int
+	}{
+		{
+			name:  "paragraph",
+			lines: []string{"first", "last line  ", "", "after"},
+			line:  0,
+			want:  []string{"first", "last line ^abc", "", "after"},
+		},
+		{
+			name:  "list item",
+			lines: []string{"- one", "- two"},
+			line:  1,
+			want:  []string{"- one", "- two ^abc"},
+		},
+		{
+			name:     "quote followed by text",
+			lines:    []string{"> q", "> r", "next"},
+			line:     0,
+			want:     []string{"> q", "> r", "", "^abc", "", "next"},
+			inserted: 3,
+		},
+		{
+			name:     "code block at the end",
+			lines:    []string{"```", "^x in code", "```"},
+			line:     1,
+			want:     []string{"```", "^x in code", "```", "", "^abc"},
+			inserted: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.lines)
+			a, ok := FindBlockAnchor(tt.lines, tt.line)
+			if !ok || a.ID != "" {
+				t.Fatalf("FindBlockAnchor() = %+v, %v", a, ok)
+			}
+			got, inserted := AddBlockID(tt.lines, a, "abc")
+			if !slices.Equal(got, tt.want) || inserted != tt.inserted {
+				t.Errorf("AddBlockID() = %q, %d, want %q, %d", got, inserted, tt.want, tt.inserted)
+			}
+			if !slices.Equal(tt.lines, before) {
+				t.Errorf("AddBlockID() changed its input to %q", tt.lines)
+			}
+
+			// The block now has the id
+			if a, _ := FindBlockAnchor(got, tt.line); a.ID != "abc" {
+				t.Errorf("FindBlockAnchor() after AddBlockID() = %+v, want the id abc", a)
+			}
+		})
+	}
+}
+
+func TestNewBlockIDCollision(t *testing.T) {
+	lines := []string{"text ^ABC123", "```", "code ^def456", "```"}
+	ids := []string{"abc123", "def456"}
+	random := func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+	// Ids are compared without case; markers in code don't count
+	if got := newBlockID(lines, random); got != "def456" {
+		t.Errorf("newBlockID() = %q, want def456", got)
+	}
+
+	if id := NewBlockID(lines); len(id) != 6 || strings.ToLower(id) != id {
+		t.Errorf("NewBlockID() = %q, want six lower-case letters or digits", id)
+	}
+}
+
+func TestDuplicateBlockIDs(t *testing.T) {
+	// Links go to the first block with the id
+	lines := []string{"first ^dup", "", "second ^DUP"}
+	if got := SubpathLine(lines, "#^dup"); got != 0 {
+		t.Errorf("SubpathLine(#^dup) = %d, want 0", got)
+	}
+	if got := len(ExtractBlockIDs(strings.Join(lines, "\n"))); got != 2 {
+		t.Errorf("ExtractBlockIDs() found %d ids, want both", got)
+	}
+}
+
+func TestReplaceBlockIDs(t *testing.T) {
+	got := ReplaceBlockIDs(strings.Join(blockNote, "\n"), func(id string) string { return "[" + id + "]" })
+	want := slices.Clone(blockNote)
+	want[2] = "that ends [para]"
+	want[4] = "- item one [one]"
+	want[14] = "[quote]"
+	want[15] = "## Heading [head]"
+	if got != strings.Join(want, "\n") {
+		t.Errorf("ReplaceBlockIDs() =\n%s", got)
+	}
+
+	want[2], want[4], want[14], want[15] = "that ends", "- item one", "", "## Heading"
+	if got := StripBlockIDs(strings.Join(blockNote, "\n")); got != strings.Join(want, "\n") {
+		t.Errorf("StripBlockIDs() =\n%s", got)
+	}
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CalloutOpen and CalloutClosed end the titles of callouts that fold
+const (
+	CalloutOpen   = "▾"
+	CalloutClosed = "▸"
+)
+
+// CalloutMark starts the rendered title of every callout that folds, and is
+// nowhere else in what MarkdownRenderer returns, so the titles can be found.
+// It takes the place of a space.
+const CalloutMark = "\uE001"
+
+var calloutRe = regexp.MustCompile(`^\[!([A-Za-z0-9_-]+)\]([+-]?)(?:\s+(.*))?$`)
+
+// Callout is a blockquote starting with [!type], like > [!warning]- Title
+type Callout struct {
+	Type  string // lower-cased
+	Title string // the title given, or the type's own
+	Fold  string // + or - for callouts that fold, open or closed
+	Start int    // the line of the [!type] marker
+	End   int    // last line, inclusive
+	Depth int    // 1 for callouts outside any other
+}
+
+// CalloutKind is how a type of callout looks
+type CalloutKind struct {
+	Icon  string
+	Color string // terminal colour, as for lipgloss.Color
+}
+
+var (
+	noteCallout    = CalloutKind{Icon: "✎", Color: "33"}
+	calloutAliases = map[string]string{
+		"summary": "abstract", "tldr": "abstract",
+		"hint": "tip", "important": "tip",
+		"check": "success", "done": "success",
+		"help": "question", "faq": "question",
+		"caution": "warning", "attention": "warning",
+		"fail": "failure", "missing": "failure",
+		"error": "danger",
+		"cite":  "quote",
+	}
+	calloutKinds = map[string]CalloutKind{
+		"note":     noteCallout,
+		"abstract": {Icon: "☰", Color: "37"},
+		"info":     {Icon: "ℹ", Color: "33"},
+		"todo":     {Icon: "☐", Color: "33"},
+		"tip":      {Icon: "★", Color: "43"},
+		"success":  {Icon: "✔", Color: "35"},
+		"question": {Icon: "?", Color: "178"},
+		"warning":  {Icon: "⚠", Color: "208"},
+		"failure":  {Icon: "✘", Color: "196"},
+		"danger":   {Icon: "ϟ", Color: "196"},
+		"bug":      {Icon: "✱", Color: "196"},
+		"example":  {Icon: "◆", Color: "141"},
+		"quote":    {Icon: "❝", Color: "245"},
+	}
+)
+
+// LookupCallout returns how callouts of a type look. Unknown types look like
+// notes, as in Obsidian.
+func LookupCallout(typ string) CalloutKind {
+	typ = strings.ToLower(typ)
+	if alias, ok := calloutAliases[typ]; ok {
+		typ = alias
+	}
+	if kind, ok := calloutKinds[typ]; ok {
+		return kind
+	}
+	return noteCallout
+}
+
+// ParseCallout parses a callout's first line, with or without its > markers
+func ParseCallout(line string) (Callout, bool) {
+	depth, rest := unquote(line)
+	c, ok := parseMarker(rest)
+	c.Depth = depth
+	return c, ok
+}
+
+// parseMarker parses the [!type] marker a callout's first line starts with
+// once its > markers are gone
+func parseMarker(text string) (Callout, bool) {
+	m := calloutRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return Callout{}, false
+	}
+	c := Callout{Type: strings.ToLower(m[1]), Title: strings.TrimSpace(m[3]), Fold: m[2]}
+	if c.Title == "" {
+		c.Title = strings.ToUpper(m[1][:1]) + strings.ToLower(m[1][1:])
+	}
+	return c, true
+}
+
+// FindCallouts returns the callouts of a note's lines in order, with those
+// nested in others after them. Callouts in plain quotes are left out, as they
+// show as text.
+func FindCallouts(lines []string) []Callout {
+	return findCallouts(lines, 0, 1)
+}
+
+func findCallouts(lines []string, offset, depth int) []Callout {
+	var callouts []Callout
+	code := codeLines(lines)
+	for i := 0; i < len(lines); i++ {
+		if code[i] || !strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+			continue
+		}
+		c, ok := parseMarker(stripQuote(lines[i]))
+		end := i
+		for end+1 < len(lines) && !code[end+1] && strings.HasPrefix(strings.TrimSpace(lines[end+1]), ">") {
+			end++
+		}
+		if ok {
+			c.Start, c.End, c.Depth = offset+i, offset+end, depth
+			callouts = append(callouts, c)
+			callouts = append(callouts, findCallouts(calloutBody(lines[i:end+1]), offset+i+1, depth+1)...)
+		}
+		i = end
+	}
+	return callouts
+}
+
+// calloutBody returns the lines after a callout's first one, without the
+// quote marker they share with it
+func calloutBody(lines []string) []string {
+	body := make([]string, 0, len(lines))
+	for _, line := range lines[1:] {
+		body = append(body, stripQuote(line))
+	}
+	return body
+}
+
+// ToggleCallouts opens the closed callouts starting at the given lines and
+// closes the open ones
+func ToggleCallouts(content string, starts []int) string {
+	if len(starts) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for _, s := range starts {
+		if s < 0 || s >= len(lines) {
+			continue
+		}
+		line := lines[s]
+		i := strings.Index(line, "[!")
+		if i < 0 {
+			continue
+		}
+		j := i + strings.IndexByte(line[i:], ']')
+		if j < i || j+1 >= len(line) {
+			continue
+		}
+		switch line[j+1] {
+		case '-':
+			lines[s] = line[:j+1] + "+" + line[j+2:]
+		case '+':
+			lines[s] = line[:j+1] + "-" + line[j+2:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripQuote removes one > marker and the space after it
+func stripQuote(line string) string {
+	rest := strings.TrimLeft(line, " \t")
+	rest, ok := strings.CutPrefix(rest, ">")
+	if !ok {
+		return line
+	}
+	return strings.TrimPrefix(rest, " ")
+}
+
+// unquote removes every > marker, returning how many there were
+func unquote(line string) (int, string) {
+	depth := 0
+	for {
+		rest := strings.TrimLeft(line, " \t")
+		if !strings.HasPrefix(rest, ">") {
+			return depth, line
+		}
+		line = rest[1:]
+		depth++
+	}
+}
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var listItemRe = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])(?:\s|$)`)
+
+// Fold is a range of lines that can be collapsed to its first line: a
+// heading's section, a list item with the lines nested under it, or a
+// fenced code block
+type Fold struct {
+	Start int // the line left showing
+	End   int // last hidden line, inclusive
+}
+
+// FindFolds returns the folds of a note's lines ordered by start, outer
+// folds first
+func FindFolds(lines []string) []Fold {
+	var folds []Fold
+
+	// Code blocks first, as nothing inside them folds
+	code := codeLines(lines)
+	open := -1
+	for i, line := range lines {
+		if !code[i] || !isFence(line) {
+			continue
+		}
+		if open < 0 {
+			open = i
+			continue
+		}
+		if i > open+1 {
+			folds = append(folds, Fold{Start: open, End: i})
+		}
+		open = -1
+	}
+
+	for i, line := range lines {
+		if code[i] {
+			continue
+		}
+		if level := headingLevel(line); level > 0 {
+			end := len(lines) - 1
+			for j := i + 1; j < len(lines); j++ {
+				if l := headingLevel(lines[j]); l > 0 && l <= level && !code[j] {
+					end = j - 1
+					break
+				}
+			}
+			for end > i && strings.TrimSpace(lines[end]) == "" {
+				end--
+			}
+			if end > i {
+				folds = append(folds, Fold{Start: i, End: end})
+			}
+			continue
+		}
+		if m := listItemRe.FindStringSubmatch(line); m != nil {
+			if end := listItemEnd(lines, i, indentWidth(m[1])); end > i {
+				folds = append(folds, Fold{Start: i, End: end})
+			}
+		}
+	}
+
+	sort.SliceStable(folds, func(i, j int) bool {
+		if folds[i].Start != folds[j].Start {
+			return folds[i].Start < folds[j].Start
+		}
+		return folds[i].End > folds[j].End
+	})
+	return folds
+}
+
+// codeLines marks the lines of fenced code blocks, fences included
+func codeLines(lines []string) []bool {
+	code := make([]bool, len(lines))
+	inCode := false
+	for i, line := range lines {
+		if isFence(line) {
+			code[i] = true
+			inCode = !inCode
+			continue
+		}
+		code[i] = inCode
+	}
+	return code
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// listItemEnd returns the last line nested under the list item at start,
+// not counting blank lines after it
+func listItemEnd(lines []string, start, indent int) int {
+	end := start
+	for j := start + 1; j < len(lines); j++ {
+		line := lines[j]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indentWidth(line[:len(line)-len(strings.TrimLeft(line, " \t"))]) <= indent {
+			break
+		}
+		end = j
+	}
+	return end
+}
+
+func indentWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r == '\t' {
+			w += 4
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// headingLevel returns the level of an ATX heading line, or 0
+func headingLevel(line string) int {
+	trimmed := strings.TrimSpace(line)
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level == 0 || level > 6 || strings.TrimSpace(trimmed[level:]) == "" {
+		return 0
+	}
+	if rest := trimmed[level:]; rest[0] != ' ' && rest[0] != '\t' {
+		return 0
+	}
+	return level
+}
+
+// FoldSummary stands for n hidden lines
+func FoldSummary(n int) string {
+	if n == 1 {
+		return "⋯ 1 line"
+	}
+	return fmt.Sprintf("⋯ %d lines", n)
+}
+
+// CollapseFolds drops the hidden lines of the folds starting at the given
+// lines, leaving a marker, so rendered markdown shows the same folds as the
+// editor. Code blocks keep their fences so the rest still renders.
+func CollapseFolds(content string, starts []int) string {
+	if len(starts) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	closed := make(map[int]bool, len(starts))
+	for _, s := range starts {
+		closed[s] = true
+	}
+
+	var out []string
+	skipTo := -1
+	for _, f := range FindFolds(lines) {
+		if !closed[f.Start] || f.Start <= skipTo {
+			continue
+		}
+		out = append(out, lines[skipTo+1:f.Start]...)
+		hidden := f.End - f.Start
+		if isFence(lines[f.Start]) {
+			out = append(out, lines[f.Start], FoldSummary(hidden-1), lines[f.End])
+		} else {
+			out = append(out, lines[f.Start]+" ⋯")
+		}
+		skipTo = f.End
+	}
+	out = append(out, lines[skipTo+1:]...)
+	return strings.Join(out, "\n")
+}
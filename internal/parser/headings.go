@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// Heading is a markdown heading
+type Heading struct {
+	Text  string
+	Level int
+	Line  int // 0-indexed line number
+}
+
+// ExtractHeadings returns the ATX headings outside code blocks
+func ExtractHeadings(content string) []Heading {
+	var headings []Heading
+	lines := strings.Split(content, "\n")
+
+	inCodeBlock := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+
+		if inCodeBlock {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		level := 0
+		for _, ch := range trimmed {
+			if ch == '#' {
+				level++
+			} else {
+				break
+			}
+		}
+
+		if level > 0 && level <= 6 {
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			if text != "" {
+				headings = append(headings, Heading{
+					Text:  text,
+					Level: level,
+					Line:  i,
+				})
+			}
+		}
+	}
+
+	return headings
+}
+
+// ExtractAliases returns the aliases listed in the frontmatter, written
+// either inline (aliases: [a, b] or aliases: a, b) or as a block list
+func ExtractAliases(content string) []string {
+	return ExtractListProperty(content, "aliases", "alias")
+}
+
+// ExtractListProperty returns the items of a frontmatter list under any of
+// keys, written like aliases
+func ExtractListProperty(content string, keys ...string) []string {
+	if !strings.HasPrefix(content, "---") {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	var items []string
+	inList := false
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" {
+			break
+		}
+
+		if inList {
+			if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+				items = appendAlias(items, item)
+				continue
+			}
+			if trimmed == "" {
+				continue
+			}
+			inList = false
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if key = strings.TrimSpace(key); !slices.Contains(keys, key) {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			inList = true
+			continue
+		}
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		for _, item := range strings.Split(value, ",") {
+			items = appendAlias(items, item)
+		}
+	}
+
+	return items
+}
+
+func appendAlias(aliases []string, item string) []string {
+	item = strings.Trim(strings.TrimSpace(item), `"'`)
+	if item == "" {
+		return aliases
+	}
+	return append(aliases, item)
+}
@@ -4,11 +4,18 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
+var calloutBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	MarginLeft(2)
+
 type MarkdownRenderer struct {
 	renderer *glamour.TermRenderer
 	width    int
+	inner    *MarkdownRenderer // for what is inside callouts, made when needed
 }
 
 func NewMarkdownRenderer(width int) (*MarkdownRenderer, error) {
@@ -25,8 +32,106 @@ func NewMarkdownRenderer(width int) (*MarkdownRenderer, error) {
 }
 
 func (m *MarkdownRenderer) Render(content string) (string, error) {
-	content = RenderTeX(content)
-	return m.renderer.Render(content)
+	content = RenderTeX(strings.ReplaceAll(content, CalloutMark, ""))
+	return m.render(content)
+}
+
+// render draws callouts as boxes, leaving the rest to glamour
+func (m *MarkdownRenderer) render(content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	var callouts []Callout
+	for _, c := range FindCallouts(lines) {
+		if c.Depth == 1 {
+			callouts = append(callouts, c)
+		}
+	}
+	if len(callouts) == 0 {
+		return m.renderer.Render(content)
+	}
+
+	var parts []string
+	prev := 0
+	for _, c := range callouts {
+		text, err := m.renderText(lines[prev:c.Start])
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			parts = append(parts, text)
+		}
+		box, err := m.renderCallout(c, lines[c.Start:c.End+1])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, box)
+		prev = c.End + 1
+	}
+	text, err := m.renderText(lines[prev:])
+	if err != nil {
+		return "", err
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return "\n" + strings.Join(parts, "\n\n") + "\n\n", nil
+}
+
+// renderText renders lines with glamour, without the blank rows around them
+func (m *MarkdownRenderer) renderText(lines []string) (string, error) {
+	text := strings.Join(lines, "\n")
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+	out, err := m.renderer.Render(text)
+	if err != nil {
+		return "", err
+	}
+	return trimBlankRows(out), nil
+}
+
+// renderCallout draws a callout as a box in its type's colour, with its
+// icon and title on top and nothing else when it is folded
+func (m *MarkdownRenderer) renderCallout(c Callout, lines []string) (string, error) {
+	kind := LookupCallout(c.Type)
+	color := lipgloss.Color(kind.Color)
+
+	title := "  " + kind.Icon + " " + c.Title
+	switch c.Fold {
+	case "-":
+		title = CalloutMark + title[1:] + " " + CalloutClosed
+	case "+":
+		title = CalloutMark + title[1:] + " " + CalloutOpen
+	}
+	rows := []string{lipgloss.NewStyle().Bold(true).Foreground(color).Render(title)}
+
+	if body := strings.Join(calloutBody(lines), "\n"); c.Fold != "-" && strings.TrimSpace(body) != "" {
+		if m.inner == nil {
+			inner, err := NewMarkdownRenderer(m.width - 5)
+			if err != nil {
+				return "", err
+			}
+			m.inner = inner
+		}
+		out, err := m.inner.render(body)
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, trimBlankRows(out))
+	}
+	return calloutBoxStyle.BorderForeground(color).Width(m.width - 6).Render(strings.Join(rows, "\n")), nil
+}
+
+// trimBlankRows drops the blank rows glamour puts before and after its output
+func trimBlankRows(s string) string {
+	rows := strings.Split(s, "\n")
+	blank := func(row string) bool { return strings.TrimSpace(ansi.Strip(row)) == "" }
+	for len(rows) > 0 && blank(rows[0]) {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && blank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return strings.Join(rows, "\n")
 }
 
 func (m *MarkdownRenderer) RenderWithTeX(content string) (string, error) {
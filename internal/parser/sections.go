@@ -0,0 +1,138 @@
+package parser
+
+import "strings"
+
+// Section is a heading with everything under it, up to the next heading of
+// the same or a higher level
+type Section struct {
+	Start int // the heading's line
+	End   int // first line after the section
+	Level int
+}
+
+// FindSection returns the section of the heading at line
+func FindSection(lines []string, line int) (Section, bool) {
+	return findSection(lines, codeLines(lines), line)
+}
+
+func findSection(lines []string, code []bool, line int) (Section, bool) {
+	if line < 0 || line >= len(lines) || code[line] {
+		return Section{}, false
+	}
+	level := headingLevel(lines[line])
+	if level == 0 {
+		return Section{}, false
+	}
+	s := Section{Start: line, End: len(lines), Level: level}
+	for i := line + 1; i < len(lines); i++ {
+		if l := headingLevel(lines[i]); l > 0 && l <= level && !code[i] {
+			s.End = i
+			break
+		}
+	}
+	return s, true
+}
+
+// ShiftSection changes the level of the heading at line and of the headings
+// under it by delta, e.g. -1 to promote them. It fails when a level would
+// leave 1 to 6.
+func ShiftSection(lines []string, line, delta int) ([]string, bool) {
+	code := codeLines(lines)
+	s, ok := findSection(lines, code, line)
+	if !ok {
+		return nil, false
+	}
+
+	out := append([]string(nil), lines...)
+	for i := s.Start; i < s.End; i++ {
+		level := headingLevel(lines[i])
+		if level == 0 || code[i] {
+			continue
+		}
+		if level+delta < 1 || level+delta > 6 {
+			return nil, false
+		}
+		hashes := strings.Index(lines[i], "#")
+		out[i] = lines[i][:hashes] + strings.Repeat("#", level+delta) + lines[i][hashes+level:]
+	}
+	return out, true
+}
+
+// MoveSection swaps the section of the heading at line with the one before
+// it (dir < 0) or after it at the same level, under the same parent. It
+// returns the heading's new line.
+func MoveSection(lines []string, line, dir int) ([]string, int, bool) {
+	code := codeLines(lines)
+	s, ok := findSection(lines, code, line)
+	if !ok {
+		return nil, 0, false
+	}
+
+	if dir < 0 {
+		for i := s.Start - 1; i >= 0; i-- {
+			l := headingLevel(lines[i])
+			if l == 0 || code[i] {
+				continue
+			}
+			if l < s.Level {
+				return nil, 0, false
+			}
+			if l == s.Level {
+				prev, _ := findSection(lines, code, i)
+				out, _ := swapSections(lines, prev, s)
+				return out, prev.Start, true
+			}
+		}
+		return nil, 0, false
+	}
+
+	if s.End >= len(lines) || headingLevel(lines[s.End]) != s.Level {
+		return nil, 0, false
+	}
+	next, _ := findSection(lines, code, s.End)
+	out, moved := swapSections(lines, s, next)
+	return out, moved, true
+}
+
+// swapSections swaps two adjacent sections, leaving the blank lines that
+// ended each where they were. It returns where the first one went.
+func swapSections(lines []string, first, second Section) ([]string, int) {
+	firstBody, firstGap := splitTrailingBlank(lines[first.Start:first.End])
+	secondBody, secondGap := splitTrailingBlank(lines[second.Start:second.End])
+
+	out := make([]string, 0, len(lines))
+	out = append(out, lines[:first.Start]...)
+	out = append(out, secondBody...)
+	out = append(out, firstGap...)
+	moved := len(out)
+	out = append(out, firstBody...)
+	out = append(out, secondGap...)
+	out = append(out, lines[second.End:]...)
+	return out, moved
+}
+
+func splitTrailingBlank(lines []string) (body, blank []string) {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end], lines[end:]
+}
+
+// ExtractSection cuts out the section of the heading at line, putting
+// replacement in its place. It returns the section's text, without the
+// blank lines after it, and the remaining lines.
+func ExtractSection(lines []string, line int, replacement string) (string, []string, bool) {
+	s, ok := FindSection(lines, line)
+	if !ok {
+		return "", nil, false
+	}
+	body, gap := splitTrailingBlank(lines[s.Start:s.End])
+
+	out := make([]string, 0, len(lines))
+	out = append(out, lines[:s.Start]...)
+	out = append(out, replacement)
+	out = append(out, gap...)
+	out = append(out, lines[s.End:]...)
+	return strings.Join(body, "\n"), out, true
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var taskRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[(.)\](?:\s|$)`)
+
+// CountWords counts the words in a note's body the way Obsidian does: runs
+// of letters and digits, with each CJK character counted as a word
+func CountWords(content string) int {
+	_, body := ExtractFrontmatter(content)
+
+	words := 0
+	inWord := false
+	for _, r := range body {
+		switch {
+		case isCJK(r):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case inWord && (r == '\'' || r == '’'):
+			// Part of a word like "don't"
+		default:
+			inWord = false
+		}
+	}
+	return words
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// CountTasks counts the checkbox list items outside code blocks. Any mark
+// other than a space, e.g. [x] or [-], counts as done.
+func CountTasks(content string) (done, total int) {
+	inCodeBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if m := taskRe.FindStringSubmatch(line); m != nil {
+			total++
+			if m[1] != " " {
+				done++
+			}
+		}
+	}
+	return done, total
+}
@@ -0,0 +1,69 @@
+package parser
+
+import "strings"
+
+// SplitSubpath splits a link target like "note#Heading" or "note#^block" into
+// the note and the subpath, which keeps its #. The note is empty for links
+// within the same note.
+func SplitSubpath(target string) (note, subpath string) {
+	if i := strings.Index(target, "#"); i >= 0 {
+		return strings.TrimSpace(target[:i]), target[i:]
+	}
+	return strings.TrimSpace(target), ""
+}
+
+// SubpathLine returns the line of a "#Heading" or "#^block" subpath, or -1.
+// Nested subpaths like "#A#B" look for B under A.
+func SubpathLine(lines []string, subpath string) int {
+	start, _, ok := SubpathRange(lines, subpath)
+	if !ok {
+		return -1
+	}
+	return start
+}
+
+// SubpathRange returns the lines a subpath stands for, end excluded: the
+// section of a heading, or the block marked with ^id. That is the paragraph
+// or list item the marker ends, or the block before a marker on its own line.
+func SubpathRange(lines []string, subpath string) (start, end int, ok bool) {
+	code := codeLines(lines)
+	if id, isBlock := blockSubpath(subpath); isBlock {
+		line := blockLine(lines, code, id)
+		if line < 0 {
+			return 0, 0, false
+		}
+		start, end = blockRange(lines, code, line)
+		return start, end, start < end
+	}
+
+	s := Section{End: len(lines)}
+	for _, name := range strings.Split(strings.TrimPrefix(subpath, "#"), "#") {
+		name = strings.TrimSpace(name)
+		found := false
+		for i := s.Start; i < s.End; i++ {
+			if code[i] || headingLevel(lines[i]) <= s.Level || !strings.EqualFold(headingText(lines[i]), name) {
+				continue
+			}
+			s, found = findSection(lines, code, i)
+			break
+		}
+		if !found {
+			return 0, 0, false
+		}
+	}
+	return s.Start, s.End, true
+}
+
+func blockSubpath(subpath string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(subpath, "#"), "#")
+	return strings.CutPrefix(strings.TrimSpace(parts[len(parts)-1]), "^")
+}
+
+// headingText returns a heading's text without its #s, closing ones included
+func headingText(line string) string {
+	text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+	if open := strings.TrimRight(text, "#"); open != text && (open == "" || strings.HasSuffix(open, " ")) {
+		text = strings.TrimSpace(open)
+	}
+	return text
+}
@@ -0,0 +1,283 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var tableDelimRe = regexp.MustCompile(`^\|?(\s*:?-+:?\s*\|)*\s*:?-+:?\s*\|?$`)
+
+// Align is how a table column's cells line up
+type Align int
+
+const (
+	AlignNone Align = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Table is a Markdown table: a header row, a delimiter row and body rows
+type Table struct {
+	Start  int        // the header's line
+	End    int        // last line, inclusive
+	Indent string     // what the rows start with before their first |
+	Rows   [][]string // the header and body rows' cells, trimmed
+	Align  []Align    // one per column
+}
+
+// FindTable returns the table with a row at line. Tables are rows starting
+// with |, the second of which is a delimiter row like |---|:--:|.
+func FindTable(lines []string, line int) (Table, bool) {
+	if line < 0 || line >= len(lines) || !IsTableRow(lines[line]) {
+		return Table{}, false
+	}
+	code := codeLines(lines)
+	if code[line] {
+		return Table{}, false
+	}
+	start, end := line, line
+	for start > 0 && !code[start-1] && IsTableRow(lines[start-1]) {
+		start--
+	}
+	for end+1 < len(lines) && !code[end+1] && IsTableRow(lines[end+1]) {
+		end++
+	}
+	if end == start || !IsTableDelimiter(lines[start+1]) {
+		return Table{}, false
+	}
+
+	indent := lines[start][:len(lines[start])-len(strings.TrimLeft(lines[start], " \t"))]
+	t := Table{Start: start, End: end, Indent: indent, Rows: [][]string{SplitTableRow(lines[start])}}
+	for _, cell := range SplitTableRow(lines[start+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			t.Align = append(t.Align, AlignCenter)
+		case left:
+			t.Align = append(t.Align, AlignLeft)
+		case right:
+			t.Align = append(t.Align, AlignRight)
+		default:
+			t.Align = append(t.Align, AlignNone)
+		}
+	}
+	for _, l := range lines[start+2 : end+1] {
+		t.Rows = append(t.Rows, SplitTableRow(l))
+	}
+	t.normalize()
+	return t, true
+}
+
+// NewTable returns an empty table with the given number of columns and body
+// rows
+func NewTable(cols, rows int) Table {
+	t := Table{Rows: make([][]string, rows+1), Align: make([]Align, cols)}
+	for i := range t.Rows {
+		t.Rows[i] = make([]string, cols)
+	}
+	for c := range cols {
+		t.Rows[0][c] = "Column " + strconv.Itoa(c+1)
+	}
+	return t
+}
+
+// IsTableRow reports whether a line can be a table row
+func IsTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// IsTableDelimiter reports whether a line is the row under a table's header
+func IsTableDelimiter(line string) bool {
+	return tableDelimRe.MatchString(strings.TrimSpace(line))
+}
+
+// SplitTableRow returns the trimmed cells of a row. Escaped \| stays in its
+// cell.
+func SplitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(line[start:]))
+}
+
+// TableCellAt returns the cell of a row a rune column is in
+func TableCellAt(line string, col int) int {
+	runes := []rune(line)
+	pipes := 0
+	for i := 0; i < col && i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '|':
+			pipes++
+		}
+	}
+	return max(pipes-1, 0)
+}
+
+// TableCellCol returns the rune column where a cell's text starts in a
+// row formatted by Format
+func TableCellCol(line string, cell int) int {
+	runes := []rune(line)
+	n := -1
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '|':
+			if n++; n == cell {
+				col := i + 1
+				if col < len(runes) && runes[col] == ' ' {
+					col++
+				}
+				return col
+			}
+		}
+	}
+	return len(runes)
+}
+
+// Columns returns the table's number of columns
+func (t Table) Columns() int {
+	return len(t.Align)
+}
+
+// Format returns the table's lines with the columns padded to line up
+func (t Table) Format() []string {
+	t.normalize()
+	widths := make([]int, t.Columns())
+	for c := range widths {
+		widths[c] = 3
+		for _, row := range t.Rows {
+			widths[c] = max(widths[c], ansi.StringWidth(row[c]))
+		}
+	}
+
+	lines := make([]string, 0, len(t.Rows)+1)
+	for r, row := range t.Rows {
+		cells := make([]string, len(row))
+		for c, cell := range row {
+			cells[c] = pad(cell, widths[c], t.Align[c])
+		}
+		lines = append(lines, t.Indent+"| "+strings.Join(cells, " | ")+" |")
+		if r == 0 {
+			delims := make([]string, len(widths))
+			for c, w := range widths {
+				delims[c] = delimiter(w, t.Align[c])
+			}
+			lines = append(lines, t.Indent+"| "+strings.Join(delims, " | ")+" |")
+		}
+	}
+	return lines
+}
+
+// Line returns the line a row is on, row 0 being the header
+func (t Table) Line(row int) int {
+	if row == 0 {
+		return t.Start
+	}
+	return t.Start + row + 1
+}
+
+// Row returns the row at a line of the table, -1 for the delimiter row
+func (t Table) Row(line int) int {
+	switch {
+	case line == t.Start:
+		return 0
+	case line == t.Start+1:
+		return -1
+	}
+	return line - t.Start - 1
+}
+
+// InsertRow adds an empty row before row i
+func (t *Table) InsertRow(i int) {
+	t.Rows = slices.Insert(t.Rows, i, make([]string, t.Columns()))
+}
+
+// DeleteRow removes row i
+func (t *Table) DeleteRow(i int) {
+	t.Rows = slices.Delete(t.Rows, i, i+1)
+}
+
+// InsertColumn adds an empty column before column i
+func (t *Table) InsertColumn(i int) {
+	for r, row := range t.Rows {
+		t.Rows[r] = slices.Insert(row, i, "")
+	}
+	t.Align = slices.Insert(t.Align, i, AlignNone)
+}
+
+// DeleteColumn removes column i
+func (t *Table) DeleteColumn(i int) {
+	for r, row := range t.Rows {
+		t.Rows[r] = slices.Delete(row, i, i+1)
+	}
+	t.Align = slices.Delete(t.Align, i, i+1)
+}
+
+// SwapColumns exchanges columns i and j
+func (t *Table) SwapColumns(i, j int) {
+	for _, row := range t.Rows {
+		row[i], row[j] = row[j], row[i]
+	}
+	t.Align[i], t.Align[j] = t.Align[j], t.Align[i]
+}
+
+// normalize gives every row as many cells as the widest one
+func (t *Table) normalize() {
+	cols := len(t.Align)
+	for _, row := range t.Rows {
+		cols = max(cols, len(row))
+	}
+	for len(t.Align) < cols {
+		t.Align = append(t.Align, AlignNone)
+	}
+	for r, row := range t.Rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		t.Rows[r] = row
+	}
+}
+
+func pad(cell string, width int, align Align) string {
+	gap := width - ansi.StringWidth(cell)
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", gap) + cell
+	case AlignCenter:
+		return strings.Repeat(" ", gap/2) + cell + strings.Repeat(" ", gap-gap/2)
+	}
+	return cell + strings.Repeat(" ", gap)
+}
+
+func delimiter(width int, align Align) string {
+	switch align {
+	case AlignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	}
+	return strings.Repeat("-", width)
+}
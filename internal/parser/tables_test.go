@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitTableRow(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"| a | b |", []string{"a", "b"}},
+		{"a | b", []string{"a", "b"}},
+		{`| a \| b | c |`, []string{`a \| b`, "c"}},
+		{`| a | b \|`, []string{"a", `b \|`}},
+		{"|  | x |", []string{"", "x"}},
+		{"  | 日本 |", []string{"日本"}},
+	}
+	for _, tt := range tests {
+		if got := SplitTableRow(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitTableRow(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFindTable(t *testing.T) {
+	lines := strings.Split(strings.Join([]string{
+		"text",
+		"| a | b |",
+		"|:--|--:|",
+		"| 1 |",
+		"| 1 | 2 | 3 |",
+		"",
+		"```",
+		"| x | y |",
+		"|---|---|",
+		"```",
+		"| no | delimiter |",
+		"| 1 | 2 |",
+	}, "\n"), "\n")
+
+	table, ok := FindTable(lines, 3)
+	if !ok {
+		t.Fatal("FindTable() found no table at a body row")
+	}
+	if table.Start != 1 || table.End != 4 {
+		t.Errorf("table spans lines %d-%d, want 1-4", table.Start, table.End)
+	}
+	// Ragged rows are padded to the widest one
+	want := [][]string{{"a", "b", ""}, {"1", "", ""}, {"1", "2", "3"}}
+	if !slices.EqualFunc(table.Rows, want, slices.Equal) {
+		t.Errorf("Rows = %q, want %q", table.Rows, want)
+	}
+	if want := []Align{AlignLeft, AlignRight, AlignNone}; !slices.Equal(table.Align, want) {
+		t.Errorf("Align = %v, want %v", table.Align, want)
+	}
+	if got, ok := FindTable(lines, 2); !ok || got.Start != 1 {
+		t.Error("FindTable() missed the table from its delimiter row")
+	}
+
+	for _, line := range []int{0, 5, 7, 8, 10, 11, -1, len(lines)} {
+		if _, ok := FindTable(lines, line); ok {
+			t.Errorf("FindTable() found a table at line %d", line)
+		}
+	}
+}
+
+func TestTableFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "alignment markers",
+			lines: []string{"|a|b|c|d|", "|:-|:-:|-:|-|", "|long cell|x|y|z|"},
+			want: []string{
+				"| a         |  b  |   c | d   |",
+				"| :-------- | :-: | --: | --- |",
+				"| long cell |  x  |   y | z   |",
+			},
+		},
+		{
+			name:  "wide runes",
+			lines: []string{"| 名前 | n |", "|---|--:|", "| 日本語 | 1 |"},
+			want: []string{
+				"| 名前   |   n |",
+				"| ------ | --: |",
+				"| 日本語 |   1 |",
+			},
+		},
+		{
+			name:  "escaped pipes and ragged rows",
+			lines: []string{"  | a \\| b |", "  |---|", "  | 1 | 2 |"},
+			want: []string{
+				"  | a \\| b |     |",
+				"  | ------ | --- |",
+				"  | 1      | 2   |",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, ok := FindTable(tt.lines, 0)
+			if !ok {
+				t.Fatal("FindTable() found no table")
+			}
+			got := table.Format()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Format() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			// Formatting is stable
+			again, _ := FindTable(got, 0)
+			if !slices.Equal(again.Format(), got) {
+				t.Errorf("Format() of a formatted table changed it:\n%s", strings.Join(again.Format(), "\n"))
+			}
+		})
+	}
+}
+
+func TestTableCells(t *testing.T) {
+	line := `| a \| b | cd |`
+	tests := []struct{ col, cell int }{
+		{0, 0}, {2, 0}, {5, 0}, {9, 0}, {10, 1}, {14, 1},
+	}
+	for _, tt := range tests {
+		if got := TableCellAt(line, tt.col); got != tt.cell {
+			t.Errorf("TableCellAt(%q, %d) = %d, want %d", line, tt.col, got, tt.cell)
+		}
+	}
+	if got := TableCellCol(line, 1); got != 11 {
+		t.Errorf("TableCellCol(%q, 1) = %d, want 11", line, got)
+	}
+	if got := TableCellCol(line, 5); got != len(line) {
+		t.Errorf("TableCellCol() of a missing cell = %d, want the end of the line", got)
+	}
+}
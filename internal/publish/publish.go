@@ -0,0 +1,486 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/components/graph"
+	"github.com/takahashinaoki/obsidiantui/internal/export"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Options selects the notes to publish and where the site is written.
+// A note is published when it is in one of Folders, carries one of Tags or has
+// "publish: true" in its frontmatter; "publish: false" always excludes it.
+type Options struct {
+	Folders []string
+	Tags    []string
+	OutDir  string
+	Title   string
+	Theme   string
+}
+
+// Result summarises a generated site
+type Result struct {
+	Notes int
+	Pages int
+}
+
+// Generated pages other than folder indexes go under reservedDir, so they
+// never take the place of a note
+const (
+	reservedDir = "_site"
+	searchURL   = reservedDir + "/search.html"
+	graphURL    = reservedDir + "/graph.html"
+	tagsURL     = reservedDir + "/tags.html"
+)
+
+type page struct {
+	note      *export.Note
+	url       string // site-relative URL of the rendered page
+	tags      []string
+	backlinks []string
+}
+
+type site struct {
+	v        *vault.Vault
+	opts     Options
+	pages    map[string]*page // by note path
+	order    []string
+	tags     map[string][]string
+	renderer *export.HTMLRenderer
+	css      string
+	written  int
+	urls     map[string]bool // pages written so far
+}
+
+// Select returns the notes that would be published with opts
+func Select(v *vault.Vault, opts Options) []string {
+	var notes []string
+	for _, file := range v.Notes() {
+		relPath := file.RelativePath
+		content, err := v.ReadFile(relPath)
+		if err != nil {
+			continue
+		}
+		fm, _ := parser.ExtractFrontmatter(content)
+
+		switch strings.ToLower(fm["publish"]) {
+		case "false", "no":
+			continue
+		case "true", "yes":
+			notes = append(notes, relPath)
+			continue
+		}
+
+		if inFolders(relPath, opts.Folders) || hasAnyTag(file.Tags, opts.Tags) {
+			notes = append(notes, relPath)
+		}
+	}
+
+	sort.Strings(notes)
+	return notes
+}
+
+func inFolders(relPath string, folders []string) bool {
+	p := filepath.ToSlash(relPath)
+	for _, f := range folders {
+		f = strings.Trim(filepath.ToSlash(f), "/")
+		if f == "" || strings.HasPrefix(p, f+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, w := range wanted {
+		w = strings.TrimPrefix(w, "#")
+		for _, t := range tags {
+			if t == w || strings.HasPrefix(t, w+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Build generates the static site. The vault index must be built.
+func Build(v *vault.Vault, opts Options) (*Result, error) {
+	notes := Select(v, opts)
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("no notes selected for publishing")
+	}
+	if opts.Title == "" {
+		opts.Title = filepath.Base(v.Path)
+	}
+	if err := os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &site{
+		v:        v,
+		opts:     opts,
+		pages:    make(map[string]*page),
+		tags:     make(map[string][]string),
+		renderer: export.NewHTMLRenderer(),
+		css:      export.ThemeCSS(opts.Theme) + siteCSS,
+		urls:     make(map[string]bool),
+	}
+
+	selected := make(map[string]bool)
+	for _, relPath := range notes {
+		selected[relPath] = true
+	}
+	allow := func(relPath string) bool { return selected[relPath] }
+
+	noteTags := make(map[string][]string)
+	for _, file := range v.Notes() {
+		noteTags[file.RelativePath] = file.Tags
+	}
+
+	for _, relPath := range notes {
+		// Private notes are never inlined into published pages
+		note, err := export.LoadNote(v, relPath, allow)
+		if err != nil {
+			return nil, err
+		}
+		p := &page{note: note, url: pageURL(relPath)}
+		if strings.HasPrefix(p.url, reservedDir+"/") {
+			return nil, fmt.Errorf("%s: the %s folder is reserved for the site's own pages", relPath, reservedDir)
+		}
+		p.tags = noteTags[relPath]
+		for _, t := range p.tags {
+			s.tags[t] = append(s.tags[t], relPath)
+		}
+		s.pages[relPath] = p
+		s.order = append(s.order, relPath)
+	}
+
+	// Backlinks only come from published notes
+	for _, relPath := range s.order {
+		for _, from := range v.GetBacklinks(relPath) {
+			if _, ok := s.pages[from]; ok && from != relPath {
+				s.pages[relPath].backlinks = append(s.pages[relPath].backlinks, from)
+			}
+		}
+	}
+
+	steps := []func() error{s.writeNotes, s.writeFolderIndexes, s.writeTagPages, s.writeSearch, s.writeGraph}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Result{Notes: len(s.order), Pages: s.written}, nil
+}
+
+func pageURL(relPath string) string {
+	return strings.TrimSuffix(filepath.ToSlash(relPath), filepath.Ext(relPath)) + ".html"
+}
+
+func tagURL(tag string) string {
+	return reservedDir + "/tags/" + tag + ".html"
+}
+
+// folderURL returns the URL of a folder's index. A folder note, index.md,
+// keeps index.html, and the listing moves under reservedDir.
+func (s *site) folderURL(dir string) string {
+	url := "index.html"
+	if dir != "" {
+		url = dir + "/index.html"
+	}
+	for _, p := range s.pages {
+		if p.url == url {
+			return reservedDir + "/folders/" + url
+		}
+	}
+	return url
+}
+
+// href links from one site page to another
+func href(from, to string) string {
+	return export.RelativeHref(from, to)
+}
+
+func (s *site) write(url, title, body string) error {
+	if s.urls[url] {
+		return fmt.Errorf("two pages would be written to %s", url)
+	}
+	s.urls[url] = true
+	full := filepath.Join(s.opts.OutDir, filepath.FromSlash(url))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("<header class=\"site-nav\">")
+	b.WriteString("<a class=\"site-title\" href=\"" + href(url, "index.html") + "\">" + html.EscapeString(s.opts.Title) + "</a>")
+	b.WriteString("<a href=\"" + href(url, tagsURL) + "\">Tags</a>")
+	b.WriteString("<a href=\"" + href(url, graphURL) + "\">Graph</a>")
+	b.WriteString("<a href=\"" + href(url, searchURL) + "\">Search</a>")
+	b.WriteString("</header>\n<main>\n")
+	b.WriteString(body)
+	b.WriteString("\n</main>")
+
+	pageTitle := title
+	if title != s.opts.Title {
+		pageTitle = title + " - " + s.opts.Title
+	}
+	if err := os.WriteFile(full, []byte(export.Page(pageTitle, s.css, b.String())), 0644); err != nil {
+		return err
+	}
+	s.written++
+	return nil
+}
+
+func (s *site) link(from, relPath string) string {
+	p := s.pages[relPath]
+	return "<a href=\"" + href(from, p.url) + "\">" + html.EscapeString(p.note.Title) + "</a>"
+}
+
+func (s *site) writeNotes() error {
+	for _, relPath := range s.order {
+		p := s.pages[relPath]
+
+		body := export.RewriteWikiLinks(p.note.Body, func(target string) (string, bool) {
+			to, fragment := export.ResolveTarget(s.v, target)
+			if to == "" && fragment != "" {
+				return "#" + anchor(fragment), true
+			}
+			dest, ok := s.pages[to]
+			if !ok {
+				// Private or missing notes become plain text
+				return "", false
+			}
+			h := href(p.url, dest.url)
+			if fragment != "" {
+				h += "#" + anchor(fragment)
+			}
+			return h, true
+		})
+
+		s.renderer.ResetAnchors()
+		rendered, err := s.renderer.Render(body)
+		if err != nil {
+			return err
+		}
+
+		var b strings.Builder
+		b.WriteString("<article class=\"note\">\n" + rendered + "</article>\n")
+
+		if len(p.tags) > 0 {
+			b.WriteString("<p class=\"tags\">")
+			for _, t := range p.tags {
+				b.WriteString("<a class=\"tag\" href=\"" + href(p.url, tagURL(t)) + "\">#" + html.EscapeString(t) + "</a> ")
+			}
+			b.WriteString("</p>\n")
+		}
+
+		if len(p.backlinks) > 0 {
+			b.WriteString("<section class=\"backlinks\">\n<h2>Backlinks</h2>\n<ul>\n")
+			for _, from := range p.backlinks {
+				b.WriteString("<li>" + s.link(p.url, from) + "</li>\n")
+			}
+			b.WriteString("</ul>\n</section>\n")
+		}
+
+		if err := s.write(p.url, p.note.Title, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func anchor(fragment string) string {
+	if strings.HasPrefix(fragment, "^") {
+		return fragment[1:]
+	}
+	return export.Slug(fragment)
+}
+
+// writeFolderIndexes writes index.html for the site root and every folder
+// containing published notes
+func (s *site) writeFolderIndexes() error {
+	folders := map[string][]string{"": nil}
+	subfolders := make(map[string]map[string]bool)
+
+	for _, relPath := range s.order {
+		dir := filepath.ToSlash(filepath.Dir(relPath))
+		if dir == "." {
+			dir = ""
+		}
+		folders[dir] = append(folders[dir], relPath)
+
+		// Register every ancestor so intermediate folders get an index too
+		for dir != "" {
+			parent := filepath.ToSlash(filepath.Dir(dir))
+			if parent == "." {
+				parent = ""
+			}
+			if subfolders[parent] == nil {
+				subfolders[parent] = make(map[string]bool)
+			}
+			subfolders[parent][dir] = true
+			if _, ok := folders[parent]; !ok {
+				folders[parent] = nil
+			}
+			dir = parent
+		}
+	}
+
+	for dir, notes := range folders {
+		url := s.folderURL(dir)
+		title := s.opts.Title
+		if dir != "" {
+			title = filepath.Base(dir)
+		}
+
+		var b strings.Builder
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+
+		var subs []string
+		for sub := range subfolders[dir] {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+		if len(subs) > 0 {
+			b.WriteString("<h2>Folders</h2>\n<ul class=\"folders\">\n")
+			for _, sub := range subs {
+				b.WriteString("<li><a href=\"" + href(url, s.folderURL(sub)) + "\">📁 " + html.EscapeString(filepath.Base(sub)) + "</a></li>\n")
+			}
+			b.WriteString("</ul>\n")
+		}
+
+		if len(notes) > 0 {
+			b.WriteString("<h2>Notes</h2>\n<ul class=\"notes\">\n")
+			for _, relPath := range notes {
+				b.WriteString("<li>" + s.link(url, relPath) + "</li>\n")
+			}
+			b.WriteString("</ul>\n")
+		}
+
+		if err := s.write(url, title, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *site) writeTagPages() error {
+	var names []string
+	for t := range s.tags {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+
+	var index strings.Builder
+	index.WriteString("<h1>Tags</h1>\n<ul class=\"tags\">\n")
+	for _, t := range names {
+		index.WriteString(fmt.Sprintf("<li><a class=\"tag\" href=\"%s\">#%s</a> (%d)</li>\n",
+			href(tagsURL, tagURL(t)), html.EscapeString(t), len(s.tags[t])))
+	}
+	index.WriteString("</ul>\n")
+	if err := s.write(tagsURL, "Tags", index.String()); err != nil {
+		return err
+	}
+
+	for _, t := range names {
+		url := tagURL(t)
+		var b strings.Builder
+		b.WriteString("<h1>#" + html.EscapeString(t) + "</h1>\n<ul class=\"notes\">\n")
+		for _, relPath := range s.tags[t] {
+			b.WriteString("<li>" + s.link(url, relPath) + "</li>\n")
+		}
+		b.WriteString("</ul>\n")
+		if err := s.write(url, "#"+t, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type searchEntry struct {
+	Title string   `json:"title"`
+	URL   string   `json:"url"`
+	Tags  []string `json:"tags,omitempty"`
+	Text  string   `json:"text"`
+}
+
+// writeSearch writes search.json and a small client-side search page
+func (s *site) writeSearch() error {
+	entries := make([]searchEntry, 0, len(s.order))
+	for _, relPath := range s.order {
+		p := s.pages[relPath]
+		text := export.RewriteWikiLinks(parser.StripBlockIDs(p.note.Body), func(string) (string, bool) { return "", false })
+		entries = append(entries, searchEntry{
+			Title: p.note.Title,
+			URL:   href(searchURL, p.url),
+			Tags:  p.tags,
+			Text:  strings.Join(strings.Fields(text), " "),
+		})
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(s.opts.OutDir, reservedDir), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(s.opts.OutDir, reservedDir, "search.json"), data, 0644); err != nil {
+		return err
+	}
+
+	return s.write(searchURL, "Search", searchPage)
+}
+
+// writeGraph writes graph.json and a page drawing the published link graph
+func (s *site) writeGraph() error {
+	full := graph.Collect(s.v, graph.ExportOptions{})
+	published := &graph.ExportGraph{}
+	for _, n := range full.Nodes {
+		if _, ok := s.pages[n.ID]; ok {
+			node := *n
+			node.Links = keepPublished(n.Links, s.pages)
+			node.Backlinks = keepPublished(n.Backlinks, s.pages)
+			published.Nodes = append(published.Nodes, &node)
+		}
+	}
+	for _, e := range full.Edges {
+		if s.pages[e.Source] != nil && s.pages[e.Target] != nil {
+			published.Edges = append(published.Edges, e)
+		}
+	}
+
+	f, err := os.Create(filepath.Join(s.opts.OutDir, reservedDir, "graph.json"))
+	if err != nil {
+		return err
+	}
+	if err := published.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return s.write(graphURL, "Graph", s.graphSVG(published))
+}
+
+func keepPublished(ids []string, pages map[string]*page) []string {
+	kept := []string{}
+	for _, id := range ids {
+		if pages[id] != nil {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,100 @@
+package publish
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func readPage(t *testing.T, out, url string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(url)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuildKeepsNotesNamedLikeSitePages(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"index.md":      "# Home note\n",
+		"search.md":     "# Search note\n",
+		"graph.md":      "# Graph note\n",
+		"tags/index.md": "# Tags note\n",
+		"docs/index.md": "# Docs note\n",
+		"docs/guide.md": "# Guide\n",
+	})
+	out := t.TempDir()
+	if _, err := Build(v, Options{Folders: []string{""}, OutDir: out}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"index.html", "Home note"},
+		{"search.html", "Search note"},
+		{"graph.html", "Graph note"},
+		{"tags/index.html", "Tags note"},
+		{"docs/index.html", "Docs note"},
+		{"_site/folders/index.html", "docs"},
+		{"_site/folders/docs/index.html", "guide"},
+		{searchURL, "search.json"},
+		{tagsURL, "Tags"},
+	}
+	for _, tt := range tests {
+		if page := readPage(t, out, tt.url); !strings.Contains(page, tt.want) {
+			t.Errorf("%s does not contain %q", tt.url, tt.want)
+		}
+	}
+}
+
+func TestBuildWritesFolderIndexesWithoutFolderNotes(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"docs/guide.md": "# Guide\n",
+	})
+	out := t.TempDir()
+	if _, err := Build(v, Options{Folders: []string{""}, OutDir: out}); err != nil {
+		t.Fatal(err)
+	}
+	if page := readPage(t, out, "index.html"); !strings.Contains(page, `href="docs/index.html"`) {
+		t.Errorf("root index does not link docs/index.html:\n%s", page)
+	}
+	if page := readPage(t, out, "docs/index.html"); !strings.Contains(page, "guide") {
+		t.Errorf("docs/index.html does not list guide:\n%s", page)
+	}
+}
+
+func TestBuildRejectsReservedFolder(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"_site/search.md": "# Search\n",
+	})
+	_, err := Build(v, Options{Folders: []string{""}, OutDir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("Build() error = %v, want reserved folder error", err)
+	}
+}
+
+func TestBuildLinksBlocks(t *testing.T) {
+	v := vaulttest.Indexed(t, map[string]string{
+		"a.md": "# A\n\nSee [[b#^blk]].\n",
+		"b.md": "# B\n\nquoted ^blk\n",
+	})
+	out := t.TempDir()
+	if _, err := Build(v, Options{Folders: []string{""}, OutDir: out}); err != nil {
+		t.Fatal(err)
+	}
+	if page := readPage(t, out, "a.html"); !strings.Contains(page, `href="b.html#blk"`) {
+		t.Errorf("a.html does not link b.html#blk:\n%s", page)
+	}
+	if page := readPage(t, out, "b.html"); !strings.Contains(page, `id="blk"`) {
+		t.Errorf("b.html has no blk anchor:\n%s", page)
+	}
+	if page := readPage(t, out, searchURL); strings.Contains(page, "^blk") {
+		t.Errorf("search index has the block marker:\n%s", page)
+	}
+}
@@ -0,0 +1,87 @@
+package publish
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/components/graph"
+)
+
+// siteCSS is appended to the export theme for site navigation and sections
+const siteCSS = `
+.site-nav { display: flex; gap: 1.2em; align-items: baseline; padding-bottom: 0.6em; margin-bottom: 1.5em; border-bottom: 1px solid #8884; }
+.site-nav .site-title { font-weight: bold; margin-right: auto; }
+.tags .tag { margin-right: 0.4em; }
+.backlinks { margin-top: 3em; padding-top: 0.5em; border-top: 1px solid #8884; font-size: 0.95em; }
+#results li p { margin: 0.2em 0 0.8em; font-size: 0.9em; opacity: 0.8; }
+#search { width: 100%; padding: 0.5em; font-size: 1em; }
+svg.graph { width: 100%; height: auto; }
+svg.graph line { stroke: #8888; }
+svg.graph circle { fill: #705dcf; }
+svg.graph text { font-size: 11px; fill: currentColor; }
+`
+
+// searchPage filters search.json in the browser; it needs no server support
+const searchPage = `<h1>Search</h1>
+<input id="search" type="search" placeholder="Search notes..." autofocus>
+<ul id="results"></ul>
+<script>
+(function () {
+  var input = document.getElementById("search");
+  var list = document.getElementById("results");
+  var entries = [];
+  fetch("search.json").then(function (r) { return r.json(); }).then(function (data) { entries = data; });
+  function esc(s) { var d = document.createElement("div"); d.textContent = s; return d.innerHTML; }
+  input.addEventListener("input", function () {
+    var q = input.value.toLowerCase().trim();
+    list.innerHTML = "";
+    if (!q) { return; }
+    entries.filter(function (e) {
+      return e.title.toLowerCase().indexOf(q) !== -1 || e.text.toLowerCase().indexOf(q) !== -1 ||
+        (e.tags || []).some(function (t) { return t.toLowerCase().indexOf(q) !== -1; });
+    }).slice(0, 50).forEach(function (e) {
+      var i = e.text.toLowerCase().indexOf(q);
+      var snippet = i === -1 ? e.text.slice(0, 120) : e.text.slice(Math.max(0, i - 40), i + 80);
+      var li = document.createElement("li");
+      li.innerHTML = '<a href="' + e.url + '">' + esc(e.title) + "</a><p>" + esc(snippet) + "</p>";
+      list.appendChild(li);
+    });
+  });
+})();
+</script>
+`
+
+// graphSVG draws the published notes on a circle with their links as lines
+func (s *site) graphSVG(g *graph.ExportGraph) string {
+	const size = 800.0
+	center := size / 2
+	radius := size/2 - 120
+
+	pos := make(map[string][2]float64)
+	for i, n := range g.Nodes {
+		angle := 2 * math.Pi * float64(i) / float64(len(g.Nodes))
+		pos[n.ID] = [2]float64{center + radius*math.Cos(angle), center + radius*math.Sin(angle)}
+	}
+
+	var b strings.Builder
+	b.WriteString("<h1>Graph</h1>\n")
+	b.WriteString(fmt.Sprintf("<p>%d notes, %d links. Data: <a href=\"graph.json\">graph.json</a></p>\n", len(g.Nodes), len(g.Edges)))
+	b.WriteString(fmt.Sprintf("<svg class=\"graph\" viewBox=\"0 0 %.0f %.0f\" xmlns=\"http://www.w3.org/2000/svg\">\n", size, size))
+
+	for _, e := range g.Edges {
+		from, to := pos[e.Source], pos[e.Target]
+		b.WriteString(fmt.Sprintf("<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n", from[0], from[1], to[0], to[1]))
+	}
+
+	for _, n := range g.Nodes {
+		p := pos[n.ID]
+		r := 4 + math.Min(float64(len(n.Backlinks)), 8)
+		b.WriteString(fmt.Sprintf("<a href=\"%s\"><circle cx=\"%.1f\" cy=\"%.1f\" r=\"%.1f\"/>", href(graphURL, s.pages[n.ID].url), p[0], p[1], r))
+		b.WriteString(fmt.Sprintf("<text x=\"%.1f\" y=\"%.1f\">%s</text></a>\n", p[0]+r+2, p[1]+4, html.EscapeString(s.pages[n.ID].note.Title)))
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Fold is a closed fold of a note. The text of its first line finds it
+// again when the note was edited elsewhere in the meantime.
+type Fold struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Folds are the closed folds of each note, by path
+type Folds map[string][]Fold
+
+// LoadFolds reads a folds file, returning no folds when there is none
+func LoadFolds(path string) (Folds, error) {
+	folds := make(Folds)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return folds, nil
+	}
+	if err != nil {
+		return folds, err
+	}
+	if err := json.Unmarshal(data, &folds); err != nil {
+		return make(Folds), err
+	}
+	return folds, nil
+}
+
+// Save writes the folds to path
+func (f Folds) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return vault.AtomicWriteFile(path, data, 0644)
+}
+
+// Record sets the folds of a note from the first lines of its closed folds
+func (f Folds) Record(path string, lines []string, starts []int) {
+	var folds []Fold
+	for _, s := range starts {
+		if s >= 0 && s < len(lines) {
+			folds = append(folds, Fold{Line: s, Text: lines[s]})
+		}
+	}
+	if len(folds) == 0 {
+		delete(f, path)
+		return
+	}
+	f[path] = folds
+}
+
+// Lines returns the first lines of a note's closed folds in its current
+// lines. A fold whose line moved is found at the nearest line with its text.
+func (f Folds) Lines(path string, lines []string) []int {
+	var starts []int
+	for _, fold := range f[path] {
+		best := -1
+		for i, line := range lines {
+			if line != fold.Text {
+				continue
+			}
+			if best < 0 || abs(i-fold.Line) < abs(best-fold.Line) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			starts = append(starts, best)
+		}
+	}
+	return starts
+}
+
+// Rename moves the folds of notes after from, a note or folder, moved to to
+func (f Folds) Rename(from, to string) {
+	moved := make(Folds)
+	for path, folds := range f {
+		if newPath := vault.RenamedPath(path, from, to); newPath != path {
+			delete(f, path)
+			moved[newPath] = folds
+		}
+	}
+	for path, folds := range moved {
+		f[path] = folds
+	}
+}
+
+// Remove drops the folds of path and of the notes inside it
+func (f Folds) Remove(path string) {
+	for p := range f {
+		if p == path || strings.HasPrefix(p, path+string(filepath.Separator)) {
+			delete(f, p)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
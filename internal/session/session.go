@@ -0,0 +1,87 @@
+// Package session saves the layout of the UI for a vault on exit so the next
+// launch can pick up where the last one left off.
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// Session is the state of the UI for one vault
+type Session struct {
+	Buffers      []Buffer   `json:"buffers"`
+	ActiveWindow int        `json:"active_window"`
+	Windows      []Window   `json:"windows"`
+	Split        string     `json:"split,omitempty"` // "vertical" or "horizontal"
+	Pane         string     `json:"pane,omitempty"`  // "tree", "editor", "preview" or "outline"
+	History      []Location `json:"history,omitempty"`
+	HistoryIndex int        `json:"history_index"`
+	Recent       []string   `json:"recent,omitempty"`   // most recently accessed first
+	Expanded     []string   `json:"expanded,omitempty"` // folders open in the file tree
+}
+
+// Location is an entry in the back/forward history
+type Location struct {
+	Path string    `json:"path"`
+	Line int       `json:"line"`
+	Col  int       `json:"col"`
+	Time time.Time `json:"time"`
+}
+
+// UnmarshalJSON also accepts a plain path, which is how history entries
+// were saved before they kept the cursor position
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*l = Location{Path: path}
+		return nil
+	}
+	type location Location // without this method
+	return json.Unmarshal(data, (*location)(l))
+}
+
+// Buffer is an open note and where it was scrolled to
+type Buffer struct {
+	Path          string `json:"path"`
+	CursorRow     int    `json:"cursor_row"`
+	CursorCol     int    `json:"cursor_col"`
+	Scroll        int    `json:"scroll"`
+	PreviewScroll int    `json:"preview_scroll"`
+}
+
+// Window shows one of the buffers, by index, in a view mode
+type Window struct {
+	Buffer int    `json:"buffer"` // -1 for an empty window
+	Mode   string `json:"mode"`   // "edit", "preview" or "split"
+}
+
+// Load reads a session file. It returns an os.ErrNotExist error when there
+// is no saved session.
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the session to path
+func (s *Session) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return vault.AtomicWriteFile(path, data, 0644)
+}
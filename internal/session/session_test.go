@@ -0,0 +1,73 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadHistory(t *testing.T) {
+	when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		name string
+		json string
+		want []Location
+	}{
+		{
+			name: "paths from older versions",
+			json: `{"history": ["a.md", "dir/b.md"], "history_index": 1}`,
+			want: []Location{{Path: "a.md"}, {Path: "dir/b.md"}},
+		},
+		{
+			name: "locations",
+			json: `{"history": [{"path": "a.md", "line": 3, "col": 4, "time": "2024-05-06T07:08:09Z"}]}`,
+			want: []Location{{Path: "a.md", Line: 3, Col: 4, Time: when}},
+		},
+		{
+			name: "mixed",
+			json: `{"history": ["a.md", {"path": "b.md", "line": 1}]}`,
+			want: []Location{{Path: "a.md"}, {Path: "b.md", Line: 1}},
+		},
+		{
+			name: "no history",
+			json: `{"buffers": []}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s.History, tt.want) {
+				t.Errorf("History = %+v, want %+v", s.History, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "session.json")
+	want := &Session{
+		Buffers:      []Buffer{{Path: "a.md", CursorRow: 2, CursorCol: 1, Scroll: 1}},
+		Windows:      []Window{{Buffer: 0, Mode: "edit"}},
+		History:      []Location{{Path: "a.md", Line: 2}},
+		HistoryIndex: 0,
+	}
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+// Package swap keeps recovery copies of buffers with unsaved edits so they
+// survive a crash or a killed terminal.
+package swap
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+const ext = ".swp"
+
+// Store saves swap files under dir, mirroring the vault's folder layout
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store rooted at dir, usually vault.DataDir("swap")
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(relPath string) string {
+	return filepath.Join(s.dir, relPath+ext)
+}
+
+// Write replaces the swap file for relPath with content
+func (s *Store) Write(relPath, content string) error {
+	path := s.path(relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return vault.AtomicWriteFile(path, []byte(content), 0600)
+}
+
+// Read returns the swap content for relPath and when it was written. It
+// returns an os.ErrNotExist error when there is no swap file.
+func (s *Store) Read(relPath string) (string, time.Time, error) {
+	path := s.path(relPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return string(data), info.ModTime(), nil
+}
+
+// Remove deletes the swap file for relPath, if any
+func (s *Store) Remove(relPath string) error {
+	if err := os.Remove(s.path(relPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
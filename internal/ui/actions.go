@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
+)
+
+// loadKeys applies the keymap config and reports any problems with it
+func (m *Model) loadKeys() {
+	m.keys, m.keyProblems = LoadKeyMap(config.AppConfig.Keymap)
+	m.cmdpalette.SetKeyLabels(m.keys.commandKeys())
+	switch len(m.keyProblems) {
+	case 0:
+	case 1:
+		m.statusMsg = "Keymap: " + m.keyProblems[0].Error()
+	default:
+		m.statusMsg = fmt.Sprintf("Keymap: %d problems, see Keymap Problems in the command palette", len(m.keyProblems))
+	}
+}
+
+func (m *Model) showKeyProblems() {
+	if len(m.keyProblems) == 0 {
+		m.statusMsg = "No problems with the keymap"
+		return
+	}
+	var b strings.Builder
+	for _, err := range m.keyProblems {
+		b.WriteString(err.Error() + "\n")
+	}
+	m.diffview.SetSize(m.width*3/4, m.height*3/4)
+	m.diffview.Show("Keymap Problems", b.String())
+}
+
+// handleKey runs the global action or palette command bound to the keys
+// pressed so far. Keys that are not bound report false and go to the
+// focused pane.
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	wasPending := len(m.keySeq.Pending()) > 0
+	km, action, pending := m.keySeq.Feed(msg.String(), m.keys.global, m.keys.commands)
+	switch {
+	case pending:
+		m.statusMsg = keymap.Format(m.keySeq.Pending()) + " …"
+		return nil, true
+	case wasPending:
+		m.statusMsg = ""
+	}
+
+	switch km {
+	case nil:
+		return nil, false
+	case m.keys.commands:
+		return m.executeCommand(action), true
+	}
+	return m.runAction(action), true
+}
+
+// runAction performs a global action by its keymap name
+func (m *Model) runAction(action string) tea.Cmd {
+	switch action {
+	case "quit":
+		if m.anyModified() {
+			m.statusMsg = "Unsaved changes! C-s:save C-c:force quit"
+			return m.writeSwap()
+		}
+		// A session that can't be saved must not keep the app open: the
+		// error is printed once the terminal is restored
+		if err := m.saveSession(); err != nil {
+			m.quitErr = fmt.Errorf("saving session: %w", err)
+		}
+		return tea.Quit
+
+	case "help":
+		m.showHelp = !m.showHelp
+		m.help.ShowAll = m.showHelp
+		return nil
+
+	case "command_palette":
+		m.cmdpalette.SetSize(m.width/2, m.height*3/4)
+		return m.cmdpalette.Show()
+
+	case "focus_next":
+		prev := m.activePane
+		m.cycleFocus(1)
+		return m.paneChanged(prev)
+
+	case "focus_prev":
+		prev := m.activePane
+		m.cycleFocus(-1)
+		return m.paneChanged(prev)
+
+	case "focus_tree":
+		prev := m.activePane
+		m.setActivePane(PaneFileTree)
+		return m.paneChanged(prev)
+
+	case "focus_editor":
+		if m.viewMode == ViewPreview {
+			m.setActivePane(PanePreview)
+		} else {
+			m.setActivePane(PaneEditor)
+		}
+		return nil
+
+	case "search":
+		m.search.SetSize(m.width/2, m.height/2)
+		return m.search.Activate()
+
+	case "backlinks":
+		if m.currentFile != "" {
+			m.backlinks.SetSize(m.width/2, m.height/2)
+			m.backlinks.Show(m.currentFile)
+		}
+		return nil
+
+	case "forward_links":
+		if m.currentFile != "" {
+			m.forwardlinks.SetSize(m.width/2, m.height/2)
+			m.forwardlinks.Show(m.currentFile)
+		}
+		return nil
+
+	case "graph":
+		m.graph.SetSize(m.width*3/4, m.height*3/4)
+		m.graph.Show(m.currentFile)
+		return nil
+
+	case "tags":
+		m.tagpane.SetSize(m.width/2, m.height*3/4)
+		m.tagpane.Show()
+		return nil
+
+	case "outline":
+		if m.currentFile != "" {
+			m.outline.SetSize(m.width/2, m.height*3/4)
+			content := m.editor.Content()
+			m.outline.SetContent(content, m.currentFile)
+			m.outline.Show()
+		}
+		return nil
+
+	case "outline_sidebar":
+		m.toggleOutlineSidebar()
+		return nil
+
+	case "daily_note":
+		return m.openDailyNote()
+
+	case "toggle_view":
+		m.cycleViewMode()
+		m.updateLayout()
+		return nil
+
+	case "view_edit":
+		m.viewMode = ViewEdit
+		m.updateLayout()
+		return nil
+
+	case "view_preview":
+		m.viewMode = ViewPreview
+		m.updateLayout()
+		return nil
+
+	case "view_split":
+		m.viewMode = ViewSplit
+		m.updateLayout()
+		return nil
+
+	case "save":
+		return m.saveCurrentFile()
+
+	case "new_file":
+		return m.startCreate(false)
+
+	case "delete":
+		m.deleteSelection()
+		return nil
+
+	case "refresh":
+		m.filetree.Refresh()
+		m.statusMsg = "Vault refreshed"
+		return m.refreshGitStatus()
+
+	case "go_back":
+		return m.goBack()
+
+	case "go_forward":
+		return m.goForward()
+
+	case "jump_list":
+		return m.showJumpList()
+
+	case "recent_files":
+		return m.showRecentFiles()
+
+	case "quick_switch":
+		return m.showSwitcher()
+
+	case "bookmarks":
+		return m.showBookmarks()
+
+	case "bookmark":
+		m.toggleBookmark()
+		return nil
+
+	case "reveal_file":
+		return m.revealFile()
+
+	case "switch_vault":
+		return m.showVaultPicker()
+
+	case "history":
+		m.showHistory()
+		return nil
+
+	case "next_tab":
+		return m.cycleTab(1)
+
+	case "prev_tab":
+		return m.cycleTab(-1)
+
+	case "close_tab":
+		m.closeTab()
+		return nil
+
+	case "move_tab_left":
+		m.moveTab(-1)
+		return nil
+
+	case "move_tab_right":
+		m.moveTab(1)
+		return nil
+
+	case "split_vertical":
+		m.splitWindow(SplitVertical)
+		return nil
+
+	case "split_horizontal":
+		m.splitWindow(SplitHorizontal)
+		return nil
+
+	case "next_window":
+		prev := m.activePane
+		if m.activePane != PaneFileTree {
+			m.focusWindow((m.activeWin + 1) % len(m.windows))
+		}
+		m.focusContent()
+		return m.paneChanged(prev)
+
+	case "close_window":
+		m.closeWindow()
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestQuitWhenSessionSaveFails(t *testing.T) {
+	prev := config.AppConfig
+	config.AppConfig = &config.Config{RestoreSession: true}
+	t.Cleanup(func() { config.AppConfig = prev })
+
+	// A folder where the session file goes makes saving it fail
+	v := vaulttest.New(t, map[string]string{".obsidiantui/session.json/": ""})
+	m := NewModel(v)
+	m.sessionRestored = true
+
+	cmd := m.runAction("quit")
+	if cmd == nil {
+		t.Fatal("quit did nothing when the session could not be saved")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("quit did not quit when the session could not be saved")
+	}
+	if m.Err() == nil {
+		t.Error("Err() = nil, want the session error")
+	}
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+	"github.com/takahashinaoki/obsidiantui/internal/diff"
+)
+
+// swapDelay is how long the buffer must be idle before its swap file is
+// written and previews of it are refreshed
+const swapDelay = 2 * time.Second
+
+const swapPromptPrefix = "swap:"
+
+// bufferIdleMsg fires once input has paused; gen identifies the edit it was
+// scheduled after so stale timers are ignored
+type bufferIdleMsg struct {
+	gen  int
+	save bool // autosave rather than write the swap file
+}
+
+// bufferActivity restarts the idle timers after input to a dirty buffer
+func (m *Model) bufferActivity() tea.Cmd {
+	if m.currentFile == "" || !m.editor.Modified() {
+		return nil
+	}
+
+	m.editGen++
+	gen := m.editGen
+
+	cmds := []tea.Cmd{tea.Tick(swapDelay, func(time.Time) tea.Msg {
+		return bufferIdleMsg{gen: gen}
+	})}
+	if idle := config.AppConfig.AutosaveIdle; idle > 0 {
+		cmds = append(cmds, tea.Tick(time.Duration(idle)*time.Second, func(time.Time) tea.Msg {
+			return bufferIdleMsg{gen: gen, save: true}
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) handleBufferIdle(msg bufferIdleMsg) tea.Cmd {
+	if msg.gen != m.editGen || !m.anyModified() {
+		return nil
+	}
+	if msg.save {
+		return m.saveModified()
+	}
+	if m.currentFile != "" {
+		m.syncPreview()
+	}
+	return m.writeSwap()
+}
+
+// saveModified saves the unsaved edits of every tab, not just the active one
+func (m *Model) saveModified() tea.Cmd {
+	var cmds []tea.Cmd
+	for i, b := range m.buffers {
+		if !m.bufferModified(i) {
+			continue
+		}
+		path := b.path
+		if i == m.activeBuf {
+			path = m.currentFile
+		}
+		cmds = append(cmds, m.saveFile(path, m.bufferContent(i)))
+	}
+	return tea.Batch(cmds...)
+}
+
+// syncPreview re-renders the active buffer's preview from the editor when a
+// window shows it, e.g. the same note in edit and preview splits
+func (m *Model) syncPreview() {
+	if m.preview.Content() == m.editor.Content() {
+		return
+	}
+	for i, w := range m.windows {
+		mode := w.mode
+		if i == m.activeWin {
+			mode = m.viewMode
+		}
+		if w.buf == m.activeBuf && mode != ViewEdit {
+			m.preview.SetContent(m.editor.Content(), m.currentFile)
+			m.preview.SetFolds(m.editor.Folds())
+			return
+		}
+	}
+}
+
+// writeSwap stores every dirty buffer in its swap file
+func (m *Model) writeSwap() tea.Cmd {
+	if !config.AppConfig.SwapFiles {
+		return nil
+	}
+
+	swapped := make(map[string]string)
+	for i, b := range m.buffers {
+		if !m.bufferModified(i) {
+			continue
+		}
+		path := b.path
+		if i == m.activeBuf {
+			path = m.currentFile
+		}
+		swapped[path] = m.bufferContent(i)
+	}
+	if len(swapped) == 0 {
+		return nil
+	}
+
+	store := m.swap
+	return func() tea.Msg {
+		for path, content := range swapped {
+			if err := store.Write(path, content); err != nil {
+				return errMsg{err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// leaveBuffer is called before another note replaces the editor content.
+// Unsaved edits are saved if autosave on file switch is enabled, otherwise
+// they are kept in the swap file so they can be recovered later.
+func (m *Model) leaveBuffer(next string) tea.Cmd {
+	if m.currentFile == "" || m.currentFile == next || !m.editor.Modified() {
+		return nil
+	}
+	if config.AppConfig.AutosaveOnFileSwitch {
+		return m.saveCurrentFile()
+	}
+	if config.AppConfig.SwapFiles {
+		if err := m.swap.Write(m.currentFile, m.editor.Content()); err != nil {
+			m.statusMsg = "Error: " + err.Error()
+		}
+	}
+	return nil
+}
+
+// focusLost is called when the editor pane or the terminal loses focus
+func (m *Model) focusLost() tea.Cmd {
+	if !m.anyModified() {
+		return nil
+	}
+	if config.AppConfig.AutosaveOnFocusChange {
+		return m.saveModified()
+	}
+	return m.writeSwap()
+}
+
+// offerRecovery asks whether to restore a swap file left for the opened note
+func (m *Model) offerRecovery(path, content string) {
+	swapped, modTime, err := m.swap.Read(path)
+	if err != nil {
+		return
+	}
+	if swapped == content {
+		m.swap.Remove(path)
+		return
+	}
+
+	added, removed := 0, 0
+	for _, h := range diff.Compute(content, swapped, 0) {
+		added += h.NewLines
+		removed += h.OldLines
+	}
+
+	message := fmt.Sprintf("Found unsaved changes to %s from %s (+%d -%d lines).",
+		path, modTime.Format("2006-01-02 15:04:05"), added, removed)
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show("Recover unsaved changes?", message, swapPromptPrefix+path, []prompt.Choice{
+		{Key: "r", Label: "Recover the unsaved changes", ID: "recover"},
+		{Key: "d", Label: "Discard them and keep the file on disk", ID: "discard"},
+		{Key: "l", Label: "Decide later (keep the swap file)", ID: "later"},
+	})
+}
+
+func (m *Model) handleSwapChoice(path, choice string) tea.Cmd {
+	switch choice {
+	case "recover":
+		content, _, err := m.swap.Read(path)
+		if err != nil || path != m.currentFile {
+			return nil
+		}
+		m.editor.SetContent(content, path)
+		m.editor.SetModified(true)
+		m.preview.SetContent(content, path)
+		m.applyFolds(path, &m.editor, &m.preview)
+		m.statusMsg = "Recovered unsaved changes (save to keep them)"
+	case "discard":
+		if err := m.swap.Remove(path); err != nil {
+			return func() tea.Msg { return errMsg{err: err} }
+		}
+		m.statusMsg = "Discarded recovered changes"
+	}
+	return nil
+}
+
+func isSwapPrompt(context string) (string, bool) {
+	if !strings.HasPrefix(context, swapPromptPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(context, swapPromptPrefix), true
+}
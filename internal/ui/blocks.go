@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// copyBlockLink copies a link to a block of the current note, [[note#^id]]
+func (m *Model) copyBlockLink(path, id string) {
+	if path == "" || path != m.currentFile {
+		return
+	}
+	if id == "" {
+		m.statusMsg = "No paragraph or list item at the cursor"
+		return
+	}
+
+	link := "[[" + strings.TrimSuffix(filepath.Base(path), ".md") + "#^" + id + "]]"
+	if err := copyToClipboard(link); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+	m.statusMsg = "Copied " + link
+}
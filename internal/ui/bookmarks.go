@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/bookmark"
+	"github.com/takahashinaoki/obsidiantui/internal/components/bookmarks"
+	"github.com/takahashinaoki/obsidiantui/internal/components/cmdpalette"
+	"github.com/takahashinaoki/obsidiantui/internal/components/search"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// bookmarkCommandPrefix starts the palette ID of each bookmark, followed by
+// bookmarkKey, so IDs stay put when the bookmarks are reloaded or reordered
+const bookmarkCommandPrefix = "bookmark:"
+
+// bookmarkKey names a bookmark by its type and what it points at, e.g.
+// "file:notes/a.md#Heading" or "search:tag:#todo"
+func bookmarkKey(it *bookmark.Item) string {
+	target := it.Path
+	switch it.Type {
+	case bookmark.TypeSearch:
+		target = it.Query
+	case bookmark.TypeURL:
+		target = it.URL
+	}
+	return it.Type + ":" + target + it.Subpath
+}
+
+// loadBookmarks rereads the bookmarks file, which Obsidian may have changed,
+// and lists the bookmarks in the command palette. On error the previous
+// bookmarks are kept so a bad file is never overwritten.
+func (m *Model) loadBookmarks() error {
+	store, err := bookmark.Load(bookmark.File(m.vault.Path))
+	if err != nil {
+		return fmt.Errorf("bookmarks: %w", err)
+	}
+	m.bookmarks = store
+	m.syncBookmarkCommands()
+	return nil
+}
+
+// saveBookmarks writes the bookmarks after a change
+func (m *Model) saveBookmarks() error {
+	m.syncBookmarkCommands()
+	if err := m.bookmarks.Save(); err != nil {
+		return fmt.Errorf("bookmarks: %w", err)
+	}
+	return nil
+}
+
+func (m *Model) syncBookmarkCommands() {
+	var cmds []cmdpalette.Command
+	for _, it := range m.bookmarks.All() {
+		desc := it.Path + it.Subpath
+		if it.Type == bookmark.TypeSearch {
+			desc = "Search: " + it.Query
+		}
+		cmds = append(cmds, cmdpalette.Command{
+			ID:          bookmarkCommandPrefix + bookmarkKey(it),
+			Name:        "Bookmark: " + it.Label(),
+			Description: desc,
+		})
+	}
+	m.cmdpalette.SetExtraCommands(cmds)
+}
+
+// bookmarkExists reports whether a bookmark's note or folder is still there
+func (m *Model) bookmarkExists(it *bookmark.Item) bool {
+	f, ok := m.vault.GetFile(it.RelPath())
+	return ok && f.IsDir == (it.Type == bookmark.TypeFolder)
+}
+
+func (m *Model) showBookmarks() tea.Cmd {
+	if err := m.loadBookmarks(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	m.bookmarkPane.SetSize(m.width/2, m.height*3/4)
+	m.bookmarkPane.Show(m.bookmarks, m.bookmarkExists)
+	return nil
+}
+
+// addBookmark stores it unless an equal bookmark exists. With toggle, an
+// existing one is removed instead.
+func (m *Model) addBookmark(it *bookmark.Item, toggle bool) {
+	if err := m.loadBookmarks(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+
+	target := it.Path
+	if it.Type == bookmark.TypeSearch {
+		target = it.Query
+	}
+	if existing := m.bookmarks.Find(it.Type, target, it.Subpath); existing != nil {
+		if !toggle {
+			m.statusMsg = "Already bookmarked: " + existing.Label()
+			return
+		}
+		m.bookmarks.Remove(existing)
+		m.statusMsg = "Removed bookmark: " + existing.Label()
+	} else {
+		m.bookmarks.Add(it)
+		m.statusMsg = "Bookmarked: " + it.Label()
+	}
+
+	if err := m.saveBookmarks(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+	}
+}
+
+// toggleBookmark bookmarks the note or folder under the cursor in the file
+// tree, or else the current note, and unbookmarks it if it already was
+func (m *Model) toggleBookmark() {
+	if m.activePane == PaneFileTree {
+		if node := m.filetree.CursorNode(); node != nil && node.Path != "" {
+			if node.IsDir {
+				m.addBookmark(bookmark.NewFolder(node.Path), true)
+			} else {
+				m.addBookmark(bookmark.NewNote(node.Path, ""), true)
+			}
+			return
+		}
+	}
+	if m.currentFile == "" {
+		m.statusMsg = "No note to bookmark"
+		return
+	}
+	m.addBookmark(bookmark.NewNote(m.currentFile, ""), true)
+}
+
+// bookmarkHeading bookmarks the heading the editor cursor is under
+func (m *Model) bookmarkHeading() {
+	if m.currentFile == "" {
+		m.statusMsg = "No note to bookmark"
+		return
+	}
+	var heading *parser.Heading
+	row := m.editor.CursorRow()
+	for _, h := range parser.ExtractHeadings(m.editor.Content()) {
+		if h.Line > row {
+			break
+		}
+		heading = &h
+	}
+	if heading == nil {
+		m.statusMsg = "No heading above the cursor"
+		return
+	}
+	m.addBookmark(bookmark.NewNote(m.currentFile, "#"+heading.Text), false)
+}
+
+// openBookmark goes to what a bookmark points at
+func (m *Model) openBookmark(it *bookmark.Item) tea.Cmd {
+	switch it.Type {
+	case bookmark.TypeFile:
+		path := it.RelPath()
+		if !m.bookmarkExists(it) {
+			m.statusMsg = "Bookmarked note not found: " + it.Path
+			return nil
+		}
+		line := -1
+		if it.Subpath != "" {
+			content, err := m.vault.ReadFile(path)
+			if err != nil {
+				return func() tea.Msg { return errMsg{err: err} }
+			}
+			line = parser.SubpathLine(strings.Split(content, "\n"), it.Subpath)
+		}
+		return m.openNoteAt(path, line)
+
+	case bookmark.TypeFolder:
+		if !m.filetree.Reveal(it.RelPath()) {
+			m.statusMsg = "Bookmarked folder not found: " + it.Path
+			return nil
+		}
+		prev := m.activePane
+		m.setActivePane(PaneFileTree)
+		return m.paneChanged(prev)
+
+	case bookmark.TypeSearch:
+		m.search.SetSize(m.width/2, m.height/2)
+		return m.search.ActivateWithQuery(it.Query)
+	}
+
+	m.statusMsg = fmt.Sprintf("Can't open %s bookmarks here", it.Type)
+	return nil
+}
+
+// runBookmarkCommand opens the bookmark behind a palette command
+func (m *Model) runBookmarkCommand(id string) tea.Cmd {
+	if m.bookmarks == nil {
+		return nil
+	}
+	key := strings.TrimPrefix(id, bookmarkCommandPrefix)
+	for _, it := range m.bookmarks.All() {
+		if bookmarkKey(it) == key {
+			return m.openBookmark(it)
+		}
+	}
+	m.statusMsg = "Bookmark not found"
+	return nil
+}
+
+func (m *Model) handleBookmarkMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case bookmarks.OpenBookmarkMsg:
+		return m.openBookmark(msg.Item)
+	case bookmarks.BookmarksChangedMsg:
+		if err := m.saveBookmarks(); err != nil {
+			m.statusMsg = "Error: " + err.Error()
+		}
+	case search.SaveSearchMsg:
+		m.addBookmark(bookmark.NewSearch(msg.Query), false)
+	}
+	return nil
+}
@@ -0,0 +1,470 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+const closeTabPromptPrefix = "close:"
+
+// buffer is an open note. The active buffer lives unpacked in the Model's
+// editor, preview, currentFile and base fields; the copy here is only
+// current for the other buffers, see stashBuffer and loadBuffer.
+type buffer struct {
+	path        string
+	editor      liveeditor.Model
+	preview     preview.Model
+	baseContent string
+	baseRev     vault.Revision
+}
+
+// SplitDir is how windows are laid out next to each other
+type SplitDir int
+
+const (
+	SplitVertical   SplitDir = iota // side by side
+	SplitHorizontal                 // stacked
+)
+
+// window shows one buffer in one view mode
+type window struct {
+	buf  int // index into buffers, -1 for an empty window
+	mode ViewMode
+}
+
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h
+}
+
+// stashBuffer copies the unpacked active buffer back into buffers
+func (m *Model) stashBuffer() {
+	if m.activeBuf < 0 || m.activeBuf >= len(m.buffers) {
+		return
+	}
+	m.buffers[m.activeBuf] = buffer{
+		path:        m.currentFile,
+		editor:      m.editor,
+		preview:     m.preview,
+		baseContent: m.baseContent,
+		baseRev:     m.baseRev,
+	}
+}
+
+// loadBuffer unpacks buffer i into the Model, i = -1 for none
+func (m *Model) loadBuffer(i int) {
+	m.activeBuf = i
+	if i < 0 || i >= len(m.buffers) {
+		m.activeBuf = -1
+		m.currentFile = ""
+		m.editor = liveeditor.New()
+		m.preview = preview.New()
+		m.preview.SetVault(m.vault)
+		m.baseContent = ""
+		m.baseRev = vault.Revision{}
+	} else {
+		b := m.buffers[i]
+		m.currentFile = b.path
+		m.editor = b.editor
+		m.preview = b.preview
+		m.baseContent = b.baseContent
+		m.baseRev = b.baseRev
+		m.recent.Touch(b.path)
+	}
+	m.editor.SetFocused(m.activePane == PaneEditor)
+	m.preview.SetFocused(m.activePane == PanePreview)
+}
+
+// bufferIndex returns the index of the buffer for path, or -1
+func (m Model) bufferIndex(path string) int {
+	for i, b := range m.buffers {
+		if i == m.activeBuf {
+			if m.currentFile == path {
+				return i
+			}
+		} else if b.path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// bufferModified reports whether buffer i has unsaved edits
+func (m Model) bufferModified(i int) bool {
+	if i == m.activeBuf {
+		return m.editor.Modified()
+	}
+	return m.buffers[i].editor.Modified()
+}
+
+// bufferContent returns the text in buffer i's editor
+func (m Model) bufferContent(i int) string {
+	if i == m.activeBuf {
+		return m.editor.Content()
+	}
+	return m.buffers[i].editor.Content()
+}
+
+func (m Model) anyModified() bool {
+	for i := range m.buffers {
+		if m.bufferModified(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// showFile displays an opened note in the active window, reusing its tab
+// if the note is already open
+func (m *Model) showFile(msg fileOpenedMsg) bool {
+	if i := m.bufferIndex(msg.path); i >= 0 {
+		m.switchBuffer(i)
+		// Pick up changes made on disk unless there are edits to keep
+		if !m.editor.Modified() && msg.content != m.baseContent {
+			m.editor.SetContent(msg.content, msg.path)
+			m.preview.SetContent(msg.content, msg.path)
+			m.applyFolds(msg.path, &m.editor, &m.preview)
+		}
+		if !m.editor.Modified() {
+			m.baseContent = msg.content
+			m.baseRev = msg.rev
+		}
+		return false
+	}
+
+	m.stashBuffer()
+	pv := preview.New()
+	pv.SetVault(m.vault)
+	m.buffers = append(m.buffers, buffer{path: msg.path, editor: liveeditor.New(), preview: pv})
+	m.loadBuffer(len(m.buffers) - 1)
+	m.windows[m.activeWin].buf = m.activeBuf
+	m.updateLayout()
+
+	m.baseContent = msg.content
+	m.baseRev = msg.rev
+	m.editor.SetContent(msg.content, msg.path)
+	m.preview.SetContent(msg.content, msg.path)
+	m.applyFolds(msg.path, &m.editor, &m.preview)
+	return true
+}
+
+// switchBuffer shows buffer i in the active window
+func (m *Model) switchBuffer(i int) {
+	if i == m.activeBuf {
+		return
+	}
+	m.stashBuffer()
+	m.loadBuffer(i)
+	m.windows[m.activeWin].buf = i
+	m.updateLayout()
+}
+
+// cycleTab shows the next or previous tab in the active window
+func (m *Model) cycleTab(direction int) tea.Cmd {
+	if len(m.buffers) < 2 {
+		return nil
+	}
+	next := (m.activeBuf + direction + len(m.buffers)) % len(m.buffers)
+	leave := m.leaveBuffer(m.buffers[next].path)
+	m.switchBuffer(next)
+	return leave
+}
+
+// moveTab reorders the active tab
+func (m *Model) moveTab(direction int) {
+	i, j := m.activeBuf, m.activeBuf+direction
+	if i < 0 || j < 0 || j >= len(m.buffers) {
+		return
+	}
+	m.stashBuffer()
+	m.buffers[i], m.buffers[j] = m.buffers[j], m.buffers[i]
+	for w := range m.windows {
+		switch m.windows[w].buf {
+		case i:
+			m.windows[w].buf = j
+		case j:
+			m.windows[w].buf = i
+		}
+	}
+	m.activeBuf = j
+}
+
+// closeTab closes the active tab, asking first if it has unsaved edits
+func (m *Model) closeTab() {
+	if m.activeBuf < 0 {
+		return
+	}
+	if m.editor.Modified() {
+		m.prompt.SetSize(m.width/2, m.height/2)
+		m.prompt.Show("Close tab", m.currentFile+" has unsaved changes.", closeTabPromptPrefix+m.currentFile, []prompt.Choice{
+			{Key: "s", Label: "Save and close", ID: "save"},
+			{Key: "d", Label: "Discard changes and close", ID: "discard"},
+			{Key: "c", Label: "Cancel", ID: "cancel"},
+		})
+		return
+	}
+	m.removeBuffer(m.activeBuf)
+}
+
+func (m *Model) handleCloseTabChoice(path, choice string) tea.Cmd {
+	i := m.bufferIndex(path)
+	if i < 0 {
+		return nil
+	}
+
+	switch choice {
+	case "save":
+		// The tab stays open until the save succeeds, so a conflict or an
+		// error loses nothing
+		m.closeAfterSave[path] = true
+		return m.saveFile(path, m.bufferContent(i))
+	case "discard":
+		m.swap.Remove(path)
+		m.removeBuffer(i)
+	}
+	return nil
+}
+
+// closeSavedTab closes the tab for path if it was waiting for this save and
+// has no edits made since
+func (m *Model) closeSavedTab(path string) {
+	if !m.closeAfterSave[path] {
+		return
+	}
+	delete(m.closeAfterSave, path)
+	if i := m.bufferIndex(path); i >= 0 && !m.bufferModified(i) {
+		m.removeBuffer(i)
+	}
+}
+
+// removeBuffer drops buffer i; windows showing it move to a neighbouring tab
+func (m *Model) removeBuffer(i int) {
+	m.stashBuffer()
+	m.buffers = append(m.buffers[:i], m.buffers[i+1:]...)
+
+	replacement := min(i, len(m.buffers)-1)
+	for w := range m.windows {
+		switch b := m.windows[w].buf; {
+		case b == i:
+			m.windows[w].buf = replacement
+		case b > i:
+			m.windows[w].buf = b - 1
+		}
+	}
+
+	m.activeBuf = -1
+	m.loadBuffer(m.windows[m.activeWin].buf)
+	m.updateLayout()
+}
+
+// focusWindow makes window w the active one
+func (m *Model) focusWindow(w int) {
+	if w == m.activeWin || w < 0 || w >= len(m.windows) {
+		return
+	}
+	m.stashBuffer()
+	m.windows[m.activeWin].mode = m.viewMode
+	m.activeWin = w
+	m.viewMode = m.windows[w].mode
+	m.loadBuffer(m.windows[w].buf)
+
+	if m.activePane != PaneFileTree {
+		if m.viewMode == ViewPreview {
+			m.activePane = PanePreview
+		} else {
+			m.activePane = PaneEditor
+		}
+	}
+	m.setActivePane(m.activePane)
+}
+
+// splitWindow opens a new window next to the active one showing the same
+// note. A split of the same note in edit mode shows the preview.
+func (m *Model) splitWindow(dir SplitDir) {
+	m.windows[m.activeWin].mode = m.viewMode
+	m.splitDir = dir
+
+	mode := m.viewMode
+	if mode == ViewEdit && m.activeBuf >= 0 {
+		mode = ViewPreview
+	}
+
+	w := window{buf: m.activeBuf, mode: mode}
+	m.windows = append(m.windows[:m.activeWin+1], append([]window{w}, m.windows[m.activeWin+1:]...)...)
+	m.focusWindow(m.activeWin + 1)
+	m.updateLayout()
+}
+
+// closeWindow closes the active window unless it is the last one
+func (m *Model) closeWindow() {
+	if len(m.windows) < 2 {
+		m.statusMsg = "Only one window"
+		return
+	}
+	m.stashBuffer()
+	m.windows = append(m.windows[:m.activeWin], m.windows[m.activeWin+1:]...)
+	m.activeWin = min(m.activeWin, len(m.windows)-1)
+	m.viewMode = m.windows[m.activeWin].mode
+	m.activeBuf = -1
+	m.loadBuffer(m.windows[m.activeWin].buf)
+	m.setActivePane(m.activePane)
+	if m.activePane == PanePreview && m.viewMode == ViewEdit {
+		m.setActivePane(PaneEditor)
+	}
+	m.updateLayout()
+}
+
+// layoutWindows sizes every window's editor and preview within area
+func (m *Model) layoutWindows(area rect) {
+	n := len(m.windows)
+	m.winRects = make([]rect, n)
+	for i := range m.windows {
+		r := area
+		if m.splitDir == SplitVertical {
+			r.w = area.w / n
+			r.x = area.x + i*r.w
+			if i == n-1 {
+				r.w = area.w - i*(area.w/n)
+			}
+		} else {
+			r.h = area.h / n
+			r.y = area.y + i*r.h
+			if i == n-1 {
+				r.h = area.h - i*(area.h/n)
+			}
+		}
+		m.winRects[i] = r
+	}
+
+	// Size the active window last so it wins when a buffer is shown twice
+	order := make([]int, 0, n)
+	for i := range m.windows {
+		if i != m.activeWin {
+			order = append(order, i)
+		}
+	}
+	order = append(order, m.activeWin)
+
+	for _, i := range order {
+		w, r := m.windows[i], m.winRects[i]
+		if w.buf < 0 {
+			continue
+		}
+		ed, pv := &m.editor, &m.preview
+		if w.buf != m.activeBuf {
+			ed, pv = &m.buffers[w.buf].editor, &m.buffers[w.buf].preview
+		}
+		switch w.mode {
+		case ViewEdit:
+			ed.SetSize(r.w, r.h)
+		case ViewPreview:
+			pv.SetSize(r.w, r.h)
+		case ViewSplit:
+			half := r.w / 2
+			ed.SetSize(half, r.h)
+			pv.SetSize(r.w-half, r.h)
+		}
+	}
+}
+
+func (m Model) bufferModels(i int) (liveeditor.Model, preview.Model) {
+	if i == m.activeBuf {
+		return m.editor, m.preview
+	}
+	return m.buffers[i].editor, m.buffers[i].preview
+}
+
+func (m Model) renderWindows() string {
+	views := make([]string, len(m.windows))
+	for i, w := range m.windows {
+		r := m.winRects[i]
+		box := lipgloss.NewStyle().Width(r.w).MaxWidth(r.w).Height(r.h).MaxHeight(r.h)
+
+		if w.buf < 0 || w.buf >= len(m.buffers) {
+			views[i] = box.Render(TabInactiveStyle.Render("No file open"))
+			continue
+		}
+
+		ed, pv := m.bufferModels(w.buf)
+		active := i == m.activeWin
+		ed.SetFocused(active && m.activePane == PaneEditor)
+		pv.SetFocused(active && m.activePane == PanePreview)
+
+		mode := w.mode
+		if active {
+			mode = m.viewMode
+		}
+
+		var view string
+		switch mode {
+		case ViewEdit:
+			ed.SetSize(r.w, r.h)
+			view = ed.View()
+		case ViewPreview:
+			view = pv.View()
+		case ViewSplit:
+			ed.SetSize(r.w/2, r.h)
+			view = lipgloss.JoinHorizontal(lipgloss.Top, ed.View(), pv.View())
+		}
+		views[i] = box.Render(view)
+	}
+
+	if m.splitDir == SplitVertical {
+		return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, views...)
+}
+
+// tabLabels returns the rendered label of every tab
+func (m Model) tabLabels() []string {
+	labels := make([]string, len(m.buffers))
+	for i := range m.buffers {
+		path := m.buffers[i].path
+		if i == m.activeBuf {
+			path = m.currentFile
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		if m.bufferModified(i) {
+			name += " ●"
+		}
+
+		if i == m.activeBuf {
+			labels[i] = TabActiveStyle.Render(name)
+		} else {
+			labels[i] = TabInactiveStyle.Render(name)
+		}
+	}
+	return labels
+}
+
+func (m Model) renderTabBar(width int) string {
+	bar := strings.Join(m.tabLabels(), " ")
+	if lipgloss.Width(bar) > width {
+		bar = lipgloss.NewStyle().MaxWidth(width).Render(bar)
+	}
+	return lipgloss.NewStyle().Width(width).Render(bar)
+}
+
+// tabAt returns the tab under column x of the tab bar, or -1
+func (m Model) tabAt(x int) int {
+	pos := 0
+	for i, label := range m.tabLabels() {
+		w := lipgloss.Width(label)
+		if x >= pos && x < pos+w {
+			return i
+		}
+		pos += w + 1
+	}
+	return -1
+}
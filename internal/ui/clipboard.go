@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// copyToClipboard puts text on the system clipboard, or asks the terminal to
+// with OSC 52 when no clipboard tool is around, e.g. over ssh
+func copyToClipboard(text string) error {
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+	_, err := osc52.New(text).WriteTo(os.Stderr)
+	return err
+}
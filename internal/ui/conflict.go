@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+	"github.com/takahashinaoki/obsidiantui/internal/diff"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+const conflictPromptContext = "conflict"
+
+// fileConflictMsg reports a save refused because the note changed on disk
+type fileConflictMsg struct {
+	path     string
+	mine     string
+	conflict *vault.ConflictError
+}
+
+func (m *Model) showConflict(msg fileConflictMsg) {
+	m.conflict = &msg
+
+	choices := []prompt.Choice{
+		{Key: "r", Label: "Reload from disk (your version is kept in file history)", ID: "reload"},
+		{Key: "o", Label: "Overwrite the file on disk with your version", ID: "overwrite"},
+	}
+	if msg.path == m.currentFile {
+		choices = append(choices,
+			prompt.Choice{Key: "m", Label: "Three-way merge into the editor", ID: "merge"},
+			prompt.Choice{Key: "c", Label: "Compare base, mine and theirs", ID: "compare"},
+		)
+	}
+
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show("File changed on disk",
+		msg.path+" was modified outside ObsidianTUI since it was opened.",
+		conflictPromptContext, choices)
+}
+
+func (m *Model) handleConflictChoice(choice string) tea.Cmd {
+	c := m.conflict
+	if c == nil {
+		return nil
+	}
+	current := c.path == m.currentFile
+
+	switch choice {
+	case "reload":
+		m.conflict = nil
+		delete(m.closeAfterSave, c.path)
+		m.history.Save(c.path, c.mine, time.Now())
+		m.swap.Remove(c.path)
+		if !current {
+			m.statusMsg = "Kept the version on disk: " + c.path
+			return nil
+		}
+		m.editor.SetModified(false)
+		return m.openFileWithoutHistory(c.path)
+
+	case "overwrite":
+		m.conflict = nil
+		store, v, path, content := m.history, m.vault, c.path, c.mine
+		return func() tea.Msg {
+			rev, err := v.SaveFile(path, content, vault.Revision{})
+			if err != nil {
+				return fileSaveFailedMsg{path: path, err: err}
+			}
+			store.Save(path, content, time.Now())
+			return fileSavedMsg{path: path, content: content, rev: rev}
+		}
+
+	case "merge":
+		m.conflict = nil
+		delete(m.closeAfterSave, c.path)
+		if !current {
+			return nil
+		}
+		result := diff.Merge3(m.baseContent, m.editor.Content(), c.conflict.Theirs)
+		m.editor.SetContent(result.Text, c.path)
+		m.editor.SetModified(true)
+		m.preview.SetContent(result.Text, c.path)
+		m.applyFolds(c.path, &m.editor, &m.preview)
+		m.baseContent = c.conflict.Theirs
+		m.baseRev = c.conflict.Revision
+		if result.Conflicts > 0 {
+			m.statusMsg = fmt.Sprintf("Merged with %d conflict(s): resolve the %s markers, then save", result.Conflicts, diff.MarkerMine)
+		} else {
+			m.statusMsg = "Merged cleanly (unsaved)"
+		}
+
+	case "compare":
+		// Keep the conflict so saving again brings the choices back
+		mine := diff.Unified("base", "mine", diff.Compute(m.baseContent, c.mine, 3))
+		theirs := diff.Unified("base", "theirs (on disk)", diff.Compute(m.baseContent, c.conflict.Theirs, 3))
+		text := "diff base → mine (your unsaved edits)\n" + orNoChanges(mine) +
+			"\ndiff base → theirs (changes on disk)\n" + orNoChanges(theirs)
+		m.diffview.SetSize(m.width*3/4, m.height*3/4)
+		m.diffview.Show("Conflict: "+c.path, text)
+		m.statusMsg = "Save again to reload, overwrite or merge"
+	}
+	return nil
+}
+
+func orNoChanges(unified string) string {
+	if unified == "" {
+		return "(no changes)\n"
+	}
+	return unified
+}
@@ -0,0 +1,372 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/components/filetree"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+const trashPromptContext = "trash"
+
+// checkNewPath rejects paths outside the vault, and hidden ones, which the
+// file tree would never show
+func checkNewPath(path string) error {
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("%q is outside the vault", path)
+	}
+	for _, part := range strings.Split(path, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") {
+			return fmt.Errorf("%q would be hidden: names can't start with a dot", path)
+		}
+	}
+	return nil
+}
+
+// startCreate names a new note or folder in the file tree, next to the
+// current note unless the tree already has focus
+func (m *Model) startCreate(isDir bool) tea.Cmd {
+	prev := m.activePane
+	if prev != PaneFileTree {
+		if m.currentFile != "" {
+			m.filetree.Reveal(m.currentFile)
+		}
+		m.setActivePane(PaneFileTree)
+	}
+	return tea.Batch(m.paneChanged(prev), m.filetree.StartCreate(isDir))
+}
+
+// startRename renames the current note in the file tree, or the node under
+// the cursor when the tree has focus
+func (m *Model) startRename() tea.Cmd {
+	prev := m.activePane
+	if prev != PaneFileTree {
+		if m.currentFile == "" || !m.filetree.Reveal(m.currentFile) {
+			m.statusMsg = "No note to rename"
+			return nil
+		}
+		m.setActivePane(PaneFileTree)
+	}
+	return tea.Batch(m.paneChanged(prev), m.filetree.StartRename())
+}
+
+// deleteSelection asks to trash what is selected in the file tree, or the
+// current note when the tree does not have focus
+func (m *Model) deleteSelection() {
+	if m.activePane == PaneFileTree {
+		m.confirmTrash(m.filetree.Selection())
+		return
+	}
+	if m.currentFile == "" {
+		m.statusMsg = "No note to delete"
+		return
+	}
+	m.confirmTrash([]string{m.currentFile})
+}
+
+func (m *Model) createPath(path string, isDir bool) tea.Cmd {
+	path = filepath.Clean(path)
+	if err := checkNewPath(path); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	if m.vault.HasFile(path) {
+		m.statusMsg = "Error: " + path + " already exists"
+		return nil
+	}
+
+	var err error
+	if isDir {
+		err = m.vault.CreateFolder(path)
+	} else {
+		err = m.vault.CreateFile(path)
+	}
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	m.filetree.Refresh()
+	m.filetree.Reveal(path)
+
+	if isDir {
+		m.statusMsg = "Created folder: " + path
+		return nil
+	}
+	return m.openFile(path)
+}
+
+// movePath moves a note or folder and points everything that refers to it
+// at the new path. err says the move failed; updateErr that it was done but
+// the saved folds or bookmarks could not be updated.
+func (m *Model) movePath(from, to string) (updateErr, err error) {
+	if err := checkNewPath(to); err != nil {
+		return nil, err
+	}
+	if err := m.vault.Move(from, to); err != nil {
+		return nil, err
+	}
+	return m.pathMoved(from, to), nil
+}
+
+// pathMoved updates open notes, history and bookmarks after from moved. It
+// returns the first error saving the folds or bookmarks.
+func (m *Model) pathMoved(from, to string) error {
+	m.stashBuffer()
+	for i := range m.buffers {
+		b := &m.buffers[i]
+		newPath := vault.RenamedPath(b.path, from, to)
+		if newPath == b.path {
+			continue
+		}
+		oldPath := b.path
+		b.path = newPath
+		b.editor.SetFilePath(newPath)
+		b.preview.SetFilePath(newPath)
+		m.swap.Remove(oldPath)
+		if b.editor.Modified() {
+			m.swap.Write(newPath, b.editor.Content())
+		}
+	}
+	if m.activeBuf >= 0 {
+		b := m.buffers[m.activeBuf]
+		m.currentFile, m.editor, m.preview = b.path, b.editor, b.preview
+	}
+
+	m.nav.Rename(from, to)
+	m.recent.Rename(from, to)
+	m.folds.Rename(from, to)
+	foldsErr := m.saveFolds()
+	if m.pendingJump != nil {
+		m.pendingJump.Path = vault.RenamedPath(m.pendingJump.Path, from, to)
+	}
+
+	bookmarksErr := m.loadBookmarks()
+	if bookmarksErr == nil && m.bookmarks.Rename(from, to) > 0 {
+		bookmarksErr = m.saveBookmarks()
+	}
+	return cmp.Or(foldsErr, bookmarksErr)
+}
+
+func (m *Model) renamePath(from, to string) {
+	folderNote := m.vault.FolderNote(from)
+	updateErr, err := m.movePath(from, to)
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+	// A folder's folder note is renamed along with it
+	if folderNote != "" && filepath.Base(from) != filepath.Base(to) {
+		note := vault.RenamedPath(folderNote, from, to)
+		noteErr, err := m.movePath(note, vault.FolderNotePath(to))
+		if err != nil {
+			m.statusMsg = "Error: " + err.Error()
+			m.filetree.Refresh()
+			return
+		}
+		updateErr = cmp.Or(updateErr, noteErr)
+	}
+	m.filetree.Refresh()
+	m.filetree.Reveal(to)
+	m.statusMsg = "Renamed to " + to
+	if updateErr != nil {
+		m.statusMsg = "Renamed to " + to + ", but: " + updateErr.Error()
+	}
+}
+
+// pastePaths moves or copies paths into dir. Copies get a free name when
+// one is taken; moves never replace anything.
+func (m *Model) pastePaths(paths []string, dir string, move bool) {
+	var errs []error
+	done, last := 0, ""
+	for _, p := range paths {
+		to := filepath.Join(dir, filepath.Base(p))
+		var err error
+		switch {
+		case move && to == p:
+			continue // already there
+		case move:
+			var updateErr error
+			if updateErr, err = m.movePath(p, to); updateErr != nil {
+				errs = append(errs, updateErr)
+			}
+		default:
+			to = m.vault.AvailablePath(to)
+			err = m.vault.Copy(p, to)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		done++
+		last = to
+	}
+	verb := "Copied"
+	if move {
+		verb = "Moved"
+	}
+	m.finishOp(verb, done, last, errs)
+}
+
+// duplicatePaths copies each path next to itself, as "name 1.md"
+func (m *Model) duplicatePaths(paths []string) {
+	var errs []error
+	done, last := 0, ""
+	for _, p := range paths {
+		to := m.vault.AvailablePath(p)
+		if err := m.vault.Copy(p, to); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		done++
+		last = to
+	}
+	m.finishOp("Duplicated", done, last, errs)
+}
+
+// finishOp refreshes the tree after an operation on several paths and
+// reports how it went
+func (m *Model) finishOp(verb string, done int, last string, errs []error) {
+	m.filetree.Refresh()
+	if last != "" {
+		m.filetree.Reveal(last)
+	}
+	switch {
+	case len(errs) > 0:
+		m.statusMsg = opError(errs)
+	case done == 1:
+		m.statusMsg = verb + " to " + last
+	case done > 1:
+		m.statusMsg = fmt.Sprintf("%s %d items", verb, done)
+	}
+}
+
+// opError reports the first of errs, which the status bar has room for
+func opError(errs []error) string {
+	if len(errs) == 1 {
+		return "Error: " + errs[0].Error()
+	}
+	return fmt.Sprintf("Error: %s (and %d more)", errs[0], len(errs)-1)
+}
+
+// confirmTrash asks before moving paths to the vault's .trash folder
+func (m *Model) confirmTrash(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	what := fmt.Sprintf("%d items", len(paths))
+	if len(paths) == 1 {
+		what = paths[0]
+		if f, ok := m.vault.GetFile(paths[0]); ok && f.IsDir {
+			what += " and everything in it"
+		}
+	}
+	message := "Move " + what + " to the vault's " + vault.TrashDir + " folder? It can be restored from there."
+	for i := range m.buffers {
+		if m.bufferModified(i) && insideAny(m.bufferPath(i), paths) {
+			message += "\n\nUnsaved changes to open notes will be lost."
+			break
+		}
+	}
+
+	m.pendingTrash = paths
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show("Delete", message, trashPromptContext, []prompt.Choice{
+		{Key: "y", Label: "Move to " + vault.TrashDir, ID: "trash"},
+		{Key: "n", Label: "Cancel", ID: "cancel"},
+	})
+}
+
+func (m *Model) handleTrashChoice(choice string) tea.Cmd {
+	paths := m.pendingTrash
+	m.pendingTrash = nil
+	if choice != "trash" {
+		return nil
+	}
+
+	var errs []error
+	for _, p := range paths {
+		if _, err := m.vault.Trash(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.pathTrashed(p)
+	}
+	m.filetree.ClearSelection()
+	m.filetree.Refresh()
+
+	switch {
+	case len(errs) > 0:
+		m.statusMsg = opError(errs)
+	case len(paths) == 1:
+		m.statusMsg = "Moved to " + vault.TrashDir + ": " + paths[0]
+	default:
+		m.statusMsg = fmt.Sprintf("Moved %d items to %s", len(paths), vault.TrashDir)
+	}
+	return m.refreshGitStatus()
+}
+
+// pathTrashed closes the tabs of notes that went to the trash with path
+func (m *Model) pathTrashed(path string) {
+	for i := len(m.buffers) - 1; i >= 0; i-- {
+		if p := m.bufferPath(i); insideAny(p, []string{path}) {
+			m.swap.Remove(p)
+			m.removeBuffer(i)
+		}
+	}
+	for _, p := range append([]string(nil), m.recent.Paths()...) {
+		if insideAny(p, []string{path}) {
+			m.recent.Remove(p)
+		}
+	}
+	m.folds.Remove(path)
+	if err := m.saveFolds(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+	}
+}
+
+func (m Model) bufferPath(i int) string {
+	if i == m.activeBuf {
+		return m.currentFile
+	}
+	return m.buffers[i].path
+}
+
+// insideAny reports whether path is one of dirs or inside one
+func insideAny(path string, dirs []string) bool {
+	for _, d := range dirs {
+		if path == d || strings.HasPrefix(path, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) handleFileOpMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case filetree.CreateMsg:
+		return m.createPath(msg.Path, msg.IsDir)
+	case filetree.RenameMsg:
+		m.renamePath(msg.From, msg.To)
+	case filetree.PasteMsg:
+		m.pastePaths(msg.Paths, msg.Dir, msg.Move)
+	case filetree.DuplicateMsg:
+		m.duplicatePaths(msg.Paths)
+	case filetree.DeleteMsg:
+		m.confirmTrash(msg.Paths)
+		return nil
+	case filetree.ClipboardMsg:
+		verb := "Copied"
+		if msg.Cut {
+			verb = "Cut"
+		}
+		m.statusMsg = fmt.Sprintf("%s %d items: p to paste into the folder under the cursor", verb, len(msg.Paths))
+		return nil
+	}
+	return m.refreshGitStatus()
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckNewPath(t *testing.T) {
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"note.md", true},
+		{"a/b.md", true},
+		{"a/../b.md", false},
+		{"../x.md", false},
+		{"a/../../x.md", false},
+		{"/abs.md", false},
+		{"", false},
+		{".hidden.md", false},
+		{"dir/.x/y.md", false},
+		{".trash", false},
+	}
+	for _, tt := range tests {
+		err := checkNewPath(filepath.FromSlash(tt.path))
+		if (err == nil) != tt.ok {
+			t.Errorf("checkNewPath(%q) = %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
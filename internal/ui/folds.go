@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/session"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// foldsFile holds the closed folds of the vault's notes between runs
+func foldsFile(v *vault.Vault) string {
+	return v.DataDir("folds.json")
+}
+
+func (m *Model) loadFolds() error {
+	folds, err := session.LoadFolds(foldsFile(m.vault))
+	m.folds = folds
+	if err != nil {
+		return fmt.Errorf("folds: %w", err)
+	}
+	return nil
+}
+
+// saveFolds writes the folds after a change
+func (m *Model) saveFolds() error {
+	if err := m.folds.Save(foldsFile(m.vault)); err != nil {
+		return fmt.Errorf("folds: %w", err)
+	}
+	return nil
+}
+
+// applyFolds closes the folds saved for a note that was just loaded into
+// an editor and its preview
+func (m *Model) applyFolds(path string, ed *liveeditor.Model, pv *preview.Model) {
+	ed.SetFolds(m.folds.Lines(path, strings.Split(ed.Content(), "\n")))
+	pv.SetFolds(ed.Folds())
+}
+
+// foldsChanged mirrors folds opened or closed in the editor or the preview
+// in the other one and saves them
+func (m *Model) foldsChanged(path string, folds []int, inPreview bool) {
+	if path != m.currentFile {
+		return
+	}
+	if inPreview {
+		m.editor.SetFolds(folds)
+	}
+	m.preview.SetFolds(m.editor.Folds())
+
+	m.folds.Record(path, strings.Split(m.editor.Content(), "\n"), m.editor.Folds())
+	if err := m.saveFolds(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/git"
+)
+
+// defaultAutoCommitInterval is used when auto-commit is toggled on without a
+// configured interval
+const defaultAutoCommitInterval = 10 * time.Minute
+
+type gitStatusMsg struct {
+	status map[string]git.Status
+	branch string
+}
+
+type gitDiffMsg struct {
+	path string
+	diff string
+}
+
+// gitResultMsg reports a finished git operation; refreshTree is set when the
+// work tree may have changed on disk
+type gitResultMsg struct {
+	text        string
+	refreshTree bool
+}
+
+// gitCommitMsg reports a finished commit, see gitCommit
+type gitCommitMsg struct {
+	text string
+	err  error
+}
+
+// gitAutoCommitMsg fires when the auto-commit interval elapses; ticks from an
+// earlier generation are ignored after auto-commit is toggled
+type gitAutoCommitMsg struct {
+	gen int
+}
+
+func (m *Model) refreshGitStatus() tea.Cmd {
+	if m.git == nil {
+		return nil
+	}
+	repo := m.git
+	return func() tea.Msg {
+		status, err := repo.Status()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return gitStatusMsg{status: status, branch: repo.Branch()}
+	}
+}
+
+func (m *Model) setGitStatus(status map[string]git.Status) {
+	markers := make(map[string]string, len(status))
+	for path, s := range status {
+		markers[path] = string(s)
+	}
+	m.filetree.SetGitStatus(markers)
+}
+
+func (m *Model) gitDiffCurrent() tea.Cmd {
+	if m.git == nil {
+		m.statusMsg = "Vault is not a git repository"
+		return nil
+	}
+	if m.currentFile == "" {
+		m.statusMsg = "No file open"
+		return nil
+	}
+
+	repo, path := m.git, m.currentFile
+	return func() tea.Msg {
+		diff, err := repo.Diff(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return gitDiffMsg{path: path, diff: diff}
+	}
+}
+
+// gitCommit commits every change in the vault. Only one commit runs at a
+// time, as git locks the index: asking again while one runs commits once
+// more after it.
+func (m *Model) gitCommit() tea.Cmd {
+	if m.git == nil {
+		m.statusMsg = "Vault is not a git repository"
+		return nil
+	}
+	if m.gitCommitting {
+		m.gitCommitAgain = true
+		return nil
+	}
+	m.gitCommitting = true
+
+	repo := m.git
+	push := config.AppConfig.GitAutoPush
+	return func() tea.Msg {
+		msg, err := repo.CommitAll(time.Now())
+		if err != nil {
+			return gitCommitMsg{err: err}
+		}
+		if msg == "" {
+			return gitCommitMsg{text: "Nothing to commit"}
+		}
+		if push {
+			if err := repo.Push(); err != nil {
+				return gitCommitMsg{err: fmt.Errorf("committed, but push failed: %w", err)}
+			}
+			return gitCommitMsg{text: "Committed and pushed: " + msg}
+		}
+		return gitCommitMsg{text: "Committed: " + msg}
+	}
+}
+
+// gitCommitDone reports a finished commit and starts the one asked for
+// while it ran
+func (m *Model) gitCommitDone(msg gitCommitMsg) tea.Cmd {
+	m.gitCommitting = false
+	if msg.err != nil {
+		m.statusMsg = "Error: " + msg.err.Error()
+	} else {
+		m.statusMsg = msg.text
+	}
+
+	cmds := []tea.Cmd{m.refreshGitStatus()}
+	if m.gitCommitAgain {
+		m.gitCommitAgain = false
+		cmds = append(cmds, m.gitCommit())
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) gitPull() tea.Cmd {
+	if m.git == nil {
+		m.statusMsg = "Vault is not a git repository"
+		return nil
+	}
+
+	repo := m.git
+	m.statusMsg = "Pulling..."
+	return func() tea.Msg {
+		out, err := repo.Pull()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if out == "" {
+			out = "Pulled"
+		}
+		return gitResultMsg{text: out, refreshTree: true}
+	}
+}
+
+func (m *Model) gitPush() tea.Cmd {
+	if m.git == nil {
+		m.statusMsg = "Vault is not a git repository"
+		return nil
+	}
+
+	repo := m.git
+	m.statusMsg = "Pushing..."
+	return func() tea.Msg {
+		if err := repo.Push(); err != nil {
+			return errMsg{err: err}
+		}
+		return gitResultMsg{text: "Pushed " + repo.Branch()}
+	}
+}
+
+func (m *Model) gitInit() tea.Cmd {
+	if m.git != nil {
+		m.statusMsg = "Vault is already a git repository"
+		return nil
+	}
+
+	repo, err := git.Init(m.vault.Path)
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	m.git = repo
+	m.statusMsg = "Initialized git repository"
+	return m.refreshGitStatus()
+}
+
+// scheduleAutoCommit starts the next auto-commit timer if auto-commit is on
+func (m *Model) scheduleAutoCommit() tea.Cmd {
+	if m.git == nil || !m.autoCommit {
+		return nil
+	}
+
+	interval := time.Duration(config.AppConfig.GitAutoCommitInterval) * time.Minute
+	if interval <= 0 {
+		interval = defaultAutoCommitInterval
+	}
+
+	gen := m.autoCommitGen
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return gitAutoCommitMsg{gen: gen}
+	})
+}
+
+func (m *Model) toggleAutoCommit() tea.Cmd {
+	if m.git == nil {
+		m.statusMsg = "Vault is not a git repository"
+		return nil
+	}
+
+	m.autoCommit = !m.autoCommit
+	m.autoCommitGen++
+	if !m.autoCommit {
+		m.statusMsg = "Auto-commit off"
+		return nil
+	}
+
+	m.statusMsg = "Auto-commit on"
+	return m.scheduleAutoCommit()
+}
+
+// gitBranchLabel is shown in the status bar next to the vault name
+func (m Model) gitBranchLabel() string {
+	if m.gitBranch == "" {
+		return ""
+	}
+	return " [" + m.gitBranch + "]"
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/git"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestGitCommitOneAtATime(t *testing.T) {
+	prev := config.AppConfig
+	config.AppConfig = &config.Config{}
+	t.Cleanup(func() { config.AppConfig = prev })
+
+	v := vaulttest.New(t, nil)
+	m := NewModel(v)
+	m.git = &git.Repo{Dir: v.Path}
+
+	if m.gitCommit() == nil {
+		t.Fatal("gitCommit() did not start a commit")
+	}
+	// Saves while it runs wait for it, and are committed together
+	for range 3 {
+		if m.gitCommit() != nil {
+			t.Fatal("gitCommit() started a second commit while one runs")
+		}
+	}
+
+	m.gitCommitDone(gitCommitMsg{text: "Committed: a"})
+	if !m.gitCommitting || m.gitCommitAgain {
+		t.Errorf("after the first commit: committing %v, again %v; want the waiting commit started", m.gitCommitting, m.gitCommitAgain)
+	}
+	m.gitCommitDone(gitCommitMsg{text: "Nothing to commit"})
+	if m.gitCommitting {
+		t.Error("a commit is still running with none asked for")
+	}
+	if m.statusMsg != "Nothing to commit" {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/components/graph"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// graphExportPromptPrefix is followed by the export format in the context
+// of the prompt asking which part of the graph to export
+const graphExportPromptPrefix = "graph-export:"
+
+const (
+	graphScopeAll   = "all"
+	graphScopeLocal = "local"
+	graphScopeTag   = "tag:"
+)
+
+// showGraphExportPrompt asks whether to export the whole vault graph, the
+// current note's neighbourhood or the notes sharing one of its tags
+func (m *Model) showGraphExportPrompt(format graph.Format) {
+	choices := []prompt.Choice{{Key: "a", Label: "Whole vault", ID: graphScopeAll}}
+	message := "Export the link graph of the whole vault."
+	if m.currentFile != "" {
+		message = "Export the whole vault, the notes linked to or from " + m.currentFile + ", or the notes with one of its tags."
+		choices = append(choices, prompt.Choice{Key: "l", Label: "Local graph of the current note", ID: graphScopeLocal})
+		for i, tag := range parser.ExtractUniqueTags(m.editor.Content()) {
+			if i == 9 {
+				break
+			}
+			choices = append(choices, prompt.Choice{Key: fmt.Sprint(i + 1), Label: "Notes tagged #" + tag, ID: graphScopeTag + tag})
+		}
+	}
+
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show(fmt.Sprintf("Export Graph (%s)", strings.ToUpper(string(format))), message, graphExportPromptPrefix+string(format), choices)
+}
+
+func (m *Model) handleGraphExportChoice(format graph.Format, choice string) tea.Cmd {
+	var opts graph.ExportOptions
+	suffix := ""
+	switch {
+	case choice == graphScopeLocal:
+		if m.currentFile == "" {
+			m.statusMsg = "No file open"
+			return nil
+		}
+		opts.Focus, opts.Depth = m.currentFile, 1
+		suffix = "-" + strings.TrimSuffix(filepath.Base(m.currentFile), ".md")
+	case strings.HasPrefix(choice, graphScopeTag):
+		opts.Tag = strings.TrimPrefix(choice, graphScopeTag)
+		suffix = "-" + strings.ReplaceAll(opts.Tag, "/", "-")
+	}
+	return m.exportGraph(format, opts, suffix)
+}
+
+// exportGraph writes the part of the graph opts selects to the export
+// folder; suffix tells the file apart from an export of the whole vault
+func (m *Model) exportGraph(format graph.Format, opts graph.ExportOptions, suffix string) tea.Cmd {
+	return func() tea.Msg {
+		m.vault.WaitIndex()
+
+		dir := m.vault.DataDir("export")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errMsg{err: err}
+		}
+
+		name := graph.ExportFileName(m.vault, format)
+		name = strings.TrimSuffix(name, format.Extension()) + suffix + format.Extension()
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+
+		if err := graph.Collect(m.vault, opts).Write(f, format); err != nil {
+			f.Close()
+			return errMsg{err: err}
+		}
+		if err := f.Close(); err != nil {
+			return errMsg{err: err}
+		}
+		return infoMsg{text: "Graph exported: " + path}
+	}
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/history"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// historySnapshotMsg fires when the snapshot interval elapses; gen
+// identifies the timer so one left from another vault is ignored
+type historySnapshotMsg struct {
+	gen int
+}
+
+func newHistoryStore(v *vault.Vault) *history.Store {
+	store := history.NewStore(v.DataDir("history"))
+	store.MaxVersions = config.AppConfig.HistoryMaxVersions
+	store.Retention = time.Duration(config.AppConfig.HistoryRetentionDays) * 24 * time.Hour
+	return store
+}
+
+// scheduleSnapshot starts the timer for the next snapshot of unsaved edits
+func (m *Model) scheduleSnapshot() tea.Cmd {
+	interval := time.Duration(config.AppConfig.HistoryInterval) * time.Minute
+	if interval <= 0 {
+		return nil
+	}
+	gen := m.snapshotGen
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return historySnapshotMsg{gen: gen}
+	})
+}
+
+// snapshotBuffers stores every buffer with unsaved edits as a version,
+// background tabs too
+func (m *Model) snapshotBuffers() tea.Cmd {
+	snapshots := make(map[string]string)
+	for i, b := range m.buffers {
+		if !m.bufferModified(i) {
+			continue
+		}
+		path := b.path
+		if i == m.activeBuf {
+			path = m.currentFile
+		}
+		snapshots[path] = m.bufferContent(i)
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	store := m.history
+	return func() tea.Msg {
+		now := time.Now()
+		for path, content := range snapshots {
+			if _, err := store.Save(path, content, now); err != nil {
+				return errMsg{err: err}
+			}
+		}
+		return nil
+	}
+}
+
+func (m *Model) showHistory() {
+	if m.currentFile == "" {
+		m.statusMsg = "No file open"
+		return
+	}
+	m.historyview.SetSize(m.width*3/4, m.height*3/4)
+	m.historyview.Show(m.history, m.currentFile, m.editor.Content())
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/vault/vaulttest"
+)
+
+func TestSnapshotBuffersInBackgroundTabs(t *testing.T) {
+	prev := config.AppConfig
+	config.AppConfig = &config.Config{}
+	t.Cleanup(func() { config.AppConfig = prev })
+
+	v := vaulttest.New(t, nil)
+	m := NewModel(v)
+
+	editor := func(content string, modified bool) liveeditor.Model {
+		ed := liveeditor.New()
+		ed.SetContent(content, "")
+		ed.SetModified(modified)
+		return ed
+	}
+	// a.md has edits in a background tab, b.md is shown without edits
+	m.buffers = []buffer{
+		{path: "a.md", editor: editor("edited a", true)},
+		{path: "b.md"},
+	}
+	m.activeBuf, m.currentFile = 1, "b.md"
+	m.editor = editor("b", false)
+
+	cmd := m.snapshotBuffers()
+	if cmd == nil {
+		t.Fatal("snapshotBuffers() skipped the background tab")
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("snapshot failed: %v", msg)
+	}
+	if versions, _ := m.history.Versions("a.md"); len(versions) != 1 {
+		t.Errorf("a.md has %d versions, want 1", len(versions))
+	}
+	if versions, _ := m.history.Versions("b.md"); len(versions) != 0 {
+		t.Errorf("b.md without edits has %d versions", len(versions))
+	}
+
+	// The shown buffer's edits are in the editor, not in its tab
+	m.editor = editor("edited b", true)
+	m.snapshotBuffers()()
+	versions, _ := m.history.Versions("b.md")
+	if len(versions) != 1 {
+		t.Fatalf("b.md has %d versions, want 1", len(versions))
+	}
+	if content, _ := m.history.Load(versions[0]); content != "edited b" {
+		t.Errorf("b.md version = %q, want the editor's text", content)
+	}
+}
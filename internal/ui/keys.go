@@ -1,6 +1,17 @@
 package ui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/cmdpalette"
+	"github.com/takahashinaoki/obsidiantui/internal/components/filetree"
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
+)
 
 type KeyMap struct {
 	Quit       key.Binding
@@ -11,11 +22,13 @@ type KeyMap struct {
 	FocusTree  key.Binding
 	FocusEdit  key.Binding
 	Search       key.Binding
+	QuickSwitch  key.Binding
 	Backlinks    key.Binding
 	ForwardLinks key.Binding
 	Graph        key.Binding
 	Tags       key.Binding
 	Outline    key.Binding
+	OutlineBar key.Binding
 	DailyNote  key.Binding
 	Save       key.Binding
 	NewFile    key.Binding
@@ -25,12 +38,34 @@ type KeyMap struct {
 	ViewEdit   key.Binding
 	ViewPrev   key.Binding
 	ViewSplit  key.Binding
-	FollowLink key.Binding
+	FollowLink key.Binding // help only, bound in the editor's keymap
 	GoBack     key.Binding
+	GoForward  key.Binding
+	JumpList   key.Binding
+	RecentFiles key.Binding
+	History    key.Binding
+	SwitchVault key.Binding
+	Bookmarks   key.Binding
+	Bookmark    key.Binding
+	RevealFile  key.Binding
+
+	NextTab         key.Binding
+	PrevTab         key.Binding
+	CloseTab        key.Binding
+	MoveTabLeft     key.Binding
+	MoveTabRight    key.Binding
+	SplitVertical   key.Binding
+	SplitHorizontal key.Binding
+	NextWindow      key.Binding
+	CloseWindow     key.Binding
+
+	global      *keymap.Map // the bindings above by action name
+	commands    *keymap.Map // palette commands bound from the config
+	commandHelp []key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
-	return KeyMap{
+	k := KeyMap{
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c", "ctrl+q"),
 			key.WithHelp("C-c", "quit"),
@@ -60,9 +95,13 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("M-2", "editor"),
 		),
 		Search: key.NewBinding(
-			key.WithKeys("/", "ctrl+p", "ctrl+f"),
+			key.WithKeys("/", "ctrl+f"),
 			key.WithHelp("/", "search"),
 		),
+		QuickSwitch: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("C-p", "quick switch"),
+		),
 		Backlinks: key.NewBinding(
 			key.WithKeys("ctrl+b"),
 			key.WithHelp("C-b", "backlinks"),
@@ -83,6 +122,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+l"),
 			key.WithHelp("C-l", "outline"),
 		),
+		OutlineBar: key.NewBinding(
+			key.WithKeys("alt+t"),
+			key.WithHelp("M-t", "outline bar"),
+		),
 		DailyNote: key.NewBinding(
 			key.WithKeys("alt+d"),
 			key.WithHelp("M-d", "daily"),
@@ -96,8 +139,9 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("C-n", "new"),
 		),
 		Delete: key.NewBinding(
-			key.WithKeys("ctrl+d"),
-			key.WithHelp("C-d", "delete"),
+			// Not Ctrl+D, which pages down in the editor and file tree
+			key.WithKeys("delete"),
+			key.WithHelp("Del", "delete"),
 		),
 		Refresh: key.NewBinding(
 			key.WithKeys("ctrl+r", "f5"),
@@ -119,26 +163,260 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("alt+s"),
 			key.WithHelp("M-s", "split"),
 		),
-		FollowLink: key.NewBinding(
-			key.WithKeys("ctrl+]", "gd"),
-			key.WithHelp("gd/C-]", "go to link"),
-		),
 		GoBack: key.NewBinding(
 			key.WithKeys("ctrl+o", "ctrl+["),
 			key.WithHelp("C-o", "go back"),
 		),
+		GoForward: key.NewBinding(
+			// Terminals send Ctrl+I as Tab, which already switches panes
+			key.WithKeys("alt+i"),
+			key.WithHelp("M-i", "go forward"),
+		),
+		JumpList: key.NewBinding(
+			key.WithKeys("alt+j"),
+			key.WithHelp("M-j", "jump list"),
+		),
+		RecentFiles: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("M-r", "recent files"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("alt+h"),
+			key.WithHelp("M-h", "history"),
+		),
+		SwitchVault: key.NewBinding(
+			key.WithKeys("alt+v"),
+			key.WithHelp("M-v", "switch vault"),
+		),
+		Bookmarks: key.NewBinding(
+			key.WithKeys("alt+b"),
+			key.WithHelp("M-b", "bookmarks"),
+		),
+		Bookmark: key.NewBinding(
+			key.WithKeys("alt+B"),
+			key.WithHelp("M-B", "bookmark"),
+		),
+		RevealFile: key.NewBinding(
+			key.WithKeys("alt+l"),
+			key.WithHelp("M-l", "reveal file"),
+		),
+		NextTab: key.NewBinding(
+			key.WithKeys("alt+.", "ctrl+pgdown"),
+			key.WithHelp("M-.", "next tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("alt+,", "ctrl+pgup"),
+			key.WithHelp("M-,", "prev tab"),
+		),
+		CloseTab: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("M-w", "close tab"),
+		),
+		MoveTabLeft: key.NewBinding(
+			key.WithKeys("alt+<"),
+			key.WithHelp("M-<", "move tab left"),
+		),
+		MoveTabRight: key.NewBinding(
+			key.WithKeys("alt+>"),
+			key.WithHelp("M->", "move tab right"),
+		),
+		SplitVertical: key.NewBinding(
+			key.WithKeys("alt+\\"),
+			key.WithHelp("M-\\", "split right"),
+		),
+		SplitHorizontal: key.NewBinding(
+			key.WithKeys("alt+-"),
+			key.WithHelp("M--", "split down"),
+		),
+		NextWindow: key.NewBinding(
+			key.WithKeys("alt+o"),
+			key.WithHelp("M-o", "next window"),
+		),
+		CloseWindow: key.NewBinding(
+			key.WithKeys("alt+x"),
+			key.WithHelp("M-x", "close window"),
+		),
+	}
+	k.global = keymap.NewMap("global")
+	for _, a := range keyActions {
+		b := a.binding(&k)
+		seqs := make([]keymap.Sequence, len(b.Keys()))
+		for i, keys := range b.Keys() {
+			seqs[i] = keymap.MustParse(keys)
+		}
+		k.global.Bind(a.name, b.Help().Desc, seqs...)
 	}
+	k.commands = keymap.NewMap("commands")
+	k.syncBindings(liveeditor.DefaultKeymap())
+	return k
 }
 
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.CmdPalette, k.Search, k.Save, k.ToggleView, k.Quit}
+	return []key.Binding{k.CmdPalette, k.QuickSwitch, k.Search, k.Save, k.ToggleView, k.Quit}
 }
 
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.FocusNext, k.FocusPrev, k.FocusTree, k.FocusEdit},
 		{k.ToggleView, k.ViewEdit, k.ViewPrev, k.ViewSplit},
-		{k.Search, k.Backlinks, k.Graph, k.Tags},
-		{k.Outline, k.DailyNote, k.FollowLink, k.GoBack},
+		{k.QuickSwitch, k.Search, k.Backlinks, k.Graph, k.Tags},
+		{k.Outline, k.OutlineBar, k.DailyNote, k.FollowLink, k.SwitchVault},
+		{k.GoBack, k.GoForward, k.JumpList, k.RecentFiles},
+		{k.Bookmarks, k.Bookmark, k.RevealFile},
+		{k.History, k.NextTab, k.PrevTab, k.CloseTab},
+		{k.SplitVertical, k.SplitHorizontal, k.NextWindow, k.CloseWindow},
+		k.commandHelp,
+	}
+}
+
+// keyActions names the bindings of KeyMap for the keymap config. command is
+// the palette command that does the same, which is listed with its key.
+var keyActions = []struct {
+	name    string
+	command string
+	binding func(k *KeyMap) *key.Binding
+}{
+	{"quit", "", func(k *KeyMap) *key.Binding { return &k.Quit }},
+	{"help", "help", func(k *KeyMap) *key.Binding { return &k.Help }},
+	{"command_palette", "", func(k *KeyMap) *key.Binding { return &k.CmdPalette }},
+	{"focus_next", "", func(k *KeyMap) *key.Binding { return &k.FocusNext }},
+	{"focus_prev", "", func(k *KeyMap) *key.Binding { return &k.FocusPrev }},
+	{"focus_tree", "", func(k *KeyMap) *key.Binding { return &k.FocusTree }},
+	{"focus_editor", "", func(k *KeyMap) *key.Binding { return &k.FocusEdit }},
+	{"search", "search", func(k *KeyMap) *key.Binding { return &k.Search }},
+	{"quick_switch", "quick-switch", func(k *KeyMap) *key.Binding { return &k.QuickSwitch }},
+	{"backlinks", "backlinks", func(k *KeyMap) *key.Binding { return &k.Backlinks }},
+	{"forward_links", "forwardlinks", func(k *KeyMap) *key.Binding { return &k.ForwardLinks }},
+	{"graph", "graph", func(k *KeyMap) *key.Binding { return &k.Graph }},
+	{"tags", "tags", func(k *KeyMap) *key.Binding { return &k.Tags }},
+	{"outline", "outline", func(k *KeyMap) *key.Binding { return &k.Outline }},
+	{"outline_sidebar", "outline-sidebar", func(k *KeyMap) *key.Binding { return &k.OutlineBar }},
+	{"daily_note", "daily", func(k *KeyMap) *key.Binding { return &k.DailyNote }},
+	{"save", "save", func(k *KeyMap) *key.Binding { return &k.Save }},
+	{"new_file", "newfile", func(k *KeyMap) *key.Binding { return &k.NewFile }},
+	{"delete", "delete-file", func(k *KeyMap) *key.Binding { return &k.Delete }},
+	{"refresh", "refresh", func(k *KeyMap) *key.Binding { return &k.Refresh }},
+	{"toggle_view", "toggle", func(k *KeyMap) *key.Binding { return &k.ToggleView }},
+	{"view_edit", "edit", func(k *KeyMap) *key.Binding { return &k.ViewEdit }},
+	{"view_preview", "preview", func(k *KeyMap) *key.Binding { return &k.ViewPrev }},
+	{"view_split", "split", func(k *KeyMap) *key.Binding { return &k.ViewSplit }},
+	{"go_back", "go-back", func(k *KeyMap) *key.Binding { return &k.GoBack }},
+	{"go_forward", "go-forward", func(k *KeyMap) *key.Binding { return &k.GoForward }},
+	{"jump_list", "jump-list", func(k *KeyMap) *key.Binding { return &k.JumpList }},
+	{"recent_files", "recent-files", func(k *KeyMap) *key.Binding { return &k.RecentFiles }},
+	{"history", "history", func(k *KeyMap) *key.Binding { return &k.History }},
+	{"switch_vault", "vault-switch", func(k *KeyMap) *key.Binding { return &k.SwitchVault }},
+	{"bookmarks", "bookmarks", func(k *KeyMap) *key.Binding { return &k.Bookmarks }},
+	{"bookmark", "bookmark-toggle", func(k *KeyMap) *key.Binding { return &k.Bookmark }},
+	{"reveal_file", "reveal-file", func(k *KeyMap) *key.Binding { return &k.RevealFile }},
+	{"next_tab", "tab-next", func(k *KeyMap) *key.Binding { return &k.NextTab }},
+	{"prev_tab", "tab-prev", func(k *KeyMap) *key.Binding { return &k.PrevTab }},
+	{"close_tab", "tab-close", func(k *KeyMap) *key.Binding { return &k.CloseTab }},
+	{"move_tab_left", "tab-move-left", func(k *KeyMap) *key.Binding { return &k.MoveTabLeft }},
+	{"move_tab_right", "tab-move-right", func(k *KeyMap) *key.Binding { return &k.MoveTabRight }},
+	{"split_vertical", "split-vertical", func(k *KeyMap) *key.Binding { return &k.SplitVertical }},
+	{"split_horizontal", "split-horizontal", func(k *KeyMap) *key.Binding { return &k.SplitHorizontal }},
+	{"next_window", "window-next", func(k *KeyMap) *key.Binding { return &k.NextWindow }},
+	{"close_window", "window-close", func(k *KeyMap) *key.Binding { return &k.CloseWindow }},
+}
+
+// LoadKeyMap applies the keymap section of the config to the default
+// bindings, including the editor's, file tree's and preview's, which it
+// installs. It returns problems
+// found on the way: bad keys, unknown actions and conflicting bindings.
+func LoadKeyMap(cfg config.KeymapConfig) (KeyMap, []error) {
+	k := DefaultKeyMap()
+	editor := liveeditor.DefaultKeymap()
+	tree := filetree.DefaultKeymap()
+	pv := preview.DefaultKeymap()
+	var errs []error
+
+	var leader keymap.Sequence
+	if cfg.Leader != "" {
+		seq, err := keymap.Parse(cfg.Leader, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("leader: %w", err))
+		} else {
+			leader = seq
+		}
+	}
+
+	commands := make(map[string][]string, len(cfg.Commands))
+	for id, seqs := range cfg.Commands {
+		if _, ok := cmdpalette.Lookup(id); !ok {
+			errs = append(errs, fmt.Errorf("commands: unknown command %q", id))
+			continue
+		}
+		commands[id] = seqs
+	}
+
+	// The focused pane's keys share sequences only with the global ones
+	maps := []*keymap.Map{k.global, editor, tree, pv, k.commands}
+	errs = append(errs, k.global.Apply(cfg.Global, leader, false, maps...)...)
+	errs = append(errs, editor.Apply(cfg.Editor, leader, false, k.global, editor, k.commands)...)
+	errs = append(errs, tree.Apply(cfg.Tree, leader, false, k.global, tree, k.commands)...)
+	errs = append(errs, pv.Apply(cfg.Preview, leader, false, k.global, pv, k.commands)...)
+	errs = append(errs, k.commands.Apply(commands, leader, true, maps...)...)
+	for _, c := range keymap.Conflicts(k.global, k.commands, editor) {
+		errs = append(errs, c)
+	}
+	for _, pane := range []*keymap.Map{tree, pv} {
+		for _, c := range keymap.Conflicts(k.global, k.commands, pane) {
+			if strings.HasPrefix(c.Action, pane.Name+".") || strings.HasPrefix(c.OtherAct, pane.Name+".") {
+				errs = append(errs, c)
+			}
+		}
+	}
+
+	liveeditor.SetKeymap(editor)
+	filetree.SetKeymap(tree)
+	preview.SetKeymap(pv)
+	k.syncBindings(editor)
+	return k, errs
+}
+
+// syncBindings updates the bindings shown in help to the keymaps in use
+func (k *KeyMap) syncBindings(editor *keymap.Map) {
+	for _, a := range keyActions {
+		*a.binding(k) = helpBinding(k.global, a.name, k.global.Help(a.name))
+	}
+	k.FollowLink = helpBinding(editor, "follow_link", "go to link")
+
+	k.commandHelp = nil
+	for _, id := range k.commands.Actions() {
+		cmd, _ := cmdpalette.Lookup(id)
+		if b := helpBinding(k.commands, id, cmd.Name); b.Enabled() {
+			k.commandHelp = append(k.commandHelp, b)
+		}
+	}
+}
+
+// helpBinding describes an action's first sequence for the help view. It is
+// disabled, and so hidden, when the action has no keys.
+func helpBinding(km *keymap.Map, action, desc string) key.Binding {
+	seqs := km.Keys(action)
+	if len(seqs) == 0 {
+		return key.NewBinding(key.WithDisabled())
+	}
+	keys := make([]string, len(seqs))
+	for i, seq := range seqs {
+		keys[i] = seq.String()
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(km.Label(action), desc))
+}
+
+// commandKeys labels palette commands with the keys that run them
+func (k KeyMap) commandKeys() map[string]string {
+	labels := make(map[string]string)
+	for _, a := range keyActions {
+		if a.command != "" {
+			labels[a.command] = k.global.Label(a.name)
+		}
+	}
+	for _, id := range k.commands.Actions() {
+		if label := k.commands.Label(id); label != "" {
+			labels[id] = label
+		}
 	}
+	return labels
 }
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/filetree"
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
+)
+
+func TestLoadKeyMapPanes(t *testing.T) {
+	t.Cleanup(func() {
+		liveeditor.SetKeymap(liveeditor.DefaultKeymap())
+		filetree.SetKeymap(filetree.DefaultKeymap())
+		preview.SetKeymap(preview.DefaultKeymap())
+	})
+
+	if _, errs := LoadKeyMap(config.KeymapConfig{}); len(errs) > 0 {
+		t.Fatalf("default keymap has problems: %v", errs)
+	}
+
+	k, errs := LoadKeyMap(config.KeymapConfig{
+		Tree:    map[string][]string{"filter": {"F"}, "peek": {"/"}},
+		Preview: map[string][]string{"fold_toggle": {"c"}, "fold_open": {"z"}},
+	})
+	// "z" hides z c, z M and z R
+	if len(errs) != 3 {
+		t.Errorf("LoadKeyMap() problems = %v, want three conflicts", errs)
+	}
+	// A key bound in the config is taken from the defaults, global ones too
+	for _, seq := range k.global.Keys("search") {
+		if seq.String() == "/" {
+			t.Error("global search keeps / after the tree took it")
+		}
+	}
+
+	tree := filetree.Keymap()
+	if got := tree.Label("filter"); got != "F" {
+		t.Errorf("tree filter = %q, want F", got)
+	}
+	var r keymap.Resolver
+	if _, action, _ := r.Feed("c", preview.Keymap()); action != "fold_toggle" {
+		t.Errorf("preview c runs %q, want fold_toggle", action)
+	}
+	if keys := preview.Keymap().Keys("callout_toggle"); len(keys) != 0 {
+		t.Errorf("callout_toggle keeps %v after its key was taken", keys)
+	}
+}
@@ -1,25 +1,45 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
-	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/bookmark"
 	"github.com/takahashinaoki/obsidiantui/internal/components/backlinks"
+	"github.com/takahashinaoki/obsidiantui/internal/components/bookmarks"
 	"github.com/takahashinaoki/obsidiantui/internal/components/cmdpalette"
+	"github.com/takahashinaoki/obsidiantui/internal/components/diffview"
 	"github.com/takahashinaoki/obsidiantui/internal/components/filetree"
+	"github.com/takahashinaoki/obsidiantui/internal/components/folderindex"
 	"github.com/takahashinaoki/obsidiantui/internal/components/forwardlinks"
 	"github.com/takahashinaoki/obsidiantui/internal/components/graph"
+	"github.com/takahashinaoki/obsidiantui/internal/components/historyview"
+	"github.com/takahashinaoki/obsidiantui/internal/components/jumplist"
 	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
 	"github.com/takahashinaoki/obsidiantui/internal/components/outline"
 	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
 	"github.com/takahashinaoki/obsidiantui/internal/components/search"
+	"github.com/takahashinaoki/obsidiantui/internal/components/switcher"
 	"github.com/takahashinaoki/obsidiantui/internal/components/tagpane"
+	"github.com/takahashinaoki/obsidiantui/internal/components/vaultpicker"
+	"github.com/takahashinaoki/obsidiantui/internal/export"
+	"github.com/takahashinaoki/obsidiantui/internal/git"
+	"github.com/takahashinaoki/obsidiantui/internal/history"
+	"github.com/takahashinaoki/obsidiantui/internal/keymap"
+	"github.com/takahashinaoki/obsidiantui/internal/nav"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+	"github.com/takahashinaoki/obsidiantui/internal/publish"
+	"github.com/takahashinaoki/obsidiantui/internal/session"
+	"github.com/takahashinaoki/obsidiantui/internal/swap"
 	"github.com/takahashinaoki/obsidiantui/internal/vault"
 )
 
@@ -29,6 +49,7 @@ const (
 	PaneFileTree Pane = iota
 	PaneEditor
 	PanePreview
+	PaneOutline // the outline sidebar
 )
 
 type ViewMode int
@@ -50,7 +71,17 @@ type Model struct {
 	graph        graph.Model
 	tagpane   tagpane.Model
 	outline    outline.Model
+	outlineBar outline.Model // the outline as a sidebar, see outline.go
+	outlineAt  outlineAt
 	cmdpalette cmdpalette.Model
+	diffview   diffview.Model
+	historyview historyview.Model
+	prompt     prompt.Model
+	vaultpicker vaultpicker.Model
+	jumplist   jumplist.Model
+	switcher   switcher.Model
+	bookmarkPane bookmarks.Model
+	folderIndex  folderindex.Model
 	help       help.Model
 	keys      KeyMap
 
@@ -61,9 +92,45 @@ type Model struct {
 	showHelp      bool
 	statusMsg     string
 	currentFile   string
-	historyStack  []string
+	nav           *nav.History
+	recent        *nav.Recent
+	recentShown   []string      // paths listed in the recent files switcher
+	pendingJump   *nav.Location // where to put the cursor once a note opens
+	pendingTrash  []string      // paths waiting for the delete prompt
+	keySeq        keymap.Resolver
+	keyProblems   []error // from the keymap config, see loadKeys
+	bookmarks     *bookmark.Store // nil until .obsidian/bookmarks.json is read
+	folds         session.Folds   // closed folds of each note
 	cachedTreeW   int
 	cachedContentW int
+	cachedOutlineW int // 0 while the outline sidebar is hidden
+
+	history       *history.Store
+	swap          *swap.Store
+	editGen       int // bumped on input to a dirty buffer, see bufferActivity
+	snapshotGen   int
+	baseContent   string         // current note as last read from or written to disk
+	baseRev       vault.Revision // revision of baseContent, checked before saving
+	conflict      *fileConflictMsg
+	closeAfterSave map[string]bool // tabs to close once their save succeeds
+	sessionRestored bool   // saving before the restore would lose the session
+	sessionState    string // sessionKey as last scheduled for saving
+	sessionGen      int
+
+	buffers   []buffer
+	activeBuf int // index of the buffer unpacked into editor/preview, -1 for none
+	windows   []window
+	activeWin int
+	splitDir  SplitDir
+	winRects  []rect
+	git           *git.Repo // nil when the vault is not in a git work tree
+	gitBranch     string
+	autoCommit    bool
+	autoCommitGen int
+	gitCommitting  bool // a commit is running, see gitCommit
+	gitCommitAgain bool // commit again once it finishes
+	pickVault     bool // show the vault picker once the terminal size is known
+	quitErr       error // what went wrong while quitting, see Err
 }
 
 func NewModel(v *vault.Vault) Model {
@@ -79,10 +146,13 @@ func NewModel(v *vault.Vault) Model {
 	tp := tagpane.New(v)
 	ol := outline.New()
 	cp := cmdpalette.New()
+	dv := diffview.New()
 	h := help.New()
 	h.ShowAll = false
 
-	return Model{
+	repo, _ := git.Open(v.Path)
+
+	m := Model{
 		vault:        v,
 		filetree:     ft,
 		editor:       ed,
@@ -93,20 +163,63 @@ func NewModel(v *vault.Vault) Model {
 		graph:        gr,
 		tagpane:      tp,
 		outline:      ol,
+		outlineBar:   outline.NewSidebar(),
 		cmdpalette:   cp,
+		diffview:     dv,
+		historyview:  historyview.New(),
+		prompt:       prompt.New(),
+		vaultpicker:  vaultpicker.New(),
+		jumplist:     jumplist.New(),
+		switcher:     switcher.New(),
+		bookmarkPane: bookmarks.New(),
+		folderIndex:  folderindex.New(v),
+		nav:          nav.NewHistory(),
+		recent:       nav.NewRecent(),
 		help:         h,
-		keys:       DefaultKeyMap(),
 		activePane: PaneFileTree,
 		viewMode:   ViewEdit,
 		statusMsg:  "Press ? for help | C-g:graph | Tab:switch pane",
+		activeBuf:  -1,
+		windows:    []window{{buf: -1, mode: ViewEdit}},
+		history:    newHistoryStore(v),
+		swap:       swap.NewStore(v.DataDir("swap")),
+		closeAfterSave: make(map[string]bool),
+		git:        repo,
+		autoCommit: repo != nil && config.AppConfig.GitAutoCommitInterval > 0,
+	}
+	if err := m.loadBookmarks(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+	}
+	if err := m.loadFolds(); err != nil {
+		m.statusMsg = "Error: " + err.Error()
 	}
+	m.loadKeys()
+	m.applyTreeConfig()
+	return m
+}
+
+// Err returns what failed while quitting, such as saving the session
+func (m Model) Err() error {
+	return m.quitErr
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.restoreSession(), m.refreshGitStatus(), m.scheduleAutoCommit(), m.scheduleSnapshot(), m.filetree.Init())
 }
 
+// Update handles msg, then follows the editor in the outline sidebar and
+// saves the session soon after tabs, windows or the cursor change
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if nm, ok := next.(Model); ok {
+		nm.followEditor()
+		save := nm.scheduleSessionSave()
+		return nm, tea.Batch(cmd, save)
+	}
+	return next, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -114,15 +227,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.updateLayout()
+		if m.pickVault {
+			m.pickVault = false
+			return m, m.showVaultPicker()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.prompt.Active() {
+			var cmd tea.Cmd
+			m.prompt, cmd = m.prompt.Update(msg)
+			return m, cmd
+		}
+
 		if m.cmdpalette.Active() {
 			var cmd tea.Cmd
 			m.cmdpalette, cmd = m.cmdpalette.Update(msg)
 			return m, cmd
 		}
 
+		if m.vaultpicker.Active() {
+			var cmd tea.Cmd
+			m.vaultpicker, cmd = m.vaultpicker.Update(msg)
+			return m, cmd
+		}
+
+		if m.jumplist.Active() {
+			var cmd tea.Cmd
+			m.jumplist, cmd = m.jumplist.Update(msg)
+			return m, cmd
+		}
+
+		if m.switcher.Active() {
+			var cmd tea.Cmd
+			m.switcher, cmd = m.switcher.Update(msg)
+			return m, cmd
+		}
+
+		if m.bookmarkPane.Active() {
+			var cmd tea.Cmd
+			m.bookmarkPane, cmd = m.bookmarkPane.Update(msg)
+			return m, cmd
+		}
+
+		if m.folderIndex.Active() {
+			var cmd tea.Cmd
+			m.folderIndex, cmd = m.folderIndex.Update(msg)
+			return m, cmd
+		}
+
 		if m.search.Active() {
 			var cmd tea.Cmd
 			m.search, cmd = m.search.Update(msg)
@@ -159,131 +312,89 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
-		if m.editor.InsertMode() {
+		if m.diffview.Active() {
 			var cmd tea.Cmd
-			m.editor, cmd = m.editor.Update(msg)
+			m.diffview, cmd = m.diffview.Update(msg)
 			return m, cmd
 		}
 
-		switch {
-		case key.Matches(msg, m.keys.Quit):
-			if m.editor.Modified() {
-				m.statusMsg = "Unsaved changes! C-s:save C-c:force quit"
-			} else {
-				return m, tea.Quit
-			}
-
-		case key.Matches(msg, m.keys.Help):
-			m.showHelp = !m.showHelp
-			m.help.ShowAll = m.showHelp
-			return m, nil
-
-		case key.Matches(msg, m.keys.CmdPalette):
-			m.cmdpalette.SetSize(m.width/2, m.height*3/4)
-			return m, m.cmdpalette.Show()
-
-		case key.Matches(msg, m.keys.FocusNext):
-			m.cycleFocus(1)
-			return m, nil
-
-		case key.Matches(msg, m.keys.FocusPrev):
-			m.cycleFocus(-1)
-			return m, nil
-
-		case key.Matches(msg, m.keys.FocusTree):
-			m.setActivePane(PaneFileTree)
-			return m, nil
+		if m.historyview.Active() {
+			var cmd tea.Cmd
+			m.historyview, cmd = m.historyview.Update(msg)
+			return m, cmd
+		}
 
-		case key.Matches(msg, m.keys.FocusEdit):
-			if m.viewMode == ViewPreview {
-				m.setActivePane(PanePreview)
-			} else {
-				m.setActivePane(PaneEditor)
-			}
-			return m, nil
+		if m.editor.InsertMode() {
+			var cmd tea.Cmd
+			m.editor, cmd = m.editor.Update(msg)
+			return m, tea.Batch(cmd, m.bufferActivity())
+		}
 
-		case key.Matches(msg, m.keys.Search):
-			m.search.SetSize(m.width/2, m.height/2)
-			return m, m.search.Activate()
+		if m.activePane == PaneFileTree && m.filetree.Editing() {
+			var cmd tea.Cmd
+			m.filetree, cmd = m.filetree.Update(msg)
+			return m, cmd
+		}
 
-		case key.Matches(msg, m.keys.Backlinks):
-			if m.currentFile != "" {
-				m.backlinks.SetSize(m.width/2, m.height/2)
-				m.backlinks.Show(m.currentFile)
-			}
-			return m, nil
+		if cmd, ok := m.handleKey(msg); ok {
+			return m, cmd
+		}
 
-		case key.Matches(msg, m.keys.ForwardLinks):
-			if m.currentFile != "" {
-				m.forwardlinks.SetSize(m.width/2, m.height/2)
-				m.forwardlinks.Show(m.currentFile)
-			}
-			return m, nil
+		cmd := m.updateActivePane(msg)
+		if m.activePane == PaneEditor {
+			return m, tea.Batch(cmd, m.bufferActivity())
+		}
+		return m, cmd
 
-		case key.Matches(msg, m.keys.Graph):
-			m.graph.SetSize(m.width*3/4, m.height*3/4)
-			m.graph.Show(m.currentFile)
-			return m, nil
+	case tea.BlurMsg:
+		return m, m.focusLost()
 
-		case key.Matches(msg, m.keys.Tags):
-			m.tagpane.SetSize(m.width/2, m.height*3/4)
-			m.tagpane.Show()
-			return m, nil
+	case bufferIdleMsg:
+		return m, m.handleBufferIdle(msg)
 
-		case key.Matches(msg, m.keys.Outline):
-			if m.currentFile != "" {
-				m.outline.SetSize(m.width/2, m.height*3/4)
-				content := m.editor.Content()
-				m.outline.SetContent(content, m.currentFile)
-				m.outline.Show()
-			}
+	case tea.MouseMsg:
+		if m.prompt.Active() {
 			return m, nil
-
-		case key.Matches(msg, m.keys.DailyNote):
-			return m, m.openDailyNote()
-
-		case key.Matches(msg, m.keys.ToggleView):
-			m.cycleViewMode()
-			m.updateLayout()
+		}
+		if m.diffview.Active() {
+			var cmd tea.Cmd
+			m.diffview, cmd = m.diffview.Update(msg)
+			return m, cmd
+		}
+		if m.historyview.Active() {
 			return m, nil
-
-		case key.Matches(msg, m.keys.ViewEdit):
-			m.viewMode = ViewEdit
-			m.updateLayout()
+		}
+		if m.search.Active() || m.backlinks.Active() || m.forwardlinks.Active() || m.graph.Active() || m.tagpane.Active() || m.outline.Active() || m.cmdpalette.Active() || m.vaultpicker.Active() || m.jumplist.Active() || m.switcher.Active() || m.bookmarkPane.Active() || m.folderIndex.Active() {
 			return m, nil
+		}
+		return m, m.handleMouseClick(msg)
 
-		case key.Matches(msg, m.keys.ViewPrev):
-			m.viewMode = ViewPreview
-			m.updateLayout()
-			return m, nil
+	case filetree.FileSelectedMsg:
+		return m, m.openFile(msg.Path)
 
-		case key.Matches(msg, m.keys.ViewSplit):
-			m.viewMode = ViewSplit
-			m.updateLayout()
-			return m, nil
+	case filetree.FolderIndexMsg:
+		m.showFolderIndex(msg.Dir)
+		return m, nil
 
-		case key.Matches(msg, m.keys.Save):
-			return m, m.saveCurrentFile()
+	case folderindex.FileSelectedMsg:
+		return m, m.openFile(msg.Path)
 
-		case key.Matches(msg, m.keys.Refresh):
-			m.filetree.Refresh()
-			m.statusMsg = "Vault refreshed"
-			return m, nil
+	case folderindex.CreateFolderNoteMsg:
+		return m, m.createPath(vault.FolderNotePath(msg.Dir), false)
 
-		case key.Matches(msg, m.keys.GoBack):
-			return m, m.goBack()
-		}
+	case folderindex.FolderIndexClosedMsg:
+		return m, nil
 
-		return m, m.updateActivePane(msg)
+	case filetree.SortChangedMsg:
+		m.treeSorted(msg.By, msg.Descending)
+		return m, nil
 
-	case tea.MouseMsg:
-		if m.search.Active() || m.backlinks.Active() || m.forwardlinks.Active() || m.graph.Active() || m.tagpane.Active() || m.outline.Active() || m.cmdpalette.Active() {
-			return m, nil
-		}
-		return m, m.handleMouseClick(msg)
+	case filetree.IndexedMsg:
+		m.filetree.Indexed()
+		return m, nil
 
-	case filetree.FileSelectedMsg:
-		return m, m.openFile(msg.Path)
+	case filetree.CreateMsg, filetree.RenameMsg, filetree.PasteMsg, filetree.DuplicateMsg, filetree.DeleteMsg, filetree.ClipboardMsg:
+		return m, m.handleFileOpMsg(msg)
 
 	case search.FileSelectedMsg:
 		return m, m.openFile(msg.Path)
@@ -291,8 +402,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case search.SearchClosedMsg:
 		return m, nil
 
+	case search.SaveSearchMsg, bookmarks.OpenBookmarkMsg, bookmarks.BookmarksChangedMsg:
+		return m, m.handleBookmarkMsg(msg)
+
+	case bookmarks.BookmarksClosedMsg:
+		return m, nil
+
 	case backlinks.FileSelectedMsg:
-		return m, m.openFile(msg.Path)
+		return m, m.openNoteAt(msg.Path, msg.Line)
 
 	case backlinks.BacklinksClosedMsg:
 		return m, nil
@@ -316,19 +433,145 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case outline.JumpToLineMsg:
-		m.editor.JumpToLine(msg.Line)
+		m.jumpWithinNote(msg.Line)
 		m.setActivePane(PaneEditor)
 		return m, nil
 
 	case outline.OutlineClosedMsg:
+		if m.activePane == PaneOutline {
+			m.focusContent()
+		}
 		return m, nil
 
+	case outline.EditSectionMsg:
+		return m, m.editSection(msg)
+
 	case cmdpalette.CommandMsg:
 		return m, m.executeCommand(msg.ID)
 
 	case cmdpalette.PaletteClosedMsg:
 		return m, nil
 
+	case vaultpicker.VaultSelectedMsg:
+		return m, m.switchVault(msg.Path)
+
+	case vaultpicker.VaultPickerClosedMsg:
+		return m, nil
+
+	case jumplist.ItemSelectedMsg:
+		return m, m.handleJumpSelected(msg)
+
+	case jumplist.JumpListClosedMsg:
+		return m, nil
+
+	case switcher.NoteSelectedMsg, switcher.CreateNoteMsg:
+		return m, m.handleSwitcherMsg(msg)
+
+	case switcher.SwitcherClosedMsg:
+		return m, nil
+
+	case vaultOpenedMsg:
+		return m.useVault(msg.vault)
+
+	case diffview.DiffClosedMsg:
+		return m, nil
+
+	case historyview.HistoryClosedMsg:
+		return m, nil
+
+	case historyview.RestoreMsg:
+		if msg.Path != m.currentFile {
+			return m, nil
+		}
+		m.editor.SetContent(msg.Content, msg.Path)
+		m.editor.SetModified(true)
+		m.preview.SetContent(msg.Content, msg.Path)
+		m.applyFolds(msg.Path, &m.editor, &m.preview)
+		what := "version"
+		if msg.Partial {
+			what = "changes"
+		}
+		m.statusMsg = "Restored " + what + " from " + msg.Version.Local().Format("2006-01-02 15:04") + " (unsaved)"
+		return m, nil
+
+	case fileConflictMsg:
+		m.showConflict(msg)
+		return m, nil
+
+	case prompt.ChoiceMsg:
+		if path, ok := isSwapPrompt(msg.Context); ok {
+			return m, m.handleSwapChoice(path, msg.ID)
+		}
+		if msg.Context == conflictPromptContext {
+			return m, m.handleConflictChoice(msg.ID)
+		}
+		if path, ok := strings.CutPrefix(msg.Context, closeTabPromptPrefix); ok {
+			return m, m.handleCloseTabChoice(path, msg.ID)
+		}
+		if msg.Context == trashPromptContext {
+			return m, m.handleTrashChoice(msg.ID)
+		}
+		if msg.Context == sortPromptContext {
+			m.handleSortChoice(msg.ID)
+			return m, nil
+		}
+		if msg.Context == columnsPromptContext {
+			m.handleColumnsChoice(msg.ID)
+			return m, nil
+		}
+		if format, ok := strings.CutPrefix(msg.Context, graphExportPromptPrefix); ok {
+			return m, m.handleGraphExportChoice(graph.Format(format), msg.ID)
+		}
+		return m, nil
+
+	case prompt.PromptClosedMsg:
+		if msg.Context == conflictPromptContext {
+			m.statusMsg = "Save cancelled: file changed on disk"
+			if m.conflict != nil {
+				delete(m.closeAfterSave, m.conflict.path)
+			}
+			m.conflict = nil
+		}
+		if msg.Context == trashPromptContext {
+			m.pendingTrash = nil
+		}
+		return m, nil
+
+	case historySnapshotMsg:
+		if msg.gen != m.snapshotGen {
+			return m, nil
+		}
+		return m, tea.Batch(m.snapshotBuffers(), m.scheduleSnapshot())
+
+	case gitStatusMsg:
+		m.setGitStatus(msg.status)
+		m.gitBranch = msg.branch
+		return m, nil
+
+	case gitDiffMsg:
+		m.diffview.SetSize(m.width*3/4, m.height*3/4)
+		m.diffview.Show("Diff: "+msg.path, msg.diff)
+		return m, nil
+
+	case gitResultMsg:
+		m.statusMsg = msg.text
+		if msg.refreshTree {
+			m.filetree.Refresh()
+			if m.currentFile != "" && !m.editor.Modified() {
+				return m, tea.Batch(m.openFileWithoutHistory(m.currentFile), m.refreshGitStatus())
+			}
+		}
+		return m, m.refreshGitStatus()
+
+	case gitCommitMsg:
+		return m, m.gitCommitDone(msg)
+
+	case gitAutoCommitMsg:
+		if msg.gen != m.autoCommitGen || !m.autoCommit {
+			return m, nil
+		}
+		return m, tea.Batch(m.gitCommit(), m.scheduleAutoCommit())
+
 	case liveeditor.SaveRequestMsg:
 		return m, m.saveFile(msg.Path, msg.Content)
 
@@ -338,21 +581,77 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case preview.LinkFollowMsg:
 		return m, m.followLink(msg.Target)
 
+	case preview.EmbedOpenMsg:
+		return m, m.openNoteAt(msg.Path, msg.Line)
+
+	case liveeditor.BlockLinkMsg:
+		m.copyBlockLink(msg.Path, msg.ID)
+		return m, nil
+
+	case liveeditor.FoldsChangedMsg:
+		m.foldsChanged(msg.Path, msg.Folds, false)
+		return m, nil
+
+	case preview.FoldsChangedMsg:
+		m.foldsChanged(msg.Path, msg.Folds, true)
+		return m, nil
+
 	case fileSavedMsg:
 		m.statusMsg = "File saved: " + msg.path
-		m.editor.SetModified(false)
+		if msg.path == m.currentFile {
+			m.editor.SetModified(m.editor.Content() != msg.content)
+			m.baseContent = msg.content
+			m.baseRev = msg.rev
+		} else if i := m.bufferIndex(msg.path); i >= 0 {
+			b := &m.buffers[i]
+			b.editor.SetModified(b.editor.Content() != msg.content)
+			b.baseContent = msg.content
+			b.baseRev = msg.rev
+		}
+		m.swap.Remove(msg.path)
+		m.closeSavedTab(msg.path)
+		if m.git != nil && config.AppConfig.GitCommitOnSave {
+			return m, m.gitCommit()
+		}
+		return m, m.refreshGitStatus()
+
+	case sessionRestoredMsg:
+		if msg.session != nil {
+			m.applySession(msg)
+		}
+		m.sessionRestored = true
+		m.sessionState = m.sessionKey()
+		return m, nil
+
+	case sessionSaveMsg:
+		if msg.gen == m.sessionGen {
+			if err := m.saveSession(); err != nil {
+				m.statusMsg = "Error: saving session: " + err.Error()
+			}
+		}
 		return m, nil
 
 	case fileOpenedMsg:
-		m.currentFile = msg.path
-		m.editor.SetContent(msg.content, msg.path)
-		m.preview.SetContent(msg.content, msg.path)
+		created := m.showFile(msg)
+		m.applyPendingJump(msg.path)
 		m.statusMsg = "Opened: " + msg.path
 		if m.activePane == PaneFileTree {
-			m.cycleFocus(1)
+			m.focusContent()
+		}
+		if created {
+			m.offerRecovery(msg.path, msg.content)
 		}
 		return m, nil
 
+	case infoMsg:
+		m.statusMsg = msg.text
+		return m, nil
+
+	case fileSaveFailedMsg:
+		delete(m.closeAfterSave, msg.path)
+		m.statusMsg = "Error: " + msg.err.Error()
+		return m, nil
+
 	case errMsg:
 		m.statusMsg = "Error: " + msg.err.Error()
 		return m, nil
@@ -366,7 +665,7 @@ func (m Model) View() string {
 		return "Loading..."
 	}
 
-	mainContent := m.renderMainContent()
+	mainContent := m.renderPeek(m.renderMainContent())
 
 	if m.search.Active() {
 		overlay := m.search.View()
@@ -403,6 +702,46 @@ func (m Model) View() string {
 		mainContent = m.overlayCenter(mainContent, overlay)
 	}
 
+	if m.vaultpicker.Active() {
+		overlay := m.vaultpicker.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.jumplist.Active() {
+		overlay := m.jumplist.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.switcher.Active() {
+		overlay := m.switcher.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.bookmarkPane.Active() {
+		overlay := m.bookmarkPane.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.folderIndex.Active() {
+		overlay := m.folderIndex.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.diffview.Active() {
+		overlay := m.diffview.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.historyview.Active() {
+		overlay := m.historyview.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
+	if m.prompt.Active() {
+		overlay := m.prompt.View()
+		mainContent = m.overlayCenter(mainContent, overlay)
+	}
+
 	statusBar := m.renderStatusBar()
 	helpView := m.help.View(m.keys)
 
@@ -438,51 +777,86 @@ func (m *Model) updateLayout() {
 		contentWidth = 20
 	}
 
+	// The outline sidebar takes its room from the content, if there is enough
+	m.cachedOutlineW = 0
+	if config.AppConfig.OutlineSidebar {
+		outlineWidth := min(max(m.width/5, 20), 36)
+		if contentWidth-outlineWidth-2 >= 40 {
+			m.cachedOutlineW = outlineWidth
+			contentWidth -= outlineWidth + 2
+		}
+	}
+	m.outlineBar.SetSize(m.cachedOutlineW, contentHeight)
+
 	m.cachedTreeW = treeWidth
 	m.cachedContentW = contentWidth
 
 	m.filetree.SetSize(treeWidth, contentHeight)
 
-	switch m.viewMode {
-	case ViewEdit:
-		m.editor.SetSize(contentWidth, contentHeight)
-	case ViewPreview:
-		m.preview.SetSize(contentWidth, contentHeight)
-	case ViewSplit:
-		halfWidth := contentWidth / 2
-		m.editor.SetSize(halfWidth, contentHeight)
-		m.preview.SetSize(contentWidth-halfWidth, contentHeight)
-	}
+	// The tab bar takes the first line of the content column
+	m.windows[m.activeWin].mode = m.viewMode
+	m.layoutWindows(rect{x: treeWidth + 2, y: 1, w: contentWidth, h: contentHeight - 1})
 
 	m.search.SetSize(m.width/2, m.height/2)
 	m.backlinks.SetSize(m.width/2, m.height/2)
 }
 
 func (m *Model) cycleFocus(direction int) {
-	panes := []Pane{PaneFileTree}
-	switch m.viewMode {
-	case ViewEdit:
-		panes = append(panes, PaneEditor)
-	case ViewPreview:
-		panes = append(panes, PanePreview)
-	case ViewSplit:
-		panes = append(panes, PaneEditor, PanePreview)
+	// Focus stops: the tree, then the panes of every window in order
+	type stop struct {
+		win  int
+		pane Pane
+	}
+	stops := []stop{{win: -1, pane: PaneFileTree}}
+	for i, w := range m.windows {
+		mode := w.mode
+		if i == m.activeWin {
+			mode = m.viewMode
+		}
+		switch mode {
+		case ViewEdit:
+			stops = append(stops, stop{i, PaneEditor})
+		case ViewPreview:
+			stops = append(stops, stop{i, PanePreview})
+		case ViewSplit:
+			stops = append(stops, stop{i, PaneEditor}, stop{i, PanePreview})
+		}
+	}
+	if m.cachedOutlineW > 0 {
+		stops = append(stops, stop{win: -1, pane: PaneOutline})
 	}
 
 	currentIdx := 0
-	for i, p := range panes {
-		if p == m.activePane {
+	for i, st := range stops {
+		if st.pane == m.activePane && (st.win == m.activeWin || st.win < 0) {
 			currentIdx = i
 			break
 		}
 	}
 
-	newIdx := (currentIdx + direction + len(panes)) % len(panes)
-	m.activePane = panes[newIdx]
+	next := stops[(currentIdx+direction+len(stops))%len(stops)]
+	if next.win >= 0 {
+		m.focusWindow(next.win)
+	}
+	m.setActivePane(next.pane)
+}
 
-	m.filetree.SetFocused(m.activePane == PaneFileTree)
-	m.editor.SetFocused(m.activePane == PaneEditor)
-	m.preview.SetFocused(m.activePane == PanePreview)
+// focusContent moves focus to the active window's editor, or its preview in
+// preview mode
+func (m *Model) focusContent() {
+	if m.viewMode == ViewPreview {
+		m.setActivePane(PanePreview)
+	} else {
+		m.setActivePane(PaneEditor)
+	}
+}
+
+// paneChanged reports a focus move away from the editor
+func (m *Model) paneChanged(prev Pane) tea.Cmd {
+	if prev == PaneEditor && m.activePane != PaneEditor {
+		return m.focusLost()
+	}
+	return nil
 }
 
 func (m *Model) setActivePane(pane Pane) {
@@ -490,26 +864,22 @@ func (m *Model) setActivePane(pane Pane) {
 	m.filetree.SetFocused(pane == PaneFileTree)
 	m.editor.SetFocused(pane == PaneEditor)
 	m.preview.SetFocused(pane == PanePreview)
-}
-
-func (m *Model) goBack() tea.Cmd {
-	if len(m.historyStack) > 1 {
-		m.historyStack = m.historyStack[:len(m.historyStack)-1]
-		prevFile := m.historyStack[len(m.historyStack)-1]
-		return m.openFileWithoutHistory(prevFile)
+	// Synced before focus, so the sidebar starts at the cursor's section
+	if pane == PaneOutline {
+		m.syncOutline()
 	}
-	m.statusMsg = "No history"
-	return nil
+	m.outlineBar.SetFocused(pane == PaneOutline)
 }
 
 func (m *Model) openFileWithoutHistory(path string) tea.Cmd {
-	return func() tea.Msg {
-		content, err := m.vault.ReadFile(path)
+	leave := m.leaveBuffer(path)
+	return tea.Batch(leave, func() tea.Msg {
+		content, rev, err := m.vault.ReadFileRevision(path)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fileOpenedMsg{path: path, content: content}
-	}
+		return fileOpenedMsg{path: path, content: content, rev: rev}
+	})
 }
 
 func (m *Model) cycleViewMode() {
@@ -543,60 +913,78 @@ func (m *Model) updateActivePane(msg tea.Msg) tea.Cmd {
 		m.editor, cmd = m.editor.Update(msg)
 	case PanePreview:
 		m.preview, cmd = m.preview.Update(msg)
+	case PaneOutline:
+		m.syncOutline()
+		m.outlineBar, cmd = m.outlineBar.Update(msg)
 	}
 
 	return cmd
 }
 
 func (m *Model) handleMouseClick(msg tea.MouseMsg) tea.Cmd {
+	if len(m.winRects) == 0 {
+		return nil
+	}
+
 	// Handle focus change only on press
 	if msg.Action == tea.MouseActionPress {
 		if msg.X < m.cachedTreeW+1 {
-			m.activePane = PaneFileTree
-			m.filetree.SetFocused(true)
-			m.editor.SetFocused(false)
-			m.preview.SetFocused(false)
+			m.setActivePane(PaneFileTree)
+		} else if m.cachedOutlineW > 0 && msg.X >= m.cachedTreeW+2+m.cachedContentW {
+			m.setActivePane(PaneOutline)
+		} else if msg.Y == 0 {
+			if msg.Button == tea.MouseButtonLeft {
+				if i := m.tabAt(msg.X - m.cachedTreeW - 2); i >= 0 {
+					leave := m.leaveBuffer(m.buffers[i].path)
+					m.switchBuffer(i)
+					return leave
+				}
+			}
+			return nil
 		} else {
-			relX := msg.X - m.cachedTreeW - 2
+			for i, r := range m.winRects {
+				if r.contains(msg.X, msg.Y) {
+					m.focusWindow(i)
+					break
+				}
+			}
+			r := m.winRects[m.activeWin]
 
 			switch m.viewMode {
 			case ViewEdit:
-				m.activePane = PaneEditor
+				m.setActivePane(PaneEditor)
 			case ViewPreview:
-				m.activePane = PanePreview
+				m.setActivePane(PanePreview)
 			case ViewSplit:
-				if relX < m.cachedContentW/2 {
-					m.activePane = PaneEditor
+				if msg.X-r.x < r.w/2 {
+					m.setActivePane(PaneEditor)
 				} else {
-					m.activePane = PanePreview
+					m.setActivePane(PanePreview)
 				}
 			}
-
-			m.filetree.SetFocused(m.activePane == PaneFileTree)
-			m.editor.SetFocused(m.activePane == PaneEditor)
-			m.preview.SetFocused(m.activePane == PanePreview)
 		}
 	}
 
 	// Create adjusted mouse message for each pane
 	adjustedMsg := msg
+	r := m.winRects[m.activeWin]
 	switch m.activePane {
 	case PaneFileTree:
 		// Adjust for border (1 pixel)
 		adjustedMsg.X = msg.X - 1
 		adjustedMsg.Y = msg.Y - 1
 	case PaneEditor:
-		// Adjust for tree width + borders
-		adjustedMsg.X = msg.X - m.cachedTreeW - 2
-		adjustedMsg.Y = msg.Y - 1
+		// Adjust for the window position and the editor header
+		adjustedMsg.X = msg.X - r.x
+		adjustedMsg.Y = msg.Y - r.y - 1
 	case PanePreview:
-		// Adjust for tree width + editor (in split) + borders
+		// Adjust for the window position, the editor (in split) and borders
 		if m.viewMode == ViewSplit {
-			adjustedMsg.X = msg.X - m.cachedTreeW - m.cachedContentW/2 - 3
+			adjustedMsg.X = msg.X - r.x - r.w/2 - 1
 		} else {
-			adjustedMsg.X = msg.X - m.cachedTreeW - 2
+			adjustedMsg.X = msg.X - r.x
 		}
-		adjustedMsg.Y = msg.Y - 1
+		adjustedMsg.Y = msg.Y - r.y - 1
 	}
 
 	return m.updateActivePaneWithMsg(adjustedMsg)
@@ -612,6 +1000,9 @@ func (m *Model) updateActivePaneWithMsg(msg tea.Msg) tea.Cmd {
 		m.editor, cmd = m.editor.Update(msg)
 	case PanePreview:
 		m.preview, cmd = m.preview.Update(msg)
+	case PaneOutline:
+		m.syncOutline()
+		m.outlineBar, cmd = m.outlineBar.Update(msg)
 	}
 
 	return cmd
@@ -625,27 +1016,22 @@ func (m Model) renderMainContent() string {
 
 	treeView := treeBorder.Width(m.cachedTreeW).Render(m.filetree.View())
 
-	var contentView string
-	switch m.viewMode {
-	case ViewEdit:
-		contentView = m.editor.View()
-	case ViewPreview:
-		contentView = m.preview.View()
-	case ViewSplit:
-		contentView = lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			m.editor.View(),
-			m.preview.View(),
-		)
-	}
+	contentView := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.renderTabBar(m.cachedContentW),
+		m.renderWindows(),
+	)
 
+	if m.cachedOutlineW > 0 {
+		return lipgloss.JoinHorizontal(lipgloss.Top, treeView, contentView, m.renderOutline())
+	}
 	return lipgloss.JoinHorizontal(lipgloss.Top, treeView, contentView)
 }
 
 func (m Model) renderStatusBar() string {
 	vaultName := filepath.Base(m.vault.Path)
 
-	left := StatusBarStyle.Render(" " + vaultName)
+	left := StatusBarStyle.Render(" " + vaultName + m.gitBranchLabel())
 
 	// Mode and view
 	modeStr := "NORMAL"
@@ -675,6 +1061,8 @@ func (m Model) renderStatusBar() string {
 			hints = "i:insert /:search C-e:view gd:link"
 		case PanePreview:
 			hints = "j/k:scroll Enter:link C-e:view"
+		case PaneOutline:
+			hints = "Enter:jump </>:level J/K:move x/X:extract"
 		}
 	}
 
@@ -749,33 +1137,67 @@ func (m Model) overlayCenter(base, overlay string) string {
 	return strings.Join(baseLines, "\n")
 }
 
+// overlayAt draws overlay over base with its top left corner at x, y,
+// keeping the styling of what is left of base on either side
+func overlayAt(base, overlay string, x, y int) string {
+	baseLines := strings.Split(base, "\n")
+	for i, line := range strings.Split(overlay, "\n") {
+		if y+i < 0 || y+i >= len(baseLines) {
+			continue
+		}
+		baseLine := baseLines[y+i]
+		left := ansi.Truncate(baseLine, x, "")
+		if w := ansi.StringWidth(left); w < x {
+			left += strings.Repeat(" ", x-w)
+		}
+		right := ansi.TruncateLeft(baseLine, x+ansi.StringWidth(line), "")
+		baseLines[y+i] = left + "\x1b[0m" + line + "\x1b[0m" + right
+	}
+	return strings.Join(baseLines, "\n")
+}
+
 type fileSavedMsg struct {
+	path    string
+	content string
+	rev     vault.Revision
+}
+
+// fileSaveFailedMsg reports a save that failed for a reason other than a
+// conflict
+type fileSaveFailedMsg struct {
 	path string
+	err  error
 }
 
 type fileOpenedMsg struct {
 	path    string
 	content string
+	rev     vault.Revision
 }
 
 type errMsg struct {
 	err error
 }
 
+// infoMsg reports the result of a background command in the status bar
+type infoMsg struct {
+	text string
+}
+
 func (m *Model) openFile(path string) tea.Cmd {
-	return func() tea.Msg {
-		content, err := m.vault.ReadFile(path)
+	m.recordVisit(path)
+	leave := m.leaveBuffer(path)
+	return tea.Batch(leave, func() tea.Msg {
+		content, rev, err := m.vault.ReadFileRevision(path)
 		if err != nil {
 			return errMsg{err: err}
 		}
 
-		m.historyStack = append(m.historyStack, path)
-
 		config.AppConfig.LastOpenFile = path
 		config.Save()
 
-		return fileOpenedMsg{path: path, content: content}
-	}
+		return fileOpenedMsg{path: path, content: content, rev: rev}
+	})
 }
 
 func (m *Model) saveCurrentFile() tea.Cmd {
@@ -786,26 +1208,66 @@ func (m *Model) saveCurrentFile() tea.Cmd {
 }
 
 func (m *Model) saveFile(path, content string) tea.Cmd {
+	var base vault.Revision
+	if path == m.currentFile {
+		base = m.baseRev
+	} else if i := m.bufferIndex(path); i >= 0 {
+		base = m.buffers[i].baseRev
+	}
+
 	return func() tea.Msg {
-		if err := m.vault.WriteFile(path, content); err != nil {
-			return errMsg{err: err}
+		// Keep the previous content as a version before overwriting it, then
+		// the saved content so the history always ends at what is on disk.
+		// History errors must not block the save itself.
+		now := time.Now()
+		if old, err := m.vault.ReadFile(path); err == nil && old != "" {
+			m.history.Save(path, old, now)
+		}
+
+		rev, err := m.vault.SaveFile(path, content, base)
+		var conflict *vault.ConflictError
+		if errors.As(err, &conflict) {
+			return fileConflictMsg{path: path, mine: content, conflict: conflict}
+		}
+		if err != nil {
+			return fileSaveFailedMsg{path: path, err: err}
 		}
-		return fileSavedMsg{path: path}
+		m.history.Save(path, content, now)
+		return fileSavedMsg{path: path, content: content, rev: rev}
 	}
 }
 
 func (m *Model) followLink(target string) tea.Cmd {
-	resolved := m.vault.FindFile(target + ".md")
-	if resolved == "" {
-		resolved = m.vault.FindFile(target)
+	note, subpath := parser.SplitSubpath(target)
+	resolved := m.currentFile
+	if note != "" {
+		resolved = m.vault.FindFile(note + ".md")
+		if resolved == "" {
+			resolved = m.vault.FindFile(note)
+		}
 	}
 
 	if resolved == "" {
 		m.statusMsg = "Link not found: " + target
 		return nil
 	}
+	if subpath == "" {
+		return m.openFile(resolved)
+	}
 
-	return m.openFile(resolved)
+	// Links to a heading or a block open the note there
+	content := m.editor.Content()
+	if resolved != m.currentFile {
+		var err error
+		if content, err = m.vault.ReadFile(resolved); err != nil {
+			return func() tea.Msg { return errMsg{err: err} }
+		}
+	}
+	line := parser.SubpathLine(strings.Split(content, "\n"), subpath)
+	if line < 0 {
+		m.statusMsg = "Not found in " + resolved + ": " + subpath
+	}
+	return m.openNoteAt(resolved, line)
 }
 
 func (m *Model) openDailyNote() tea.Cmd {
@@ -819,15 +1281,16 @@ func (m *Model) openDailyNote() tea.Cmd {
 		dailyPath = dailyFolder + "/" + today + ".md"
 	}
 
-	return func() tea.Msg {
+	m.recordVisit(dailyPath)
+	leave := m.leaveBuffer(dailyPath)
+	return tea.Batch(leave, func() tea.Msg {
 		// Check if file exists
-		content, err := m.vault.ReadFile(dailyPath)
+		content, rev, err := m.vault.ReadFileRevision(dailyPath)
 		if err == nil {
 			// File exists, open it
-			m.historyStack = append(m.historyStack, dailyPath)
 			config.AppConfig.LastOpenFile = dailyPath
 			config.Save()
-			return fileOpenedMsg{path: dailyPath, content: content}
+			return fileOpenedMsg{path: dailyPath, content: content, rev: rev}
 		}
 
 		// Create new daily note
@@ -839,12 +1302,12 @@ func (m *Model) openDailyNote() tea.Cmd {
 			return errMsg{err: err}
 		}
 
-		m.historyStack = append(m.historyStack, dailyPath)
 		config.AppConfig.LastOpenFile = dailyPath
 		config.Save()
 
-		return fileOpenedMsg{path: dailyPath, content: template}
-	}
+		_, rev, _ = m.vault.ReadFileRevision(dailyPath)
+		return fileOpenedMsg{path: dailyPath, content: template, rev: rev}
+	})
 }
 
 func (m *Model) executeCommand(id string) tea.Cmd {
@@ -855,6 +1318,9 @@ func (m *Model) executeCommand(id string) tea.Cmd {
 	case "graph":
 		m.graph.SetSize(m.width*3/4, m.height*3/4)
 		m.graph.Show(m.currentFile)
+	case "graph-analytics":
+		m.graph.SetSize(m.width*3/4, m.height*3/4)
+		m.graph.ShowAnalytics(m.currentFile)
 	case "tags":
 		m.tagpane.SetSize(m.width/2, m.height*3/4)
 		m.tagpane.Show()
@@ -881,9 +1347,36 @@ func (m *Model) executeCommand(id string) tea.Cmd {
 	case "refresh":
 		m.filetree.Refresh()
 		m.statusMsg = "Vault refreshed"
+		return m.refreshGitStatus()
 	case "newfile":
-		// TODO: implement new file dialog
-		m.statusMsg = "New file: use Ctrl+N"
+		return m.startCreate(false)
+	case "newfolder":
+		return m.startCreate(true)
+	case "rename-file":
+		return m.startRename()
+	case "delete-file":
+		m.deleteSelection()
+	case "reveal-file":
+		return m.revealFile()
+	case "outline-sidebar":
+		m.toggleOutlineSidebar()
+	case "tree-filter":
+		return tea.Batch(m.focusTree(), m.filetree.StartFilter())
+	case "tree-sort":
+		m.showSortPrompt()
+	case "tree-collapse-all":
+		m.filetree.CollapseAll()
+	case "tree-expand-all":
+		m.filetree.ExpandAll()
+	case "tree-columns":
+		m.showColumnsPrompt()
+	case "folder-index":
+		m.showFolderIndex(m.currentFolder())
+	case "tree-peek":
+		m.filetree.TogglePeek()
+		if m.filetree.Peeking() {
+			return m.focusTree()
+		}
 	case "help":
 		m.showHelp = !m.showHelp
 		m.help.ShowAll = m.showHelp
@@ -899,6 +1392,162 @@ func (m *Model) executeCommand(id string) tea.Cmd {
 	case "toggle":
 		m.cycleViewMode()
 		m.updateLayout()
+	case "export-html":
+		return m.exportNotes(export.Selection{Note: m.currentFile}, export.FormatHTML)
+	case "export-pdf":
+		return m.exportNotes(export.Selection{Note: m.currentFile}, export.FormatPrint)
+	case "export-markdown":
+		return m.exportNotes(export.Selection{Note: m.currentFile}, export.FormatMarkdown)
+	case "export-folder-html":
+		if m.currentFile != "" {
+			return m.exportNotes(export.Selection{Folder: filepath.Dir(m.currentFile)}, export.FormatHTML)
+		}
+	case "publish":
+		return m.publishSite()
+	case "export-graph-dot":
+		m.showGraphExportPrompt(graph.FormatDOT)
+	case "export-graph-graphml":
+		m.showGraphExportPrompt(graph.FormatGraphML)
+	case "export-graph-json":
+		m.showGraphExportPrompt(graph.FormatJSON)
+	case "git-status":
+		if m.git == nil {
+			m.statusMsg = "Vault is not a git repository"
+			return nil
+		}
+		m.statusMsg = "Git status refreshed"
+		return m.refreshGitStatus()
+	case "git-diff":
+		return m.gitDiffCurrent()
+	case "git-commit":
+		return m.gitCommit()
+	case "git-pull":
+		return m.gitPull()
+	case "git-push":
+		return m.gitPush()
+	case "git-auto-commit":
+		return m.toggleAutoCommit()
+	case "git-init":
+		return m.gitInit()
+	case "vault-switch":
+		return m.showVaultPicker()
+	case "quick-switch":
+		return m.showSwitcher()
+	case "go-back":
+		return m.goBack()
+	case "go-forward":
+		return m.goForward()
+	case "jump-list":
+		return m.showJumpList()
+	case "recent-files":
+		return m.showRecentFiles()
+	case "keymap-problems":
+		m.showKeyProblems()
+	case "bookmarks":
+		return m.showBookmarks()
+	case "bookmark-toggle":
+		m.toggleBookmark()
+	case "copy-block-link":
+		if m.currentFile != "" {
+			id, _ := m.editor.AddBlockID()
+			m.copyBlockLink(m.currentFile, id)
+			return m.bufferActivity()
+		}
+	case "insert-table":
+		if m.currentFile == "" {
+			break
+		}
+		if m.viewMode == ViewPreview {
+			m.statusMsg = "Switch to the editor to insert a table"
+			break
+		}
+		m.editor.InsertTable(3, 2)
+		m.setActivePane(PaneEditor)
+		return m.bufferActivity()
+	case "format-table":
+		if !m.editor.FormatTable() {
+			m.statusMsg = "No table at the cursor"
+			break
+		}
+		return m.bufferActivity()
+	case "bookmark-heading":
+		m.bookmarkHeading()
+	case "bookmark-folder":
+		if dir := filepath.Dir(m.currentFile); m.currentFile != "" && dir != "." {
+			m.addBookmark(bookmark.NewFolder(dir), false)
+		} else {
+			m.statusMsg = "The current note is not in a folder"
+		}
+	case "history":
+		m.showHistory()
+	case "tab-next":
+		return m.cycleTab(1)
+	case "tab-prev":
+		return m.cycleTab(-1)
+	case "tab-close":
+		m.closeTab()
+	case "tab-move-left":
+		m.moveTab(-1)
+	case "tab-move-right":
+		m.moveTab(1)
+	case "split-vertical":
+		m.splitWindow(SplitVertical)
+	case "split-horizontal":
+		m.splitWindow(SplitHorizontal)
+	case "window-next":
+		m.focusWindow((m.activeWin + 1) % len(m.windows))
+	case "window-close":
+		m.closeWindow()
+	default:
+		if strings.HasPrefix(id, bookmarkCommandPrefix) {
+			return m.runBookmarkCommand(id)
+		}
 	}
 	return nil
 }
+
+func (m *Model) exportNotes(sel export.Selection, format export.Format) tea.Cmd {
+	if m.currentFile == "" {
+		m.statusMsg = "No file open"
+		return nil
+	}
+
+	return func() tea.Msg {
+		m.vault.WaitIndex()
+
+		var notes []string
+		if sel.Folder == "." {
+			// Notes at the vault root
+			for _, n := range export.SelectNotes(m.vault, export.Selection{}) {
+				if filepath.Dir(n) == "." {
+					notes = append(notes, n)
+				}
+			}
+		} else {
+			notes = export.SelectNotes(m.vault, sel)
+		}
+
+		dir := m.vault.DataDir("export")
+		written, err := export.Export(m.vault, notes, export.Options{Format: format, OutDir: dir, Theme: "light"})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if len(written) == 1 {
+			return infoMsg{text: "Exported: " + written[0]}
+		}
+		return infoMsg{text: fmt.Sprintf("Exported %d files to %s", len(written), dir)}
+	}
+}
+
+func (m *Model) publishSite() tea.Cmd {
+	return func() tea.Msg {
+		m.vault.WaitIndex()
+
+		dir := m.vault.DataDir("site")
+		result, err := publish.Build(m.vault, publish.Options{OutDir: dir})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return infoMsg{text: fmt.Sprintf("Published %d notes to %s", result.Notes, dir)}
+	}
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/components/jumplist"
+	"github.com/takahashinaoki/obsidiantui/internal/nav"
+)
+
+const (
+	jumpListContext    = "jumps"
+	recentFilesContext = "recent"
+)
+
+// jumpContext is how many lines are kept above a location jumped to
+const jumpContext = 5
+
+// currentLocation is where the cursor is in the active note
+func (m Model) currentLocation() nav.Location {
+	return nav.Location{
+		Path: m.currentFile,
+		Line: m.editor.CursorRow(),
+		Col:  m.editor.CursorCol(),
+		Time: time.Now(),
+	}
+}
+
+// recordVisit adds path to the back/forward history before it is opened
+func (m *Model) recordVisit(path string) {
+	if path == m.currentFile {
+		return
+	}
+	if m.currentFile != "" {
+		m.syncNav()
+	}
+	m.nav.Visit(nav.Location{Path: path, Time: time.Now()})
+}
+
+// syncNav saves the cursor into the current history entry. If the user moved
+// to another note without navigating, e.g. by switching tabs, that note is
+// added first so going back returns to it.
+func (m *Model) syncNav() {
+	loc := m.currentLocation()
+	if cur, ok := m.nav.Current(); ok && cur.Path != loc.Path {
+		m.nav.Visit(loc)
+	}
+	m.nav.Update(loc)
+}
+
+func (m *Model) goBack() tea.Cmd {
+	m.syncNav()
+	loc, ok := m.nav.Back(m.currentLocation())
+	if !ok {
+		m.statusMsg = "No history"
+		return nil
+	}
+	return m.jumpTo(loc)
+}
+
+func (m *Model) goForward() tea.Cmd {
+	m.syncNav()
+	loc, ok := m.nav.Forward(m.currentLocation())
+	if !ok {
+		m.statusMsg = "No forward history"
+		return nil
+	}
+	return m.jumpTo(loc)
+}
+
+// jumpTo shows a location without recording it as a new visit
+func (m *Model) jumpTo(loc nav.Location) tea.Cmd {
+	if loc.Path == m.currentFile {
+		m.editor.SetCursor(loc.Line, loc.Col, loc.Line-jumpContext)
+		return nil
+	}
+	m.pendingJump = &loc
+	return m.openFileWithoutHistory(loc.Path)
+}
+
+// applyPendingJump moves the cursor of a note opened by jumpTo
+func (m *Model) applyPendingJump(path string) {
+	if m.pendingJump == nil || m.pendingJump.Path != path {
+		return
+	}
+	loc := *m.pendingJump
+	m.pendingJump = nil
+	m.editor.SetCursor(loc.Line, loc.Col, loc.Line-jumpContext)
+}
+
+// jumpWithinNote moves to a line in the active note, recording both ends in
+// the history
+func (m *Model) jumpWithinNote(line int) {
+	m.syncNav()
+	m.editor.JumpToLine(line)
+	m.nav.Visit(m.currentLocation())
+}
+
+func (m *Model) showJumpList() tea.Cmd {
+	m.syncNav()
+	entries := m.nav.Entries()
+
+	// Newest first, so index i in the list is entry len-1-i
+	items := make([]jumplist.Item, len(entries))
+	for i, loc := range entries {
+		items[len(entries)-1-i] = jumplist.Item{
+			Label:   loc.Path,
+			Detail:  fmt.Sprintf("line %d · %s", loc.Line+1, loc.Time.Format("15:04")),
+			Current: i == m.nav.Index(),
+		}
+	}
+	m.jumplist.SetSize(m.width/2, m.height*3/4)
+	return m.jumplist.Show("Jump List", jumpListContext, items, len(entries)-1-m.nav.Index())
+}
+
+func (m *Model) showRecentFiles() tea.Cmd {
+	m.recentShown = m.recentShown[:0]
+	var items []jumplist.Item
+	for _, p := range m.recent.Paths() {
+		if !m.vault.HasFile(p) {
+			continue
+		}
+		m.recentShown = append(m.recentShown, p)
+		items = append(items, jumplist.Item{Label: p, Current: p == m.currentFile})
+	}
+
+	// Start on the previous note so Enter flips between the last two
+	cursor := 0
+	if len(items) > 1 && items[0].Current {
+		cursor = 1
+	}
+	m.jumplist.SetSize(m.width/2, m.height*3/4)
+	return m.jumplist.Show("Recent Files", recentFilesContext, items, cursor)
+}
+
+func (m *Model) handleJumpSelected(msg jumplist.ItemSelectedMsg) tea.Cmd {
+	switch msg.Context {
+	case jumpListContext:
+		i := len(m.nav.Entries()) - 1 - msg.Index
+		loc, ok := m.nav.Go(i, m.currentLocation())
+		if !ok {
+			return nil
+		}
+		return m.jumpTo(loc)
+	case recentFilesContext:
+		if msg.Index < len(m.recentShown) {
+			return m.openFile(m.recentShown[msg.Index])
+		}
+	}
+	return nil
+}
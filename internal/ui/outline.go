@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/outline"
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// noteNameReplacer drops what can't be in a note name or a wikilink to it
+var noteNameReplacer = strings.NewReplacer(
+	"/", "", `\`, "", ":", "", "*", "", "?", "", `"`, "", "<", "", ">", "",
+	"|", "", "#", "", "^", "", "[", "", "]", "",
+)
+
+// toggleOutlineSidebar shows or hides the outline beside the editor
+func (m *Model) toggleOutlineSidebar() {
+	config.AppConfig.OutlineSidebar = !config.AppConfig.OutlineSidebar
+	config.Save()
+	m.updateLayout()
+	if m.cachedOutlineW == 0 && m.activePane == PaneOutline {
+		m.focusContent()
+	}
+	if config.AppConfig.OutlineSidebar && m.cachedOutlineW == 0 {
+		m.statusMsg = "The window is too narrow for the outline sidebar"
+	}
+}
+
+// outlineAt is the note, revision of its text and line the sidebar was
+// synced to
+type outlineAt struct {
+	file string
+	rev  int64
+	line int
+}
+
+// syncOutline points the sidebar at the note and line being edited
+func (m *Model) syncOutline() {
+	m.outlineBar.Sync(m.editor.Content(), m.currentFile, m.editor.CursorRow())
+	m.outlineAt = outlineAt{m.currentFile, m.editor.Revision(), m.editor.CursorRow()}
+}
+
+// followEditor syncs the sidebar after the note or the cursor's line changed
+func (m *Model) followEditor() {
+	if m.cachedOutlineW == 0 {
+		return
+	}
+	at := outlineAt{m.currentFile, m.editor.Revision(), m.editor.CursorRow()}
+	if at != m.outlineAt {
+		m.syncOutline()
+	}
+}
+
+func (m Model) renderOutline() string {
+	border := BorderInactiveStyle
+	if m.activePane == PaneOutline {
+		border = BorderActiveStyle
+	}
+	return border.Width(m.cachedOutlineW).Render(m.outlineBar.View())
+}
+
+// editSection changes the structure of the note from the outline
+func (m *Model) editSection(msg outline.EditSectionMsg) tea.Cmd {
+	if m.currentFile == "" {
+		return nil
+	}
+	lines := strings.Split(m.editor.Content(), "\n")
+	line := msg.Line
+
+	var out []string
+	var ok bool
+	failed := ""
+	switch msg.Op {
+	case outline.Promote:
+		out, ok = parser.ShiftSection(lines, line, -1)
+		failed = "Headings can't go above level 1"
+	case outline.Demote:
+		out, ok = parser.ShiftSection(lines, line, 1)
+		failed = "Headings can't go below level 6"
+	case outline.MoveUp:
+		out, line, ok = parser.MoveSection(lines, line, -1)
+		failed = "No section above at this level"
+	case outline.MoveDown:
+		out, line, ok = parser.MoveSection(lines, line, 1)
+		failed = "No section below at this level"
+	case outline.ExtractLink, outline.ExtractEmbed:
+		return m.extractSection(lines, line, msg.Op == outline.ExtractEmbed)
+	case outline.Undo, outline.Redo:
+		return m.undoSectionEdit(msg.Op == outline.Redo)
+	}
+	if !ok {
+		m.statusMsg = failed
+		return nil
+	}
+	m.replaceContent(strings.Join(out, "\n"), line)
+	return m.bufferActivity()
+}
+
+// extractSection moves a section to a new note named after its heading,
+// next to the current note, and links or embeds the new note in its place
+func (m *Model) extractSection(lines []string, line int, embed bool) tea.Cmd {
+	if line < 0 || line >= len(lines) {
+		return nil
+	}
+	name := strings.TrimSpace(noteNameReplacer.Replace(strings.TrimLeft(strings.TrimSpace(lines[line]), "#")))
+	if name == "" || strings.HasPrefix(name, ".") {
+		m.statusMsg = "Error: the heading doesn't make a note name"
+		return nil
+	}
+	path := m.vault.AvailablePath(filepath.Join(filepath.Dir(m.currentFile), name+".md"))
+	link := "[[" + strings.TrimSuffix(filepath.Base(path), ".md") + "]]"
+	if embed {
+		link = "!" + link
+	}
+
+	section, out, ok := parser.ExtractSection(lines, line, link)
+	if !ok {
+		return nil
+	}
+	if err := m.vault.CreateFile(path); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	if err := m.vault.WriteFile(path, section+"\n"); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	m.filetree.Refresh()
+
+	m.replaceContent(strings.Join(out, "\n"), line)
+	m.statusMsg = "Extracted section to " + path
+	return m.bufferActivity()
+}
+
+// undoSectionEdit takes back the last edit made from the outline, or makes
+// it again with redo
+func (m *Model) undoSectionEdit(redo bool) tea.Cmd {
+	line, ok := m.editor.UndoEdit(redo)
+	if !ok {
+		if redo {
+			m.statusMsg = "No outline edit to redo"
+		} else {
+			m.statusMsg = "No outline edit to undo"
+		}
+		return nil
+	}
+	m.contentReplaced(line)
+	return m.bufferActivity()
+}
+
+// replaceContent puts an edit of the whole note in the editor as unsaved
+// changes the outline can undo, with the cursor on line
+func (m *Model) replaceContent(content string, line int) {
+	m.editor.Edit(content, line)
+	m.contentReplaced(line)
+}
+
+// contentReplaced follows an edit of the whole note in the preview, the
+// folds and the outlines
+func (m *Model) contentReplaced(line int) {
+	content := m.editor.Content()
+	m.editor.SetModified(content != m.baseContent)
+	m.preview.SetContent(content, m.currentFile)
+	m.applyFolds(m.currentFile, &m.editor, &m.preview)
+	m.editor.JumpToLine(line)
+
+	if m.outline.Active() {
+		m.outline.SetContent(content, m.currentFile)
+		m.outline.SelectLine(line)
+	}
+	m.syncOutline()
+	m.outlineBar.SelectLine(line)
+}
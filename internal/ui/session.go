@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/liveeditor"
+	"github.com/takahashinaoki/obsidiantui/internal/components/preview"
+	"github.com/takahashinaoki/obsidiantui/internal/nav"
+	"github.com/takahashinaoki/obsidiantui/internal/session"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+var (
+	viewModeNames = map[ViewMode]string{ViewEdit: "edit", ViewPreview: "preview", ViewSplit: "split"}
+	paneNames     = map[Pane]string{PaneFileTree: "tree", PaneEditor: "editor", PanePreview: "preview", PaneOutline: "outline"}
+	splitNames    = map[SplitDir]string{SplitVertical: "vertical", SplitHorizontal: "horizontal"}
+)
+
+// sessionFile holds the UI state of a vault between runs
+func sessionFile(v *vault.Vault) string {
+	return v.DataDir("session.json")
+}
+
+// restoredFile is an open buffer read back from disk on launch
+type restoredFile struct {
+	content string
+	rev     vault.Revision
+}
+
+type sessionRestoredMsg struct {
+	session *session.Session // nil when there was nothing to restore
+	files   []*restoredFile  // by buffer index, nil for notes that are gone
+}
+
+// sessionDelay is how long tabs and the cursor must stay put before the
+// session is saved
+const sessionDelay = 2 * time.Second
+
+// sessionSaveMsg fires once the session has stopped changing; gen
+// identifies the change it was scheduled after so stale timers are ignored
+type sessionSaveMsg struct {
+	gen int
+}
+
+// restoreSession reads the saved session and the notes it had open. Without
+// a session it falls back to the last opened note.
+func (m *Model) restoreSession() tea.Cmd {
+	if !config.AppConfig.RestoreSession {
+		return nil
+	}
+
+	v := m.vault
+	last := config.AppConfig.LastOpenFile
+	hasLast := last != "" && v.HasFile(last)
+	return func() tea.Msg {
+		s, err := session.Load(sessionFile(v))
+		if errors.Is(err, os.ErrNotExist) {
+			if !hasLast {
+				return sessionRestoredMsg{}
+			}
+			s = &session.Session{
+				Buffers: []session.Buffer{{Path: last}},
+				Windows: []session.Window{{Buffer: 0, Mode: viewModeNames[ViewEdit]}},
+				History: []session.Location{{Path: last}},
+			}
+		} else if err != nil {
+			return errMsg{err: err}
+		}
+
+		files := make([]*restoredFile, len(s.Buffers))
+		for i, b := range s.Buffers {
+			content, rev, err := v.ReadFileRevision(b.Path)
+			if err == nil {
+				files[i] = &restoredFile{content: content, rev: rev}
+			}
+		}
+		return sessionRestoredMsg{session: s, files: files}
+	}
+}
+
+// applySession rebuilds buffers, windows and the tree from a saved session
+func (m *Model) applySession(msg sessionRestoredMsg) {
+	s := msg.session
+
+	m.filetree.SetExpanded(s.Expanded)
+	var entries []nav.Location
+	index := -1
+	for i, loc := range s.History {
+		if m.vault.HasFile(loc.Path) {
+			entries = append(entries, nav.Location(loc))
+		}
+		if i == s.HistoryIndex {
+			index = len(entries) - 1
+		}
+	}
+	m.nav.Restore(entries, index)
+	m.recent.Restore(s.Recent)
+
+	// Notes deleted since the last run are dropped, so buffer indices shift
+	bufIndex := make([]int, len(s.Buffers))
+	m.buffers = nil
+	for i, b := range s.Buffers {
+		f := msg.files[i]
+		if f == nil {
+			bufIndex[i] = -1
+			continue
+		}
+		ed := liveeditor.New()
+		ed.SetContent(f.content, b.Path)
+		ed.SetCursor(b.CursorRow, b.CursorCol, b.Scroll)
+		pv := preview.New()
+		pv.SetVault(m.vault)
+		pv.SetContent(f.content, b.Path)
+		m.applyFolds(b.Path, &ed, &pv)
+		pv.SetScrollOffset(b.PreviewScroll)
+
+		bufIndex[i] = len(m.buffers)
+		m.buffers = append(m.buffers, buffer{path: b.Path, editor: ed, preview: pv, baseContent: f.content, baseRev: f.rev})
+	}
+	if len(m.buffers) == 0 {
+		return
+	}
+
+	m.windows = nil
+	for _, w := range s.Windows {
+		buf := 0 // windows whose note is gone show the first tab instead
+		if w.Buffer >= 0 && w.Buffer < len(bufIndex) && bufIndex[w.Buffer] >= 0 {
+			buf = bufIndex[w.Buffer]
+		}
+		m.windows = append(m.windows, window{buf: buf, mode: lookupName(viewModeNames, w.Mode, ViewEdit)})
+	}
+	if len(m.windows) == 0 {
+		m.windows = []window{{buf: 0, mode: ViewEdit}}
+	}
+	m.activeWin = max(0, min(s.ActiveWindow, len(m.windows)-1))
+	m.splitDir = lookupName(splitNames, s.Split, SplitVertical)
+	m.viewMode = m.windows[m.activeWin].mode
+
+	m.activeBuf = -1
+	m.loadBuffer(m.windows[m.activeWin].buf)
+	pane := lookupName(paneNames, s.Pane, PaneFileTree)
+	if pane == PaneFileTree || m.activeBuf < 0 {
+		m.setActivePane(PaneFileTree)
+	} else {
+		m.focusContent()
+	}
+	m.updateLayout()
+
+	if m.activeBuf >= 0 {
+		m.offerRecovery(m.currentFile, m.baseContent)
+	}
+}
+
+// saveSession records the open buffers and layout for the next launch
+func (m *Model) saveSession() error {
+	if !config.AppConfig.RestoreSession {
+		return nil
+	}
+
+	m.stashBuffer()
+	m.windows[m.activeWin].mode = m.viewMode
+	if m.currentFile != "" {
+		m.syncNav()
+	}
+
+	s := &session.Session{
+		ActiveWindow: m.activeWin,
+		Split:        splitNames[m.splitDir],
+		Pane:         paneNames[m.activePane],
+		HistoryIndex: m.nav.Index(),
+		Recent:       m.recent.Paths(),
+		Expanded:     m.filetree.ExpandedPaths(),
+	}
+	for _, loc := range m.nav.Entries() {
+		s.History = append(s.History, session.Location(loc))
+	}
+	for _, b := range m.buffers {
+		s.Buffers = append(s.Buffers, session.Buffer{
+			Path:          b.path,
+			CursorRow:     b.editor.CursorRow(),
+			CursorCol:     b.editor.CursorCol(),
+			Scroll:        b.editor.ScrollOffset(),
+			PreviewScroll: b.preview.ScrollOffset(),
+		})
+	}
+	for _, w := range m.windows {
+		s.Windows = append(s.Windows, session.Window{Buffer: w.buf, Mode: viewModeNames[w.mode]})
+	}
+
+	return s.Save(sessionFile(m.vault))
+}
+
+// sessionKey summarises the state saveSession records, to tell when it
+// changed
+func (m Model) sessionKey() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %d %d %d %d %d|", m.activeWin, m.splitDir, m.activePane, m.viewMode, m.nav.Index(), len(m.nav.Entries()))
+	for i, buf := range m.buffers {
+		ed, path := buf.editor, buf.path
+		if i == m.activeBuf {
+			ed, path = m.editor, m.currentFile
+		}
+		fmt.Fprintf(&b, "%s:%d:%d:%d|", path, ed.CursorRow(), ed.CursorCol(), ed.ScrollOffset())
+	}
+	for _, w := range m.windows {
+		fmt.Fprintf(&b, "%d:%d|", w.buf, w.mode)
+	}
+	return b.String()
+}
+
+// scheduleSessionSave starts the session save timer when the session
+// changed, restarting it on every change so a burst of them saves once
+func (m *Model) scheduleSessionSave() tea.Cmd {
+	if !config.AppConfig.RestoreSession || !m.sessionRestored {
+		return nil
+	}
+	key := m.sessionKey()
+	if key == m.sessionState {
+		return nil
+	}
+	m.sessionState = key
+	m.sessionGen++
+	gen := m.sessionGen
+	return tea.Tick(sessionDelay, func(time.Time) tea.Msg {
+		return sessionSaveMsg{gen: gen}
+	})
+}
+
+// lookupName is the reverse of a names map, returning def for unknown names
+func lookupName[K comparable](names map[K]string, name string, def K) K {
+	for k, n := range names {
+		if n == name {
+			return k
+		}
+	}
+	return def
+}
@@ -47,6 +47,17 @@ var (
 			BorderForeground(lipgloss.Color("62")).
 			Padding(1, 2)
 
+	TabActiveStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("62")).
+			Padding(0, 1)
+
+	TabInactiveStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("245")).
+				Background(lipgloss.Color("236")).
+				Padding(0, 1)
+
 	ErrorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")).
 			Bold(true)
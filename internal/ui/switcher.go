@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/internal/components/switcher"
+	"github.com/takahashinaoki/obsidiantui/internal/nav"
+)
+
+func (m *Model) showSwitcher() tea.Cmd {
+	m.switcher.SetSize(m.width*2/3, m.height*3/4)
+	return m.switcher.Show(m.vault.Notes(), m.recent.Paths())
+}
+
+// openNoteAt opens a note picked in the switcher, at a heading if line >= 0
+func (m *Model) openNoteAt(path string, line int) tea.Cmd {
+	if line < 0 {
+		return m.openFile(path)
+	}
+	if path == m.currentFile {
+		m.jumpWithinNote(line)
+		m.focusContent()
+		return nil
+	}
+	m.pendingJump = &nav.Location{Path: path, Line: line}
+	return m.openFile(path)
+}
+
+// createNote creates and opens a note named in the switcher. Names may
+// include folders, e.g. "Projects/Idea".
+func (m *Model) createNote(name string) tea.Cmd {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimSpace(name)))
+	if !strings.EqualFold(filepath.Ext(rel), ".md") {
+		rel += ".md"
+	}
+	if !filepath.IsLocal(rel) {
+		m.statusMsg = fmt.Sprintf("Error: %q is outside the vault", name)
+		return nil
+	}
+
+	if !m.vault.HasFile(rel) {
+		if err := m.vault.CreateFile(rel); err != nil {
+			m.statusMsg = "Error: " + err.Error()
+			return nil
+		}
+		m.filetree.Refresh()
+	}
+	return m.openFile(rel)
+}
+
+func (m *Model) handleSwitcherMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case switcher.NoteSelectedMsg:
+		return m.openNoteAt(msg.Path, msg.Line)
+	case switcher.CreateNoteMsg:
+		return m.createNote(msg.Name)
+	}
+	return nil
+}
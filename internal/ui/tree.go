@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/filetree"
+	"github.com/takahashinaoki/obsidiantui/internal/components/prompt"
+)
+
+const (
+	sortPromptContext    = "sort"
+	columnsPromptContext = "columns"
+)
+
+// applyTreeConfig orders the file tree and picks its columns as the config says
+func (m *Model) applyTreeConfig() {
+	by, _ := filetree.ParseSortBy(config.AppConfig.FileSort)
+	m.filetree.SetSort(by, config.AppConfig.FileSortDescending)
+
+	var columns []filetree.Column
+	for _, name := range config.AppConfig.FileColumns {
+		if c, ok := filetree.ParseColumn(name); ok {
+			columns = append(columns, c)
+		}
+	}
+	m.filetree.SetColumns(columns)
+	m.filetree.SetFolderNotes(config.AppConfig.FolderNotes)
+}
+
+// treeSorted remembers a sort mode picked in the tree
+func (m *Model) treeSorted(by filetree.SortBy, descending bool) {
+	config.AppConfig.FileSort = by.String()
+	config.AppConfig.FileSortDescending = descending
+	config.Save()
+
+	order := "ascending"
+	if descending {
+		order = "descending"
+	}
+	m.statusMsg = fmt.Sprintf("File tree sorted by %s (%s)", by, order)
+}
+
+func (m *Model) showSortPrompt() {
+	by, descending := m.filetree.Sort()
+	order := "ascending"
+	if descending {
+		order = "descending"
+	}
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show("Sort File Tree", fmt.Sprintf("Sorted by %s (%s). Folders always come first.", by, order), sortPromptContext, []prompt.Choice{
+		{Key: "n", Label: "Name", ID: filetree.SortName.String()},
+		{Key: "m", Label: "Modified time", ID: filetree.SortModified.String()},
+		{Key: "c", Label: "Created time", ID: filetree.SortCreated.String()},
+		{Key: "s", Label: "Size", ID: filetree.SortSize.String()},
+		{Key: "l", Label: "Number of backlinks", ID: filetree.SortLinks.String()},
+		{Key: "r", Label: "Reverse the order", ID: "reverse"},
+	})
+}
+
+func (m *Model) handleSortChoice(choice string) {
+	by, descending := m.filetree.Sort()
+	if choice == "reverse" {
+		descending = !descending
+	} else if b, ok := filetree.ParseSortBy(choice); ok {
+		by = b
+	}
+	m.filetree.SetSort(by, descending)
+	m.treeSorted(by, descending)
+}
+
+func (m *Model) showColumnsPrompt() {
+	shown := make(map[filetree.Column]bool)
+	for _, c := range m.filetree.Columns() {
+		shown[c] = true
+	}
+	label := func(c filetree.Column, name string) string {
+		if shown[c] {
+			return name + " (shown)"
+		}
+		return name
+	}
+
+	m.prompt.SetSize(m.width/2, m.height/2)
+	m.prompt.Show("File Tree Columns", "Show or hide a column next to the names in the file tree.", columnsPromptContext, []prompt.Choice{
+		{Key: "m", Label: label(filetree.ColumnModified, "Modified date"), ID: filetree.ColumnModified.String()},
+		{Key: "w", Label: label(filetree.ColumnWords, "Word count"), ID: filetree.ColumnWords.String()},
+		{Key: "l", Label: label(filetree.ColumnLinks, "Backlink count"), ID: filetree.ColumnLinks.String()},
+		{Key: "t", Label: label(filetree.ColumnTasks, "Task progress"), ID: filetree.ColumnTasks.String()},
+		{Key: "n", Label: label(filetree.ColumnNotes, "Notes in folders"), ID: filetree.ColumnNotes.String()},
+	})
+}
+
+func (m *Model) handleColumnsChoice(choice string) {
+	c, ok := filetree.ParseColumn(choice)
+	if !ok {
+		return
+	}
+	m.filetree.ToggleColumn(c)
+
+	names := make([]string, 0, len(m.filetree.Columns()))
+	for _, col := range m.filetree.Columns() {
+		names = append(names, col.String())
+	}
+	config.AppConfig.FileColumns = names
+	config.Save()
+
+	if len(names) == 0 {
+		m.statusMsg = "File tree columns hidden"
+	} else {
+		m.statusMsg = "File tree columns: " + strings.Join(names, ", ")
+	}
+}
+
+// renderPeek draws the file tree's peek beside the cursor
+func (m Model) renderPeek(base string) string {
+	if m.activePane != PaneFileTree || !m.filetree.Peeking() {
+		return base
+	}
+	box := m.filetree.PeekView(min(60, m.cachedContentW))
+	if box == "" {
+		return base
+	}
+
+	// One line down for the tree's border, and up as far as needed to fit
+	y := 1 + m.filetree.CursorRow()
+	y = max(0, min(y, strings.Count(base, "\n")+1-lipgloss.Height(box)))
+	return overlayAt(base, box, m.cachedTreeW+2, y)
+}
+
+func (m *Model) showFolderIndex(dir string) {
+	m.folderIndex.SetSize(m.width*2/3, m.height*2/3)
+	m.folderIndex.Show(dir)
+}
+
+// currentFolder is the folder under the tree cursor when the tree has focus,
+// or else the folder of the current note
+func (m Model) currentFolder() string {
+	path := m.currentFile
+	if m.activePane == PaneFileTree {
+		if node := m.filetree.CursorNode(); node != nil {
+			if node.IsDir {
+				return node.Path
+			}
+			path = node.Path
+		}
+	}
+	if dir := filepath.Dir(path); path != "" && dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// revealFile shows the current note in the file tree, opening the folders
+// above it and clearing a filter that hides it
+func (m *Model) revealFile() tea.Cmd {
+	if m.currentFile == "" {
+		m.statusMsg = "No note to reveal"
+		return nil
+	}
+	if !m.filetree.Reveal(m.currentFile) {
+		m.statusMsg = "Not in the file tree: " + m.currentFile
+		return nil
+	}
+	return m.focusTree()
+}
+
+// focusTree moves focus to the file tree for a command acting on it
+func (m *Model) focusTree() tea.Cmd {
+	prev := m.activePane
+	m.setActivePane(PaneFileTree)
+	return m.paneChanged(prev)
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/takahashinaoki/obsidiantui/config"
+	"github.com/takahashinaoki/obsidiantui/internal/components/vaultpicker"
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// vaultOpenedMsg carries a scanned vault to switch to
+type vaultOpenedMsg struct {
+	vault *vault.Vault
+}
+
+// PickVaultOnStart shows the vault picker as soon as the UI is laid out
+func (m *Model) PickVaultOnStart() {
+	m.pickVault = true
+}
+
+func (m *Model) showVaultPicker() tea.Cmd {
+	var vaults []vaultpicker.Vault
+	for _, vc := range config.AppConfig.Vaults {
+		vaults = append(vaults, vaultpicker.Vault{Name: vc.Name, Path: vc.Path})
+	}
+	m.vaultpicker.SetSize(m.width/2, m.height*3/4)
+	return m.vaultpicker.Show(vaults, m.vault.Path)
+}
+
+// switchVault scans the vault at path so the UI can move over to it. Notes
+// with unsaved edits have to be saved or closed first.
+func (m *Model) switchVault(path string) tea.Cmd {
+	if abs, err := filepath.Abs(path); err == nil && abs == m.vault.Path {
+		return nil
+	}
+	if m.anyModified() {
+		m.statusMsg = "Save or close modified notes before switching vaults"
+		return nil
+	}
+
+	m.statusMsg = "Opening vault " + path + "..."
+	return func() tea.Msg {
+		v, err := vault.NewVault(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return vaultOpenedMsg{vault: v}
+	}
+}
+
+// useVault replaces the model with a fresh one for v, keeping the session of
+// the vault being left and restoring the one of v
+func (m *Model) useVault(v *vault.Vault) (tea.Model, tea.Cmd) {
+	sessionErr := m.saveSession()
+	if err := config.UseVault(v.Path); err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return *m, nil
+	}
+	config.Save()
+
+	nm := NewModel(v)
+	nm.inheritTimers(m)
+	nm.statusMsg = "Switched to vault " + filepath.Base(v.Path)
+	if sessionErr != nil {
+		nm.statusMsg = "Error: saving session: " + sessionErr.Error()
+	}
+	width, height := m.width, m.height
+	return nm, tea.Batch(nm.Init(), func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	})
+}
+
+// inheritTimers moves the timer generations on past those of old, the
+// model being replaced, so the ticks it still has scheduled are dropped
+// instead of running against the new vault
+func (m *Model) inheritTimers(old *Model) {
+	m.editGen = old.editGen + 1
+	m.snapshotGen = old.snapshotGen + 1
+	m.autoCommitGen = old.autoCommitGen + 1
+	m.sessionGen = old.sessionGen + 1
+}
@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AtomicWriteFile writes data to a temporary file next to path and renames
+// it into place, so a crash mid-write never leaves a truncated file. A
+// symlink is followed and its target replaced, so the link survives, and an
+// existing file keeps its permissions.
+func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	path = resolveLink(path)
+	perm = keepMode(path, perm)
+
+	tmpPath, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes data to a synced temporary file next to path, ready to be
+// renamed over it
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	// The dot prefix keeps a leftover temp file out of ScanFiles
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	cleanup := func(err error) (string, error) {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// resolveLink returns the file a symlink points to, even through a chain of
+// links or when the target does not exist yet. Other paths come back as they
+// are.
+func resolveLink(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	// A dangling link: follow it by hand, giving up on loops
+	for range 40 {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return path
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return path
+}
+
+// keepMode returns the permissions of the file at path, or perm for a new one
+func keepMode(path string, perm os.FileMode) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	}
+	return perm
+}
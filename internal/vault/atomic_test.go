@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteFileKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := AtomicWriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode = %v, want 0600", perm)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+}
+
+func TestAtomicWriteFileFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real", "note.md")
+	if err := os.Mkdir(filepath.Dir(real), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(real, []byte("old"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string // what the link points to
+		want   string // the file that must get the content
+	}{
+		{"relative", filepath.Join("real", "note.md"), real},
+		{"absolute", real, real},
+		{"chain", "link-relative.md", real},
+		{"dangling", filepath.Join("real", "new.md"), filepath.Join(dir, "real", "new.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := filepath.Join(dir, "link-"+tt.name+".md")
+			if err := os.Symlink(tt.target, link); err != nil {
+				t.Skip("symlinks are not supported:", err)
+			}
+			if err := AtomicWriteFile(link, []byte(tt.name), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Lstat(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Error("the symlink was replaced by a regular file")
+			}
+			if data, _ := os.ReadFile(tt.want); string(data) != tt.name {
+				t.Errorf("%s = %q, want %q", tt.want, data, tt.name)
+			}
+			if tt.want == real {
+				if info, _ := os.Stat(real); info.Mode().Perm() != 0640 {
+					t.Errorf("target mode = %v, want 0640", info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
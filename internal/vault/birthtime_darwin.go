@@ -0,0 +1,17 @@
+package vault
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// CreatedTime returns when the file at path was created, or its
+// modification time where the file system doesn't record that
+func CreatedTime(path string, info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(st.Birthtimespec.Unix())
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package vault
+
+import (
+	"os"
+	"time"
+)
+
+// CreatedTime returns the modification time, as creation times can't be
+// read on this platform
+func CreatedTime(path string, info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// BlockRef is a link from a note to a block of another, like [[note#^id]]
+type BlockRef struct {
+	Source string // the linking note
+	Line   int    // the link's line in it
+	ID     string
+	Block  int    // the block's first line, or -1 when no block has the id
+	Text   string // the block's first line
+}
+
+// BlockRefs returns the links to blocks of the note at relPath, ordered by
+// the linking note and line
+func (v *Vault) BlockRefs(relPath string) []BlockRef {
+	v.mu.RLock()
+	var blocks []parser.BlockID
+	if f, ok := v.Files[relPath]; ok {
+		blocks = f.Blocks
+	}
+	v.mu.RUnlock()
+
+	var refs []BlockRef
+	for _, source := range slices.Compact(v.GetBacklinks(relPath)) {
+		content, err := v.ReadFile(source)
+		if err != nil {
+			continue
+		}
+		for _, link := range parser.ExtractWikiLinks(content) {
+			note, subpath := parser.SplitSubpath(link.Target)
+			id, ok := strings.CutPrefix(subpath, "#^")
+			if !ok || v.resolveNote(note) != relPath {
+				continue
+			}
+			ref := BlockRef{Source: source, Line: strings.Count(content[:link.StartPos], "\n"), ID: id, Block: -1}
+			for _, b := range blocks {
+				if strings.EqualFold(b.ID, id) {
+					ref.Block, ref.Text = b.Line, b.Text
+					break
+				}
+			}
+			refs = append(refs, ref)
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].Source != refs[j].Source {
+			return refs[i].Source < refs[j].Source
+		}
+		return refs[i].Line < refs[j].Line
+	})
+	return refs
+}
+
+// resolveNote finds the note a link names
+func (v *Vault) resolveNote(name string) string {
+	if path := v.FindFile(name + ".md"); path != "" {
+		return path
+	}
+	return v.FindFile(name)
+}
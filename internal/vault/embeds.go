@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// EmbedMark is in the header of every embed ExpandNoteEmbeds inlines, and
+// nowhere else in what it returns, so the headers can be found once rendered
+const EmbedMark = "\uE000"
+
+// Embed is a note, or a section or block of one, inlined by ExpandNoteEmbeds
+type Embed struct {
+	Link string // the embed's target, e.g. note#Heading
+	Path string // the embedded note
+	Line int    // where the embedded part starts in it
+}
+
+// ExpandEmbeds replaces ![[note]] in the content of the note at path with the
+// embedded note content, following nested embeds up to maxDepth levels.
+// ![[note#Heading]] inlines only the heading's section and ![[note#^id]] only
+// the block marked ^id; ![[#Heading]] refers to the note at path.
+func (v *Vault) ExpandEmbeds(path, content string, maxDepth int) string {
+	return v.ExpandEmbedsFiltered(path, content, maxDepth, nil)
+}
+
+// ExpandEmbedsFiltered is ExpandEmbeds but only inlines notes for which allow
+// returns true; other embeds are replaced by their display name
+func (v *Vault) ExpandEmbedsFiltered(path, content string, maxDepth int, allow func(relPath string) bool) string {
+	e := &embedExpander{v: v, maxDepth: maxDepth, allow: allow, seen: map[string]bool{path: true}}
+	return e.expand(content, path, 0)
+}
+
+// ExpandNoteEmbeds is ExpandEmbeds for the note at path, which embeds like
+// ![[#Heading]] refer to, as the preview shows it: headers carry EmbedMark
+// and the embed's size, and embeds nested too deep become warnings. It also
+// returns what was inlined, in the order the embeds' headers appear.
+func (v *Vault) ExpandNoteEmbeds(path, content string, maxDepth int) (string, []Embed) {
+	e := &embedExpander{v: v, maxDepth: maxDepth, hints: true, seen: map[string]bool{path: true}}
+	return e.expand(content, path, 0), e.embeds
+}
+
+type embedExpander struct {
+	v        *Vault
+	maxDepth int
+	allow    func(string) bool
+	hints    bool            // marks, sizes and warnings only the preview shows
+	seen     map[string]bool // what is being expanded, to break cycles
+	embeds   []Embed
+}
+
+// expand inlines the embeds of content, which is from the note at source
+func (e *embedExpander) expand(content, source string, depth int) string {
+	if e.hints {
+		content = strings.ReplaceAll(content, EmbedMark, "")
+	} else if depth >= e.maxDepth {
+		// Embeds nested too deep are left as written
+		return content
+	}
+	embeds := parser.ExtractEmbedLinks(content)
+	if len(embeds) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, embed := range embeds {
+		b.WriteString(content[prev:embed.StartPos])
+		prev = embed.EndPos
+		b.WriteString(e.replace(embed, source, depth))
+	}
+	b.WriteString(content[prev:])
+	return b.String()
+}
+
+func (e *embedExpander) replace(embed parser.EmbedLink, source string, depth int) string {
+	target := embed.Target
+	note, subpath := parser.SplitSubpath(target)
+
+	// Try to resolve the embed target
+	resolvedPath := source
+	if note != "" {
+		resolvedPath = e.v.resolveNote(note)
+	}
+
+	// A number instead of a display name limits the lines shown
+	displayName := strings.ReplaceAll(strings.TrimPrefix(target, "#"), "#", " › ")
+	maxLines, err := strconv.Atoi(embed.AltText)
+	if err != nil || maxLines <= 0 {
+		maxLines = 0
+		if embed.AltText != "" {
+			displayName = embed.AltText
+		}
+	}
+
+	if resolvedPath != "" && e.allow != nil && !e.allow(resolvedPath) {
+		return displayName
+	}
+	if resolvedPath == "" {
+		// Broken embed
+		return fmt.Sprintf("\n> **⚠ Embed not found: %s**\n", target)
+	}
+	key := resolvedPath + strings.ToLower(subpath)
+	if e.seen[key] {
+		// Circular reference
+		return fmt.Sprintf("\n> **⚠ Circular embed: %s**\n", target)
+	}
+	if depth >= e.maxDepth {
+		return fmt.Sprintf("\n> **⚠ Embeds nested deeper than %d levels: %s**\n", e.maxDepth, target)
+	}
+
+	// Read the embedded file
+	fileContent, err := e.v.ReadFile(resolvedPath)
+	if err != nil || fileContent == "" {
+		return fmt.Sprintf("\n> **⚠ Cannot read: %s**\n", target)
+	}
+	lines, start, ok := embeddedLines(fileContent, subpath)
+	if !ok {
+		return fmt.Sprintf("\n> **⚠ Embed not found: %s**\n", target)
+	}
+
+	size := len(lines)
+	more := 0
+	if maxLines > 0 && len(lines) > maxLines {
+		more = len(lines) - maxLines
+		lines = lines[:maxLines]
+	}
+
+	e.embeds = append(e.embeds, Embed{Link: target, Path: resolvedPath, Line: start})
+
+	// Mark as seen while expanding to prevent cycles
+	e.seen[key] = true
+	embeddedContent := e.expand(strings.Join(lines, "\n"), resolvedPath, depth+1)
+	delete(e.seen, key)
+
+	// Create a blockquote-style embed
+	header := fmt.Sprintf("**📎 %s**", displayName)
+	if e.hints {
+		header = fmt.Sprintf("**📎%s %s** *(%s)*", EmbedMark, displayName, lineCount(size))
+	}
+	// Blank lines around the rules keep the text before the embed from
+	// becoming a setext heading
+	quotedLines := []string{"\n\n---\n" + header + "\n"}
+	for _, line := range strings.Split(embeddedContent, "\n") {
+		quotedLines = append(quotedLines, "> "+line)
+	}
+	if more > 0 && e.hints {
+		quotedLines = append(quotedLines, ">", "> *… "+lineCount(more)+" more*")
+	}
+	quotedLines = append(quotedLines, "\n---\n\n")
+	return strings.Join(quotedLines, "\n")
+}
+
+// embeddedLines returns the lines of a note a subpath stands for, and the
+// first one's line in the note. Trailing blank lines and block markers are
+// left out.
+func embeddedLines(content, subpath string) ([]string, int, bool) {
+	lines := strings.Split(content, "\n")
+	if subpath == "" {
+		return lines, 0, true
+	}
+
+	start, end, ok := parser.SubpathRange(lines, subpath)
+	if !ok {
+		return nil, 0, false
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	part := make([]string, 0, end-start)
+	for _, line := range lines[start:end] {
+		part = append(part, parser.StripBlockID(line))
+	}
+	return part, start, true
+}
+
+func lineCount(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", n)
+}
@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandEmbeds(t *testing.T) {
+	dir := t.TempDir()
+	notes := map[string]string{
+		"a.md":    "A says 📎 hi\n![[b]]",
+		"b.md":    "B\n![[c]]",
+		"c.md":    "C",
+		"loop.md": "![[loop]]",
+		"x.md":    "![[#Sec]]\n\n# Sec\nsection text ^blk",
+	}
+	for name, content := range notes {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err := NewVault(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		depth    int
+		hints    bool
+		want     []string
+		dontWant []string
+	}{
+		{
+			name: "export has no hints", content: "![[a]]", depth: 3,
+			want:     []string{"**📎 a**", "> A says 📎 hi", "> > C"},
+			dontWant: []string{"lines)", EmbedMark},
+		},
+		{
+			name: "export leaves deep embeds as written", content: "![[a]]", depth: 2,
+			want:     []string{"> > ![[c]]"},
+			dontWant: []string{"nested deeper"},
+		},
+		{
+			name: "preview has hints", content: "![[a]]", depth: 3, hints: true,
+			want: []string{"**📎" + EmbedMark + " a** *(2 lines)*"},
+		},
+		{
+			name: "preview warns about deep embeds", content: "![[a]]", depth: 2, hints: true,
+			want: []string{"Embeds nested deeper than 2 levels: c"},
+		},
+		{
+			name: "marks in notes are dropped", content: EmbedMark + "![[c]]", depth: 3, hints: true,
+			want: []string{"\n\n---\n**📎" + EmbedMark + " c**"},
+		},
+		{
+			name: "export embeds from the same note", content: "![[#Sec]] ![[#^blk]]", depth: 3,
+			want:     []string{"**📎 Sec**", "> # Sec", "**📎 ^blk**", "> section text"},
+			dontWant: []string{"not found", "Circular"},
+		},
+		{
+			name: "preview embeds from the same note", content: "![[#Sec]]", depth: 3, hints: true,
+			want: []string{"> section text"},
+		},
+		{
+			name: "the note itself is a cycle", content: "![[x]]", depth: 3,
+			want: []string{"Circular embed: x"},
+		},
+		{
+			name: "cycles", content: "![[loop]]", depth: 3,
+			want: []string{"Circular embed: loop"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if tt.hints {
+				got, _ = v.ExpandNoteEmbeds("x.md", tt.content, tt.depth)
+				if n := strings.Count(got, EmbedMark); n != strings.Count(got, "📎"+EmbedMark) {
+					t.Errorf("%d marks outside embed headers in %q", n, got)
+				}
+			} else {
+				got = v.ExpandEmbeds("x.md", tt.content, tt.depth)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("expansion lacks %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.dontWant {
+				if strings.Contains(got, s) {
+					t.Errorf("expansion has %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package vault
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TrashDir is the vault folder Obsidian moves deleted files into when its
+// "Deleted files" option is set to the Obsidian trash
+const TrashDir = ".trash"
+
+// The operations below change the disk only. Call Scan afterwards so Files
+// and the index match it.
+
+// CreateFolder creates a folder and any missing parents
+func (v *Vault) CreateFolder(relPath string) error {
+	fullPath := filepath.Join(v.Path, relPath)
+	if _, err := os.Lstat(fullPath); err == nil {
+		return fmt.Errorf("%s already exists", relPath)
+	}
+	return os.MkdirAll(fullPath, 0755)
+}
+
+// Move renames a note or folder. It fails rather than replace something
+// already at newPath, and won't move a folder into itself.
+func (v *Vault) Move(oldPath, newPath string) error {
+	if oldPath == newPath {
+		return nil
+	}
+	if within(newPath, oldPath) {
+		return fmt.Errorf("can't move %s into itself", oldPath)
+	}
+	src := filepath.Join(v.Path, oldPath)
+	dst := filepath.Join(v.Path, newPath)
+	if dstInfo, err := os.Lstat(dst); err == nil {
+		// On case-insensitive file systems a case-only rename finds src
+		// itself at dst
+		srcInfo, err := os.Lstat(src)
+		if err != nil || !os.SameFile(srcInfo, dstInfo) {
+			return fmt.Errorf("%s already exists", newPath)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// Copy copies a note, or a folder with everything in it, to newPath
+func (v *Vault) Copy(oldPath, newPath string) error {
+	if within(newPath, oldPath) {
+		return fmt.Errorf("can't copy %s into itself", oldPath)
+	}
+	dst := filepath.Join(v.Path, newPath)
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+	return copyPath(filepath.Join(v.Path, oldPath), dst)
+}
+
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := copyPath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Trash moves a note or folder into the vault's .trash folder, where
+// Obsidian keeps deleted files, and returns its path there. A name already
+// in the trash gets a number, as Obsidian does.
+func (v *Vault) Trash(relPath string) (string, error) {
+	if relPath == "" || relPath == "." {
+		return "", fmt.Errorf("can't delete the vault folder")
+	}
+	trashed := v.AvailablePath(filepath.Join(TrashDir, filepath.Base(relPath)))
+	if err := v.Move(relPath, trashed); err != nil {
+		return "", err
+	}
+	return trashed, nil
+}
+
+// AvailablePath returns relPath if nothing is there, or else the first free
+// "name 1.md", "name 2.md", ... next to it
+func (v *Vault) AvailablePath(relPath string) string {
+	if _, err := os.Lstat(filepath.Join(v.Path, relPath)); err != nil {
+		return relPath
+	}
+	ext := filepath.Ext(relPath)
+	if info, err := os.Stat(filepath.Join(v.Path, relPath)); err == nil && info.IsDir() {
+		ext = ""
+	}
+	base := strings.TrimSuffix(relPath, ext)
+	for i := 1; ; i++ {
+		candidate := base + " " + strconv.Itoa(i) + ext
+		if _, err := os.Lstat(filepath.Join(v.Path, candidate)); err != nil {
+			return candidate
+		}
+	}
+}
+
+// RenamedPath returns where path is after oldPath moved to newPath, which
+// also moves everything inside oldPath if it is a folder
+func RenamedPath(path, oldPath, newPath string) string {
+	if path == oldPath {
+		return newPath
+	}
+	if rest, ok := strings.CutPrefix(path, oldPath+string(filepath.Separator)); ok {
+		return filepath.Join(newPath, rest)
+	}
+	return path
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFileOpsVault makes a vault holding files, and folders for names
+// ending in /
+func newFileOpsVault(t *testing.T, files ...string) *Vault {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err := NewVault(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func exists(t *testing.T, v *Vault, relPath string) bool {
+	t.Helper()
+	_, err := os.Lstat(filepath.Join(v.Path, filepath.FromSlash(relPath)))
+	return err == nil
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		fails    bool
+	}{
+		{"rename a note", "a.md", "b.md", false},
+		{"into a new folder", "a.md", "new/a.md", false},
+		{"a folder", "docs", "archive/docs", false},
+		{"onto itself", "a.md", "a.md", false},
+		{"onto an existing note", "a.md", "docs/guide.md", true},
+		{"onto an existing folder", "docs", "other", true},
+		{"a folder into itself", "docs", "docs/sub", true},
+		{"a folder onto itself", "docs", "docs", false},
+		{"a missing note", "missing.md", "b.md", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newFileOpsVault(t, "a.md", "docs/guide.md", "other/")
+			err := v.Move(filepath.FromSlash(tt.from), filepath.FromSlash(tt.to))
+			if (err != nil) != tt.fails {
+				t.Fatalf("Move(%q, %q) = %v, want failure %v", tt.from, tt.to, err, tt.fails)
+			}
+			if tt.fails {
+				if tt.from != "missing.md" && !exists(t, v, tt.from) {
+					t.Errorf("a failed move lost %s", tt.from)
+				}
+				return
+			}
+			if !exists(t, v, tt.to) {
+				t.Errorf("%s is missing after the move", tt.to)
+			}
+			if tt.from != tt.to && exists(t, v, tt.from) {
+				t.Errorf("%s is still there after the move", tt.from)
+			}
+		})
+	}
+
+	// Nothing was replaced
+	v := newFileOpsVault(t, "a.md", "docs/guide.md")
+	v.Move("a.md", filepath.Join("docs", "guide.md"))
+	if data, _ := os.ReadFile(filepath.Join(v.Path, "docs", "guide.md")); string(data) != "docs/guide.md" {
+		t.Errorf("moving onto a note replaced it with %q", data)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	v := newFileOpsVault(t, "a.md", "docs/guide.md", "docs/deep/more.md")
+
+	if err := v.Copy("docs", "copy"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"copy/guide.md", "copy/deep/more.md", "docs/guide.md"} {
+		if !exists(t, v, p) {
+			t.Errorf("%s is missing after copying docs", p)
+		}
+	}
+	if err := v.Copy("docs", filepath.Join("docs", "deep", "docs")); err == nil {
+		t.Error("Copy() copied a folder into itself")
+	}
+	if err := v.Copy("a.md", filepath.Join("docs", "guide.md")); err == nil {
+		t.Error("Copy() replaced an existing note")
+	}
+}
+
+func TestTrash(t *testing.T) {
+	v := newFileOpsVault(t, "a.md", "sub/a.md", "docs/guide.md", ".trash/docs/old.md")
+
+	// Names already in the trash get a number
+	tests := []struct{ path, want string }{
+		{"a.md", ".trash/a.md"},
+		{"sub/a.md", ".trash/a 1.md"},
+		{"docs", ".trash/docs 1"},
+	}
+	for _, tt := range tests {
+		got, err := v.Trash(filepath.FromSlash(tt.path))
+		if err != nil {
+			t.Fatalf("Trash(%q): %v", tt.path, err)
+		}
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("Trash(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if exists(t, v, tt.path) || !exists(t, v, tt.want) {
+			t.Errorf("Trash(%q) did not move it to %s", tt.path, tt.want)
+		}
+	}
+	if !exists(t, v, ".trash/docs/old.md") {
+		t.Error("trashing a folder replaced the one already in the trash")
+	}
+
+	for _, root := range []string{"", "."} {
+		if _, err := v.Trash(root); err == nil {
+			t.Errorf("Trash(%q) trashed the vault", root)
+		}
+	}
+}
+
+func TestAvailablePath(t *testing.T) {
+	v := newFileOpsVault(t, "a.md", "a 1.md", "b.md", "v1.2/", "v1.2 1/")
+
+	tests := []struct{ path, want string }{
+		{"new.md", "new.md"},
+		{"a.md", "a 2.md"},
+		{"b.md", "b 1.md"},
+		{"v1.2", "v1.2 2"},
+		{"sub/b.md", "sub/b.md"},
+	}
+	for _, tt := range tests {
+		if got := v.AvailablePath(filepath.FromSlash(tt.path)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("AvailablePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/takahashinaoki/obsidiantui/internal/parser"
+)
+
+// A folder note is the note inside a folder named after it, such as
+// Projects/Projects.md, which stands for the folder itself.
+
+// FolderNotePath returns where the folder note of dir would be
+func FolderNotePath(dir string) string {
+	return filepath.Join(dir, filepath.Base(dir)+".md")
+}
+
+// FolderNote returns the folder note of dir, or "" if it has none
+func (v *Vault) FolderNote(dir string) string {
+	if dir == "" || dir == "." {
+		return ""
+	}
+	path := FolderNotePath(dir)
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if f, ok := v.Files[path]; ok && !f.IsDir {
+		return path
+	}
+	return ""
+}
+
+// IsFolderNote reports whether path is the folder note of the folder it is in
+func IsFolderNote(path string) bool {
+	dir := filepath.Dir(path)
+	return dir != "." && path == FolderNotePath(dir)
+}
+
+// FolderEntry is a note or subfolder listed in a folder's index
+type FolderEntry struct {
+	Path        string
+	Title       string
+	Description string // from the description or summary property
+	Tags        []string
+	IsDir       bool
+	Notes       int // notes in a subfolder, including deeper ones
+}
+
+// FolderIndex lists what is directly inside dir, subfolders first, for
+// folders without a folder note. A subfolder's own folder note is left out
+// of its count, as it stands for the subfolder.
+func (v *Vault) FolderIndex(dir string) []FolderEntry {
+	if dir == "." {
+		dir = ""
+	}
+
+	v.mu.RLock()
+	var entries []FolderEntry
+	subfolders := make(map[string]int)
+	for relPath, f := range v.Files {
+		parent := filepath.Dir(relPath)
+		if parent == "." {
+			parent = ""
+		}
+		switch {
+		case relPath == "." || relPath == dir:
+		case parent == dir && f.IsDir:
+			if _, ok := subfolders[relPath]; !ok {
+				subfolders[relPath] = 0
+			}
+		case parent == dir:
+			entries = append(entries, FolderEntry{Path: relPath, Tags: append([]string(nil), f.Tags...)})
+		case !f.IsDir && (dir == "" || strings.HasPrefix(relPath, dir+string(filepath.Separator))):
+			// A note deeper down counts toward the subfolder holding it
+			rest := relPath
+			if dir != "" {
+				rest = relPath[len(dir)+1:]
+			}
+			sub := filepath.Join(dir, strings.SplitN(rest, string(filepath.Separator), 2)[0])
+			if relPath != FolderNotePath(sub) {
+				subfolders[sub]++
+			}
+		}
+	}
+	v.mu.RUnlock()
+
+	for i := range entries {
+		e := &entries[i]
+		e.Title = strings.TrimSuffix(filepath.Base(e.Path), filepath.Ext(e.Path))
+		content, err := v.ReadFile(e.Path)
+		if err != nil {
+			continue
+		}
+		fm, body := parser.ExtractFrontmatter(content)
+		if title := strings.Trim(fm["title"], `"'`); title != "" {
+			e.Title = title
+		} else if title := parser.ExtractTitle(body); title != "" {
+			e.Title = title
+		}
+		for _, tag := range parser.ExtractListProperty(content, "tags", "tag") {
+			if tag = strings.TrimPrefix(tag, "#"); !slices.Contains(e.Tags, tag) {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+		e.Description = strings.Trim(fm["description"], `"'`)
+		if e.Description == "" {
+			e.Description = strings.Trim(fm["summary"], `"'`)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Title) < strings.ToLower(entries[j].Title)
+	})
+
+	folders := make([]FolderEntry, 0, len(subfolders))
+	for sub, notes := range subfolders {
+		folders = append(folders, FolderEntry{Path: sub, Title: filepath.Base(sub), IsDir: true, Notes: notes})
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return strings.ToLower(folders[i].Title) < strings.ToLower(folders[j].Title)
+	})
+	return append(folders, entries...)
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// Revision identifies a file's content as it was read from or written to
+// disk, so later saves can tell whether someone else changed it meanwhile
+type Revision struct {
+	ModTime time.Time
+	Hash    string
+}
+
+// ConflictError is returned by SaveFile when the file on disk no longer
+// matches the revision the buffer was based on
+type ConflictError struct {
+	Path     string
+	Theirs   string   // current content on disk
+	Revision Revision // revision of Theirs
+}
+
+func (e *ConflictError) Error() string {
+	return e.Path + " was changed on disk"
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadFileRevision reads a file and returns the revision of what was read
+func (v *Vault) ReadFileRevision(relPath string) (string, Revision, error) {
+	content, err := v.ReadFile(relPath)
+	if err != nil {
+		return "", Revision{}, err
+	}
+	return content, v.revision(relPath), nil
+}
+
+func (v *Vault) revision(relPath string) Revision {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	file, ok := v.Files[relPath]
+	if !ok {
+		return Revision{}
+	}
+	return Revision{ModTime: file.ModTime, Hash: file.Hash}
+}
+
+// SaveFile writes content unless the file was changed on disk since base
+// was read, in which case it returns a *ConflictError and leaves the file
+// alone. A zero base skips the check.
+func (v *Vault) SaveFile(relPath, content string, base Revision) (Revision, error) {
+	v.mu.RLock()
+	file, ok := v.Files[relPath]
+	v.mu.RUnlock()
+
+	if !ok {
+		return Revision{}, os.ErrNotExist
+	}
+
+	path := resolveLink(file.Path)
+	if err := checkRevision(relPath, path, base); err != nil {
+		return Revision{}, err
+	}
+	tmpPath, err := writeTemp(path, []byte(content), keepMode(path, 0644))
+	if err != nil {
+		return Revision{}, err
+	}
+
+	// Writing the temp file takes a while, so check again just before it
+	// replaces the note. Holding the lock keeps other saves out in between.
+	v.mu.Lock()
+	if err := checkRevision(relPath, path, base); err != nil {
+		v.mu.Unlock()
+		os.Remove(tmpPath)
+		return Revision{}, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		v.mu.Unlock()
+		os.Remove(tmpPath)
+		return Revision{}, err
+	}
+	v.setContent(file, content, path)
+	rev := Revision{ModTime: file.ModTime, Hash: file.Hash}
+	v.mu.Unlock()
+
+	return rev, nil
+}
+
+// checkRevision returns a *ConflictError when the file at path no longer
+// matches base. A zero base always passes.
+func checkRevision(relPath, path string, base Revision) error {
+	if base.Hash == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	// A touched file with the same content is not a conflict, so only hash
+	// it when the modification time moved
+	if err != nil || info.ModTime().Equal(base.ModTime) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if theirs := string(data); hashContent(theirs) != base.Hash {
+		return &ConflictError{
+			Path:     relPath,
+			Theirs:   theirs,
+			Revision: Revision{ModTime: info.ModTime(), Hash: hashContent(theirs)},
+		}
+	}
+	return nil
+}
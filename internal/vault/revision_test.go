@@ -0,0 +1,76 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		external string // written behind the vault's back, "" for nothing
+		conflict bool
+	}{
+		{"unchanged", "", false},
+		{"touched with the same content", "old\n", false},
+		{"changed on disk", "theirs\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "note.md")
+			if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			v, err := NewVault(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.WaitIndex()
+
+			_, base, err := v.ReadFileRevision("note.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.external != "" {
+				if err := os.WriteFile(path, []byte(tt.external), 0644); err != nil {
+					t.Fatal(err)
+				}
+				later := base.ModTime.Add(time.Second)
+				if err := os.Chtimes(path, later, later); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rev, err := v.SaveFile("note.md", "mine\n", base)
+			data, _ := os.ReadFile(path)
+
+			var conflict *ConflictError
+			if tt.conflict {
+				if !errors.As(err, &conflict) {
+					t.Fatalf("SaveFile() error = %v, want a conflict", err)
+				}
+				if conflict.Theirs != tt.external || string(data) != tt.external {
+					t.Errorf("conflict kept %q on disk, theirs %q; want %q", data, conflict.Theirs, tt.external)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "mine\n" {
+				t.Errorf("file = %q, want %q", data, "mine\n")
+			}
+			if rev.Hash != hashContent("mine\n") {
+				t.Error("SaveFile() returned the wrong revision")
+			}
+			// Saving again from the new revision is not a conflict
+			if _, err := v.SaveFile("note.md", "again\n", rev); err != nil {
+				t.Errorf("second SaveFile() error = %v", err)
+			}
+		})
+	}
+}
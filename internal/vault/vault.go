@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/takahashinaoki/obsidiantui/internal/parser"
 )
@@ -18,6 +19,7 @@ type Vault struct {
 	indexed      bool
 	indexing     bool
 	mu           sync.RWMutex
+	indexDone    *sync.Cond // broadcast when an index is complete
 }
 
 type File struct {
@@ -28,7 +30,15 @@ type File struct {
 	Content      string
 	Links        []parser.Link
 	Tags         []string
+	Aliases      []string
+	Headings     []parser.Heading
+	Blocks       []parser.BlockID
+	Words        int
+	Tasks        int // checkbox items, of which TasksDone are ticked
+	TasksDone    int
 	Modified     bool
+	ModTime      time.Time // on-disk modification time when Content was read or written
+	Hash         string    // hash of Content, see Revision
 }
 
 func NewVault(path string) (*Vault, error) {
@@ -51,6 +61,7 @@ func NewVault(path string) (*Vault, error) {
 		Tags:      make(map[string][]string),
 		Backlinks: make(map[string][]string),
 	}
+	v.indexDone = sync.NewCond(&v.mu)
 
 	if err := v.ScanFiles(); err != nil {
 		return nil, err
@@ -139,11 +150,21 @@ func (v *Vault) BuildIndexAsync() {
 		contentStr := string(content)
 		links := parser.ExtractAllLinks(contentStr)
 		fileTags := parser.ExtractUniqueTags(contentStr)
+		aliases := parser.ExtractAliases(contentStr)
+		headings := parser.ExtractHeadings(contentStr)
+		blocks := parser.ExtractBlockIDs(contentStr)
+		words := parser.CountWords(contentStr)
+		tasksDone, tasks := parser.CountTasks(contentStr)
 
 		v.mu.Lock()
 		if f, ok := v.Files[relPath]; ok {
 			f.Links = links
 			f.Tags = fileTags
+			f.Aliases = aliases
+			f.Headings = headings
+			f.Blocks = blocks
+			f.Words = words
+			f.Tasks, f.TasksDone = tasks, tasksDone
 		}
 		v.mu.Unlock()
 
@@ -167,6 +188,17 @@ func (v *Vault) BuildIndexAsync() {
 	v.Backlinks = backlinks
 	v.indexed = true
 	v.indexing = false
+	v.indexDone.Broadcast()
+	v.mu.Unlock()
+}
+
+// WaitIndex blocks until the links, tags and headings of every note are
+// indexed
+func (v *Vault) WaitIndex() {
+	v.mu.Lock()
+	for !v.indexed {
+		v.indexDone.Wait()
+	}
 	v.mu.Unlock()
 }
 
@@ -209,8 +241,17 @@ func (v *Vault) ReadFile(relPath string) (string, error) {
 		return "", os.ErrNotExist
 	}
 
-	if file.Content != "" {
-		return file.Content, nil
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return "", err
+	}
+
+	// Serve the cache only while the file on disk is unchanged
+	v.mu.RLock()
+	cached, fresh := file.Content, file.ModTime.Equal(info.ModTime())
+	v.mu.RUnlock()
+	if cached != "" && fresh {
+		return cached, nil
 	}
 
 	content, err := os.ReadFile(file.Path)
@@ -220,9 +261,11 @@ func (v *Vault) ReadFile(relPath string) (string, error) {
 
 	v.mu.Lock()
 	file.Content = string(content)
+	file.ModTime = info.ModTime()
+	file.Hash = hashContent(file.Content)
 	v.mu.Unlock()
 
-	return file.Content, nil
+	return string(content), nil
 }
 
 func (v *Vault) WriteFile(relPath string, content string) error {
@@ -234,18 +277,56 @@ func (v *Vault) WriteFile(relPath string, content string) error {
 		return os.ErrNotExist
 	}
 
-	if err := os.WriteFile(file.Path, []byte(content), 0644); err != nil {
+	if err := AtomicWriteFile(file.Path, []byte(content), 0644); err != nil {
 		return err
 	}
 
 	v.mu.Lock()
+	v.setContent(file, content, file.Path)
+	v.mu.Unlock()
+
+	return nil
+}
+
+// setContent records content just written to path as the file's own. The
+// caller holds v.mu.
+func (v *Vault) setContent(file *File, content, path string) {
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
 	file.Content = content
+	file.ModTime = modTime
+	file.Hash = hashContent(content)
 	file.Modified = false
 	file.Links = parser.ExtractAllLinks(content)
 	file.Tags = parser.ExtractUniqueTags(content)
-	v.mu.Unlock()
+	file.Aliases = parser.ExtractAliases(content)
+	file.Headings = parser.ExtractHeadings(content)
+	file.Blocks = parser.ExtractBlockIDs(content)
+	file.Words = parser.CountWords(content)
+	file.TasksDone, file.Tasks = parser.CountTasks(content)
+}
 
-	return nil
+// HasFile reports whether relPath is a note or folder in the vault
+func (v *Vault) HasFile(relPath string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	_, ok := v.Files[relPath]
+	return ok
+}
+
+// GetFile returns a copy of the note or folder at relPath
+func (v *Vault) GetFile(relPath string) (File, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	f, ok := v.Files[relPath]
+	if !ok {
+		return File{}, false
+	}
+	return *f, true
 }
 
 func (v *Vault) FindFile(name string) string {
@@ -270,6 +351,23 @@ func (v *Vault) FindFile(name string) string {
 	return ""
 }
 
+// Notes returns a snapshot of every note, sorted by path
+func (v *Vault) Notes() []File {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	notes := make([]File, 0, len(v.Files))
+	for _, f := range v.Files {
+		if !f.IsDir {
+			notes = append(notes, *f)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].RelativePath < notes[j].RelativePath
+	})
+	return notes
+}
+
 func (v *Vault) GetBacklinks(relPath string) []string {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
@@ -281,6 +379,33 @@ func (v *Vault) GetBacklinks(relPath string) []string {
 	return result
 }
 
+// NoteStats is what the index knows about a note, for showing next to it
+type NoteStats struct {
+	ModTime   time.Time // zero until the note is read or written
+	Words     int
+	Tasks     int
+	TasksDone int
+	Backlinks int
+}
+
+// Stats returns the index's counts for a note
+func (v *Vault) Stats(relPath string) NoteStats {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	f, ok := v.Files[relPath]
+	if !ok {
+		return NoteStats{}
+	}
+	return NoteStats{
+		ModTime:   f.ModTime,
+		Words:     f.Words,
+		Tasks:     f.Tasks,
+		TasksDone: f.TasksDone,
+		Backlinks: len(v.Backlinks[relPath]),
+	}
+}
+
 func (v *Vault) GetFilesWithTag(tag string) []string {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
@@ -374,3 +499,9 @@ func (v *Vault) IsIndexed() bool {
 	defer v.mu.RUnlock()
 	return v.indexed
 }
+
+// DataDir returns a directory for ObsidianTUI's own files inside the vault.
+// It lives under a dot-folder so ScanFiles never picks it up as notes.
+func (v *Vault) DataDir(name string) string {
+	return filepath.Join(v.Path, ".obsidiantui", name)
+}
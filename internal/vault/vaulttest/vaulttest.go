@@ -0,0 +1,47 @@
+// Package vaulttest creates vaults in temporary folders for tests.
+package vaulttest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/takahashinaoki/obsidiantui/internal/vault"
+)
+
+// New writes notes, keyed by slash-separated path, to a temporary folder and
+// opens it as a vault. A key ending in "/" makes an empty folder. The index
+// is built in the background as usual; call WaitIndex before relying on it.
+func New(t testing.TB, notes map[string]string) *vault.Vault {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range notes {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err := vault.NewVault(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// Indexed is New with the vault's index built
+func Indexed(t testing.TB, notes map[string]string) *vault.Vault {
+	t.Helper()
+	v := New(t, notes)
+	v.WaitIndex()
+	return v
+}
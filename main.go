@@ -15,7 +15,7 @@ var version = "0.1.0"
 
 func main() {
 	rootCmd := &cobra.Command{
-		Use:     "obsidiantui [vault-path]",
+		Use:     "obsidiantui [vault-path | vault-name]",
 		Short:   "A TUI client for Obsidian vaults",
 		Long:    `ObsidianTUI is a terminal-based viewer and editor for Obsidian vaults.`,
 		Version: version,
@@ -23,6 +23,10 @@ func main() {
 		RunE:    run,
 	}
 
+	rootCmd.AddCommand(newGraphCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newPublishCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -34,19 +38,9 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize config: %w", err)
 	}
 
-	var vaultPath string
-	if len(args) > 0 {
-		vaultPath = args[0]
-	} else {
-		vaultPath = config.GetVaultPath()
-	}
-
-	if vaultPath == "" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
-		}
-		vaultPath = cwd
+	vaultPath, err := resolveVaultPath(args)
+	if err != nil {
+		return err
 	}
 
 	v, err := vault.NewVault(vaultPath)
@@ -54,22 +48,58 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to open vault at %s: %w", vaultPath, err)
 	}
 
-	config.SetVaultPath(vaultPath)
+	if err := config.UseVault(v.Path); err != nil {
+		return fmt.Errorf("failed to load settings for %s: %w", vaultPath, err)
+	}
 	if err := config.Save(); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to save config: %v\n", err)
 	}
 
 	model := ui.NewModel(v)
+	if len(args) == 0 && config.AppConfig.VaultPicker && len(config.AppConfig.Vaults) > 1 {
+		model.PickVaultOnStart()
+	}
 
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
+		tea.WithReportFocus(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("error running program: %w", err)
 	}
+	if m, ok := final.(ui.Model); ok && m.Err() != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", m.Err())
+	}
 
 	return nil
 }
+
+// resolveVaultPath picks the vault from the arguments (a path or the name of
+// a known vault), the config or the working directory
+func resolveVaultPath(args []string) (string, error) {
+	var vaultPath string
+	if len(args) > 0 {
+		vaultPath = args[0]
+		if _, err := os.Stat(vaultPath); err != nil {
+			if vc := config.FindVault(vaultPath); vc != nil {
+				vaultPath = vc.Path
+			}
+		}
+	} else {
+		vaultPath = config.GetVaultPath()
+	}
+
+	if vaultPath == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		vaultPath = cwd
+	}
+
+	return vaultPath, nil
+}